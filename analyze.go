@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/meysam81/parse-dmarc/internal/cli/output"
+	"github.com/meysam81/parse-dmarc/internal/imap"
+	"github.com/meysam81/parse-dmarc/internal/parser"
+	"github.com/urfave/cli/v3"
+)
+
+// reportAnalysis is a parsed report file and its summary statistics
+type reportAnalysis struct {
+	// Source is the file, or the attachment name for reports read from an email
+	Source  string           `json:"source"`
+	Report  *parser.Feedback `json:"report"`
+	Summary analysisSummary  `json:"summary"`
+}
+
+// analysisSummary aggregates the records of a report
+type analysisSummary struct {
+	TotalMessages     int            `json:"total_messages"`
+	CompliantMessages int            `json:"compliant_messages"`
+	ComplianceRate    float64        `json:"compliance_rate"`
+	DKIMPass          int            `json:"dkim_pass"`
+	SPFPass           int            `json:"spf_pass"`
+	SourceIPs         int            `json:"source_ips"`
+	Dispositions      map[string]int `json:"dispositions"`
+}
+
+func analyzeCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "analyze",
+		Usage:     "Parse a DMARC report file and print an analysis, without a config file or database",
+		ArgsUsage: "<file>",
+		Description: "Accepts .xml, .xml.gz and .zip reports, and .eml messages, whose report\n" +
+			"attachments are each analyzed.",
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			path := cmd.Args().First()
+			if path == "" {
+				return cli.Exit("usage: parse-dmarc analyze <file>", 1)
+			}
+
+			analyses, err := analyzeFile(ctx, path)
+			if err != nil {
+				return cli.Exit(err.Error(), 1)
+			}
+
+			out := output.New(os.Stdout, cmd.String("output"))
+			for i, a := range analyses {
+				if i > 0 {
+					_ = out.Text("")
+				}
+				if len(analyses) > 1 {
+					_ = out.Text(fmt.Sprintf("== %s ==", a.Source))
+				}
+				if err := writeReportText(out, a.Report); err != nil {
+					return err
+				}
+				_ = out.Text("")
+				writeSummaryText(out, a.Summary)
+			}
+			return out.JSON(analyses)
+		},
+	}
+}
+
+// analyzeFile parses the reports in the file at path. The container format
+// of a report is detected from its content; emails are recognized by the
+// .eml extension.
+func analyzeFile(ctx context.Context, path string) ([]reportAnalysis, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	type file struct {
+		name string
+		data []byte
+	}
+	files := []file{{name: filepath.Base(path), data: data}}
+	if strings.EqualFold(filepath.Ext(path), ".eml") {
+		attachments, err := imap.ReadAttachments(bytes.NewReader(data), log)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if len(attachments) == 0 {
+			return nil, fmt.Errorf("%s: no DMARC report attachment", path)
+		}
+		files = files[:0]
+		for _, a := range attachments {
+			files = append(files, file{name: a.Filename, data: a.Data})
+		}
+	}
+
+	analyses := make([]reportAnalysis, 0, len(files))
+	for _, f := range files {
+		feedback, err := parser.ParseReportWithContext(ctx, f.data)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", f.name, err)
+		}
+		analyses = append(analyses, reportAnalysis{
+			Source:  f.name,
+			Report:  feedback,
+			Summary: summarizeReport(feedback),
+		})
+	}
+	return analyses, nil
+}
+
+// summarizeReport counts messages by DMARC result and disposition
+func summarizeReport(feedback *parser.Feedback) analysisSummary {
+	summary := analysisSummary{
+		TotalMessages:     feedback.GetTotalMessages(),
+		CompliantMessages: feedback.GetDMARCCompliantCount(),
+		Dispositions:      map[string]int{},
+	}
+	if summary.TotalMessages > 0 {
+		summary.ComplianceRate = float64(summary.CompliantMessages) / float64(summary.TotalMessages) * 100
+	}
+
+	ips := make(map[string]struct{})
+	for _, record := range feedback.Records {
+		row := record.Row
+		ips[row.SourceIP] = struct{}{}
+		if row.PolicyEvaluated.DKIM == "pass" {
+			summary.DKIMPass += row.Count
+		}
+		if row.PolicyEvaluated.SPF == "pass" {
+			summary.SPFPass += row.Count
+		}
+		summary.Dispositions[row.PolicyEvaluated.Disposition] += row.Count
+	}
+	summary.SourceIPs = len(ips)
+	return summary
+}
+
+// writeSummaryText writes the summary statistics of a report
+func writeSummaryText(out output.Writer, s analysisSummary) {
+	_ = out.Text(fmt.Sprintf("Compliance: %.1f%% (%d of %d messages)", s.ComplianceRate, s.CompliantMessages, s.TotalMessages))
+	_ = out.Text(fmt.Sprintf("DKIM pass:  %d", s.DKIMPass))
+	_ = out.Text(fmt.Sprintf("SPF pass:   %d", s.SPFPass))
+	_ = out.Text(fmt.Sprintf("Source IPs: %d", s.SourceIPs))
+
+	dispositions := make([]string, 0, len(s.Dispositions))
+	for d := range s.Dispositions {
+		dispositions = append(dispositions, d)
+	}
+	sort.Strings(dispositions)
+	parts := make([]string, 0, len(dispositions))
+	for _, d := range dispositions {
+		parts = append(parts, d+"="+strconv.Itoa(s.Dispositions[d]))
+	}
+	_ = out.Text("Dispositions: " + strings.Join(parts, " "))
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAnalyzeFile(t *testing.T) {
+	dir := t.TempDir()
+	report := syntheticReport(1)
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write(report)
+	_ = zw.Close()
+
+	eml := "From: noreply-dmarc-support@google.com\r\n" +
+		"Subject: Report domain: example.com\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/mixed; boundary=b\r\n\r\n" +
+		"--b\r\nContent-Type: text/plain\r\n\r\nReport attached\r\n" +
+		"--b\r\nContent-Type: application/gzip\r\n" +
+		"Content-Disposition: attachment; filename=\"google.com!example.com!1609459200!1609545600.xml.gz\"\r\n" +
+		"Content-Transfer-Encoding: base64\r\n\r\n" +
+		base64.StdEncoding.EncodeToString(gz.Bytes()) + "\r\n" +
+		"--b--\r\n"
+
+	files := map[string][]byte{
+		"report.xml":    report,
+		"report.xml.gz": gz.Bytes(),
+		"report.eml":    []byte(eml),
+	}
+	for name, data := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, data, 0o600); err != nil {
+				t.Fatalf("Failed to write file: %v", err)
+			}
+
+			analyses, err := analyzeFile(context.Background(), path)
+			if err != nil {
+				t.Fatalf("Failed to analyze: %v", err)
+			}
+			if len(analyses) != 1 {
+				t.Fatalf("Expected 1 report, got %d", len(analyses))
+			}
+
+			a := analyses[0]
+			if a.Report.ReportMetadata.ReportID != "bench-1" {
+				t.Errorf("Expected report bench-1, got %s", a.Report.ReportMetadata.ReportID)
+			}
+			s := a.Summary
+			if s.TotalMessages != 100 || s.CompliantMessages != 100 || s.ComplianceRate != 100 {
+				t.Errorf("Expected 100 of 100 messages compliant, got %+v", s)
+			}
+			if s.DKIMPass != 100 || s.SPFPass != 100 || s.SourceIPs != 1 || s.Dispositions["none"] != 100 {
+				t.Errorf("Unexpected summary %+v", s)
+			}
+		})
+	}
+
+	t.Run("not a report", func(t *testing.T) {
+		path := filepath.Join(dir, "notes.txt")
+		if err := os.WriteFile(path, []byte("hello"), 0o600); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		if _, err := analyzeFile(context.Background(), path); err == nil {
+			t.Error("Expected error for a file that is not a report")
+		}
+	})
+}
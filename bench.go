@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/meysam81/parse-dmarc/internal/cli/output"
+	"github.com/meysam81/parse-dmarc/internal/parser"
+	"github.com/meysam81/parse-dmarc/internal/storage"
+	"github.com/urfave/cli/v3"
+	"golang.org/x/sync/errgroup"
+)
+
+// syntheticReportXML is the template for generated benchmark reports; the
+// report ID and source IP vary per report
+const syntheticReportXML = `<?xml version="1.0" encoding="UTF-8"?>
+<feedback>
+  <report_metadata>
+    <org_name>google.com</org_name>
+    <email>noreply-dmarc-support@google.com</email>
+    <report_id>bench-%d</report_id>
+    <date_range>
+      <begin>1609459200</begin>
+      <end>1609545600</end>
+    </date_range>
+  </report_metadata>
+  <policy_published>
+    <domain>example.com</domain>
+    <p>none</p>
+    <pct>100</pct>
+  </policy_published>
+  <record>
+    <row>
+      <source_ip>192.0.%d.%d</source_ip>
+      <count>100</count>
+      <policy_evaluated>
+        <disposition>none</disposition>
+        <dkim>pass</dkim>
+        <spf>pass</spf>
+      </policy_evaluated>
+    </row>
+    <identifiers>
+      <header_from>example.com</header_from>
+    </identifiers>
+  </record>
+</feedback>`
+
+// syntheticReport returns the XML of the i-th generated report
+func syntheticReport(i int) []byte {
+	return []byte(fmt.Sprintf(syntheticReportXML, i, (i/250)%250, i%250))
+}
+
+// benchResult is the throughput of one benchmark stage
+type benchResult struct {
+	Stage        string        `json:"stage"`
+	Ops          int           `json:"ops"`
+	Duration     time.Duration `json:"duration_ns"`
+	OpsPerSecond float64       `json:"ops_per_second"`
+	MBPerSecond  float64       `json:"mb_per_second,omitempty"`
+}
+
+func newBenchResult(stage string, ops, bytes int, elapsed time.Duration) benchResult {
+	r := benchResult{Stage: stage, Ops: ops, Duration: elapsed}
+	if secs := elapsed.Seconds(); secs > 0 {
+		r.OpsPerSecond = float64(ops) / secs
+		r.MBPerSecond = float64(bytes) / secs / 1e6
+	}
+	return r
+}
+
+func benchCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "bench",
+		Usage: "Measure parse, insert and query throughput on synthetic reports",
+		Description: "Reports are saved to a temporary database, so the configured database is\n" +
+			"never touched.",
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:  "n",
+				Usage: "Number of synthetic reports",
+				Value: 1000,
+			},
+			&cli.IntFlag{
+				Name:  "workers",
+				Usage: "Number of reports parsed and saved in parallel",
+				Value: 4,
+			},
+		},
+		Action: runBench,
+	}
+}
+
+func runBench(ctx context.Context, cmd *cli.Command) error {
+	n := int(cmd.Int("n"))
+	workers := int(cmd.Int("workers"))
+	if n <= 0 || workers <= 0 {
+		return cli.Exit("--n and --workers must be positive", 1)
+	}
+
+	dir, err := os.MkdirTemp("", "parse-dmarc-bench-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	store, err := storage.NewStorage(filepath.Join(dir, "bench.sqlite"))
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	reports := make([][]byte, n)
+	size := 0
+	for i := range reports {
+		reports[i] = syntheticReport(i)
+		size += len(reports[i])
+	}
+
+	var results []benchResult
+
+	feedbacks := make([]*parser.Feedback, n)
+	start := time.Now()
+	if err := runParallel(workers, n, func(i int) error {
+		f, err := parser.ParseReport(reports[i])
+		feedbacks[i] = f
+		return err
+	}); err != nil {
+		return fmt.Errorf("failed to parse report: %w", err)
+	}
+	results = append(results, newBenchResult("parse", n, size, time.Since(start)))
+
+	start = time.Now()
+	if err := runParallel(workers, n, func(i int) error {
+		return store.SaveReport(feedbacks[i])
+	}); err != nil {
+		return fmt.Errorf("failed to save report: %w", err)
+	}
+	results = append(results, newBenchResult("insert", n, size, time.Since(start)))
+
+	queries := max(n/10, 1)
+	start = time.Now()
+	for i := 0; i < queries; i++ {
+		if _, err := store.GetStatistics(); err != nil {
+			return fmt.Errorf("failed to query statistics: %w", err)
+		}
+	}
+	results = append(results, newBenchResult("statistics", queries, 0, time.Since(start)))
+
+	rows := make([][]string, 0, len(results))
+	for _, r := range results {
+		mbps := "-"
+		if r.MBPerSecond > 0 {
+			mbps = strconv.FormatFloat(r.MBPerSecond, 'f', 2, 64)
+		}
+		rows = append(rows, []string{
+			r.Stage,
+			strconv.Itoa(r.Ops),
+			r.Duration.Round(time.Millisecond).String(),
+			strconv.FormatFloat(r.OpsPerSecond, 'f', 0, 64),
+			mbps,
+		})
+	}
+
+	out := output.New(os.Stdout, cmd.String("output"))
+	_ = out.Table([]string{"STAGE", "OPS", "DURATION", "OPS/S", "MB/S"}, rows)
+	return out.JSON(results)
+}
+
+// runParallel calls fn for 0..n-1 on up to workers goroutines and returns
+// the first error
+func runParallel(workers, n int, fn func(i int) error) error {
+	var g errgroup.Group
+	g.SetLimit(workers)
+	for i := 0; i < n; i++ {
+		g.Go(func() error { return fn(i) })
+	}
+	return g.Wait()
+}
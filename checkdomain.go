@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/meysam81/parse-dmarc/internal/cli/output"
+	"github.com/meysam81/parse-dmarc/internal/storage"
+	"github.com/urfave/cli/v3"
+)
+
+// dmarcRecord holds the tags of a published DMARC TXT record
+type dmarcRecord struct {
+	Raw   string `json:"raw"`
+	P     string `json:"p"`
+	SP    string `json:"sp"`
+	PCT   int    `json:"pct"`
+	RUA   string `json:"rua,omitempty"`
+	RUF   string `json:"ruf,omitempty"`
+	FO    string `json:"fo"`
+	ADKIM string `json:"adkim"`
+	ASPF  string `json:"aspf"`
+}
+
+// policyDiscrepancy is a tag whose DNS value differs from the stored report
+type policyDiscrepancy struct {
+	Tag    string `json:"tag"`
+	DNS    string `json:"dns"`
+	Report string `json:"report"`
+	// Stricter is set when the DNS value enforces more than the report shows
+	Stricter bool `json:"stricter"`
+}
+
+func checkDomainCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "check-domain",
+		Usage:     "Compare a domain's DMARC DNS record with its most recent stored report",
+		ArgsUsage: "<domain>",
+		Description: "Exits with status 1 if the DNS policy is stricter than the policy in the\n" +
+			"most recent report, e.g. p=reject published while reports still show p=none.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "doh-server",
+				Usage: "DNS-over-HTTPS JSON endpoint to query instead of the system resolver, e.g. https://1.1.1.1/dns-query",
+			},
+		},
+		Action: runCheckDomain,
+	}
+}
+
+func runCheckDomain(ctx context.Context, cmd *cli.Command) error {
+	domain := strings.TrimSuffix(strings.ToLower(cmd.Args().First()), ".")
+	if domain == "" {
+		return cli.Exit("usage: parse-dmarc check-domain <domain>", 1)
+	}
+
+	lookupTXT := net.DefaultResolver.LookupTXT
+	if server := cmd.String("doh-server"); server != "" {
+		doh := &dohResolver{server: server, client: &http.Client{Timeout: 10 * time.Second}}
+		lookupTXT = doh.LookupTXT
+	}
+
+	record, err := lookupDMARC(ctx, lookupTXT, domain)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := storage.NewStorage(cfg.Database.Path)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	policy, err := store.GetDomainPolicy(domain)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("no stored report for %s: %v", domain, err), 1)
+	}
+
+	discrepancies := compareDMARCPolicy(record, policy)
+	stricter := false
+	rows := make([][]string, 0, len(discrepancies))
+	for _, d := range discrepancies {
+		stricter = stricter || d.Stricter
+		rows = append(rows, []string{d.Tag, d.DNS, d.Report, strconv.FormatBool(d.Stricter)})
+	}
+
+	out := output.New(os.Stdout, cmd.String("output"))
+	_ = out.Text(fmt.Sprintf("DNS:    %s", record.Raw))
+	if len(discrepancies) == 0 {
+		_ = out.Text("DNS record matches the most recent report")
+	} else {
+		_ = out.Table([]string{"TAG", "DNS", "REPORT", "STRICTER"}, rows)
+	}
+	if err := out.JSON(map[string]interface{}{
+		"domain":        domain,
+		"dns":           record,
+		"report":        policy,
+		"discrepancies": discrepancies,
+	}); err != nil {
+		return err
+	}
+
+	if stricter {
+		return cli.Exit("DNS policy is stricter than the most recent report", 1)
+	}
+	return nil
+}
+
+// lookupDMARC fetches and parses the _dmarc TXT record of domain
+func lookupDMARC(ctx context.Context, lookupTXT func(ctx context.Context, name string) ([]string, error), domain string) (dmarcRecord, error) {
+	txts, err := lookupTXT(ctx, "_dmarc."+domain)
+	if err != nil {
+		return dmarcRecord{}, fmt.Errorf("lookup _dmarc.%s: %w", domain, err)
+	}
+	for _, txt := range txts {
+		if record, ok := parseDMARCRecord(txt); ok {
+			return record, nil
+		}
+	}
+	return dmarcRecord{}, fmt.Errorf("no DMARC record published at _dmarc.%s", domain)
+}
+
+// parseDMARCRecord parses a TXT record starting with v=DMARC1. Tags that are
+// not set take their RFC 7489 defaults.
+func parseDMARCRecord(txt string) (dmarcRecord, bool) {
+	record := dmarcRecord{Raw: txt, PCT: 100, FO: "0", ADKIM: "r", ASPF: "r"}
+	isDMARC := false
+	for i, part := range strings.Split(txt, ";") {
+		tag, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		value = strings.TrimSpace(value)
+		if i == 0 {
+			isDMARC = tag == "v" && strings.EqualFold(value, "DMARC1")
+			if !isDMARC {
+				return dmarcRecord{}, false
+			}
+			continue
+		}
+		switch tag {
+		case "p":
+			record.P = strings.ToLower(value)
+		case "sp":
+			record.SP = strings.ToLower(value)
+		case "pct":
+			if n, err := strconv.Atoi(value); err == nil {
+				record.PCT = n
+			}
+		case "rua":
+			record.RUA = value
+		case "ruf":
+			record.RUF = value
+		case "fo":
+			record.FO = value
+		case "adkim":
+			record.ADKIM = strings.ToLower(value)
+		case "aspf":
+			record.ASPF = strings.ToLower(value)
+		}
+	}
+	if record.SP == "" {
+		record.SP = record.P
+	}
+	return record, isDMARC
+}
+
+// policyStrength orders dispositions from none to reject
+var policyStrength = map[string]int{"none": 0, "quarantine": 1, "reject": 2}
+
+// compareDMARCPolicy lists the tags where the DNS record and the stored
+// report differ. rua and ruf are not part of aggregate reports, so they are
+// never compared.
+func compareDMARCPolicy(record dmarcRecord, policy storage.PolicyInfo) []policyDiscrepancy {
+	reported := dmarcRecord{
+		P:     strings.ToLower(policy.P),
+		SP:    strings.ToLower(policy.SP),
+		PCT:   policy.PCT,
+		FO:    policy.FO,
+		ADKIM: strings.ToLower(policy.ADKIM),
+		ASPF:  strings.ToLower(policy.ASPF),
+	}
+	if reported.SP == "" {
+		reported.SP = reported.P
+	}
+	if reported.FO == "" {
+		reported.FO = "0"
+	}
+	if reported.ADKIM == "" {
+		reported.ADKIM = "r"
+	}
+	if reported.ASPF == "" {
+		reported.ASPF = "r"
+	}
+
+	discrepancies := []policyDiscrepancy{}
+	add := func(tag, dns, report string, stricter bool) {
+		if dns != report {
+			discrepancies = append(discrepancies, policyDiscrepancy{Tag: tag, DNS: dns, Report: report, Stricter: stricter})
+		}
+	}
+	add("p", record.P, reported.P, policyStrength[record.P] > policyStrength[reported.P])
+	add("sp", record.SP, reported.SP, policyStrength[record.SP] > policyStrength[reported.SP])
+	add("pct", strconv.Itoa(record.PCT), strconv.Itoa(reported.PCT), record.PCT > reported.PCT)
+	add("fo", record.FO, reported.FO, false)
+	add("adkim", record.ADKIM, reported.ADKIM, record.ADKIM == "s")
+	add("aspf", record.ASPF, reported.ASPF, record.ASPF == "s")
+	return discrepancies
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/meysam81/parse-dmarc/internal/storage"
+)
+
+func TestParseDMARCRecord(t *testing.T) {
+	record, ok := parseDMARCRecord("v=DMARC1; p=reject; rua=mailto:dmarc@example.com; pct=50; adkim=s")
+	if !ok {
+		t.Fatal("Expected DMARC record to parse")
+	}
+	if record.P != "reject" || record.SP != "reject" {
+		t.Errorf("Expected p and sp reject, got %q and %q", record.P, record.SP)
+	}
+	if record.PCT != 50 || record.ADKIM != "s" || record.ASPF != "r" || record.FO != "0" {
+		t.Errorf("Expected pct 50, adkim s and defaults for the rest, got %+v", record)
+	}
+	if record.RUA != "mailto:dmarc@example.com" {
+		t.Errorf("Expected rua mailto:dmarc@example.com, got %q", record.RUA)
+	}
+
+	if _, ok := parseDMARCRecord("v=spf1 include:_spf.example.com ~all"); ok {
+		t.Error("Expected SPF record not to parse as DMARC")
+	}
+}
+
+func TestCompareDMARCPolicy(t *testing.T) {
+	record, _ := parseDMARCRecord("v=DMARC1; p=reject; sp=none; fo=1")
+	policy := storage.PolicyInfo{Domain: "example.com", P: "none", SP: "none", PCT: 100, FO: "1"}
+
+	discrepancies := compareDMARCPolicy(record, policy)
+	if len(discrepancies) != 1 {
+		t.Fatalf("Expected 1 discrepancy, got %+v", discrepancies)
+	}
+	if d := discrepancies[0]; d.Tag != "p" || d.DNS != "reject" || d.Report != "none" || !d.Stricter {
+		t.Errorf("Expected stricter p=reject over p=none, got %+v", d)
+	}
+
+	policy.P = "reject"
+	if discrepancies := compareDMARCPolicy(record, policy); len(discrepancies) != 0 {
+		t.Errorf("Expected no discrepancies, got %+v", discrepancies)
+	}
+}
+
+func TestCompareDMARCPolicy_PCTZero(t *testing.T) {
+	record, _ := parseDMARCRecord("v=DMARC1; p=quarantine; pct=0")
+	policy := storage.PolicyInfo{Domain: "example.com", P: "quarantine", SP: "quarantine", PCT: 0}
+	if discrepancies := compareDMARCPolicy(record, policy); len(discrepancies) != 0 {
+		t.Errorf("Expected pct=0 to match a report with pct 0, got %+v", discrepancies)
+	}
+
+	// The record omits pct, so it applies to 100%
+	record, _ = parseDMARCRecord("v=DMARC1; p=quarantine")
+	discrepancies := compareDMARCPolicy(record, policy)
+	if len(discrepancies) != 1 {
+		t.Fatalf("Expected 1 discrepancy, got %+v", discrepancies)
+	}
+	if d := discrepancies[0]; d.Tag != "pct" || d.DNS != "100" || d.Report != "0" || !d.Stricter {
+		t.Errorf("Expected stricter pct=100 over pct=0, got %+v", d)
+	}
+}
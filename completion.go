@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+// zshCompletion registers completion through compdef so it works both when
+// sourced and when loaded with eval. SHELL is forced to zsh so urfave/cli
+// annotates candidates with their usage text.
+const zshCompletion = `#compdef %[1]s
+
+_%[2]s() {
+	local -a opts
+	local current=${words[-1]}
+	if [[ "$current" == "-"* ]]; then
+		opts=("${(@f)$(SHELL=zsh ${words[@]:0:#words[@]-1} ${current} --generate-shell-completion)}")
+	else
+		opts=("${(@f)$(SHELL=zsh ${words[@]:0:#words[@]-1} --generate-shell-completion)}")
+	fi
+
+	if [[ "${opts[1]}" != "" ]]; then
+		_describe 'values' opts
+	else
+		_files
+	fi
+}
+
+if (( $+functions[compdef] )); then
+	compdef _%[2]s %[1]s
+fi
+`
+
+// fishCompletion asks the binary for candidates at completion time, so new
+// subcommands and flags are picked up without regenerating the script.
+const fishCompletion = `function __%[2]s_complete
+	set -l args (commandline -opc)
+	set -l current (commandline -ct)
+	if string match -q -- '-*' $current
+		env SHELL=zsh $args $current --generate-shell-completion 2>/dev/null | string replace ':' \t
+	else
+		env SHELL=zsh $args --generate-shell-completion 2>/dev/null | string replace ':' \t
+	end
+end
+
+complete -c %[1]s -f -a '(__%[2]s_complete)'
+`
+
+const completionDescription = `Print a shell completion script to stdout.
+
+bash:
+   eval "$(%[1]s completion bash)"
+
+zsh:
+   eval "$(%[1]s completion zsh)"
+
+fish:
+   %[1]s completion fish | source`
+
+// configureCompletionCommand exposes the built-in completion command and
+// replaces its zsh and fish scripts; bash keeps the urfave/cli script
+func configureCompletionCommand(c *cli.Command) {
+	appName := c.Root().Name
+	funcName := strings.ReplaceAll(appName, "-", "_")
+	builtin := c.Action
+
+	c.Hidden = false
+	c.Usage = "Print shell completion script (bash, zsh, fish)"
+	c.Description = fmt.Sprintf(completionDescription, appName)
+	c.ArgsUsage = "<bash|zsh|fish>"
+	c.Action = func(ctx context.Context, cmd *cli.Command) error {
+		switch cmd.Args().First() {
+		case "zsh":
+			_, err := fmt.Fprintf(cmd.Root().Writer, zshCompletion, appName, funcName)
+			return err
+		case "fish":
+			_, err := fmt.Fprintf(cmd.Root().Writer, fishCompletion, appName, funcName)
+			return err
+		default:
+			return builtin(ctx, cmd)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/meysam81/parse-dmarc/internal/cli/output"
+	"github.com/meysam81/parse-dmarc/internal/config"
+	"github.com/meysam81/parse-dmarc/internal/notifications"
+	"github.com/meysam81/parse-dmarc/internal/storage"
+	"github.com/urfave/cli/v3"
+)
+
+// digestDeclineThreshold is the drop in percentage points, compared to the
+// previous period, at which a domain is highlighted in the digest
+const digestDeclineThreshold = 5.0
+
+func digestCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "digest",
+		Usage: "Email a per-domain compliance summary through the configured SMTP channel",
+		Description: "Compliance for the period is compared with the period before it, and domains\n" +
+			"that dropped by more than 5 percentage points are highlighted.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "period",
+				Usage: "Length of the reporting period, in days (7d) or as a Go duration (36h)",
+				Value: "7d",
+			},
+			&cli.StringSliceFlag{
+				Name:  "to",
+				Usage: "Recipient address, overriding the SMTP channel recipients (repeatable)",
+			},
+		},
+		Action: runDigest,
+	}
+}
+
+func runDigest(ctx context.Context, cmd *cli.Command) error {
+	period, err := parsePeriod(cmd.String("period"))
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	smtpCfg, ok := digestSMTPConfig(cfg.Notifications.Channels)
+	if !ok {
+		return cli.Exit("no smtp notification channel is configured", 1)
+	}
+	if to := cmd.StringSlice("to"); len(to) > 0 {
+		smtpCfg.To = to
+	}
+	if len(smtpCfg.To) == 0 {
+		return cli.Exit("no digest recipients: set --to or the smtp channel recipients", 1)
+	}
+
+	store, err := storage.NewStorage(cfg.Database.Path)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	until := time.Now().UTC()
+	since := until.Add(-period)
+	rows, err := buildDigest(store, since, until)
+	if err != nil {
+		return fmt.Errorf("failed to build digest: %w", err)
+	}
+
+	html, err := renderDigest(rows, since, until)
+	if err != nil {
+		return fmt.Errorf("failed to render digest: %w", err)
+	}
+
+	sender := notifications.NewSMTP(notifications.SMTPOptions{
+		Host:     smtpCfg.Host,
+		Port:     smtpCfg.Port,
+		Username: smtpCfg.Username,
+		Password: smtpCfg.Password,
+		From:     smtpCfg.From,
+		To:       smtpCfg.To,
+	})
+	subject := fmt.Sprintf("DMARC digest %s - %s", since.Format("2006-01-02"), until.Format("2006-01-02"))
+	if err := sender.SendHTML(ctx, subject, html); err != nil {
+		return fmt.Errorf("failed to send digest: %w", err)
+	}
+
+	declined := 0
+	for _, row := range rows {
+		if row.Declined {
+			declined++
+		}
+	}
+
+	out := output.New(os.Stdout, cmd.String("output"))
+	_ = out.Text(fmt.Sprintf("Sent digest for %d domains (%d declined) to %s", len(rows), declined, strings.Join(smtpCfg.To, ", ")))
+	return out.JSON(map[string]interface{}{
+		"recipients": smtpCfg.To,
+		"domains":    len(rows),
+		"declined":   declined,
+	})
+}
+
+// parsePeriod parses a number of days such as "7d", or a Go duration
+func parsePeriod(s string) (time.Duration, error) {
+	var d time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid period %q", s)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("invalid period %q", s)
+		}
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("period must be positive, got %q", s)
+	}
+	return d, nil
+}
+
+// digestSMTPConfig returns the first SMTP notification channel
+func digestSMTPConfig(channels []config.NotificationChannel) (config.SMTPConfig, bool) {
+	for _, ch := range channels {
+		if ch.Type == config.ChannelSMTP {
+			return ch.SMTP, true
+		}
+	}
+	return config.SMTPConfig{}, false
+}
+
+// digestRow is one domain in the digest
+type digestRow struct {
+	storage.DomainStats
+	// PreviousRate is the compliance rate in the preceding period, if any
+	PreviousRate *float64
+	Declined     bool
+}
+
+// Change returns the compliance change in percentage points since the
+// previous period
+func (r digestRow) Change() float64 {
+	if r.PreviousRate == nil {
+		return 0
+	}
+	return r.ComplianceRate - *r.PreviousRate
+}
+
+// buildDigest compares per-domain compliance in [since, until) with the
+// period of equal length before it. Declined domains sort first.
+func buildDigest(store *storage.Storage, since, until time.Time) ([]digestRow, error) {
+	current, err := store.GetDomainStatsBetween(since, until)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := store.GetDomainStatsBetween(since.Add(-until.Sub(since)), since)
+	if err != nil {
+		return nil, err
+	}
+
+	previousRates := make(map[string]float64, len(previous))
+	for _, ds := range previous {
+		if ds.TotalMessages > 0 {
+			previousRates[ds.Domain] = ds.ComplianceRate
+		}
+	}
+
+	rows := make([]digestRow, 0, len(current))
+	for _, ds := range current {
+		row := digestRow{DomainStats: ds}
+		if rate, ok := previousRates[ds.Domain]; ok {
+			row.PreviousRate = &rate
+			row.Declined = row.Change() < -digestDeclineThreshold
+		}
+		rows = append(rows, row)
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Declined != rows[j].Declined {
+			return rows[i].Declined
+		}
+		return rows[i].Domain < rows[j].Domain
+	})
+	return rows, nil
+}
+
+var digestTemplate = template.Must(template.New("digest").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif">
+<h2>DMARC digest</h2>
+<p>{{.Since.Format "2006-01-02"}} to {{.Until.Format "2006-01-02"}}</p>
+{{if .Rows}}
+<table cellpadding="6" style="border-collapse: collapse">
+<tr style="text-align: left"><th>Domain</th><th>Messages</th><th>Compliance</th><th>Trend</th></tr>
+{{range .Rows}}
+<tr{{if .Declined}} style="background: #fde2e2"{{end}}>
+<td>{{.Domain}}</td>
+<td>{{.TotalMessages}}</td>
+<td>{{printf "%.1f%%" .ComplianceRate}}</td>
+<td>{{if .PreviousRate}}{{printf "%+.1f" .Change}} pts{{else}}new{{end}}</td>
+</tr>
+{{end}}
+</table>
+<p>Highlighted domains dropped more than {{.Threshold}} percentage points since the previous period.</p>
+{{else}}
+<p>No reports were received during this period.</p>
+{{end}}
+</body>
+</html>
+`))
+
+// renderDigest renders the digest email body
+func renderDigest(rows []digestRow, since, until time.Time) (string, error) {
+	var buf bytes.Buffer
+	err := digestTemplate.Execute(&buf, map[string]interface{}{
+		"Since":     since,
+		"Until":     until,
+		"Rows":      rows,
+		"Threshold": digestDeclineThreshold,
+	})
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
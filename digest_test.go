@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/meysam81/parse-dmarc/internal/storage"
+)
+
+func TestParsePeriod(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"7d", 7 * 24 * time.Hour, false},
+		{"36h", 36 * time.Hour, false},
+		{"0d", 0, true},
+		{"-1h", 0, true},
+		{"week", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parsePeriod(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parsePeriod(%q): expected error %v, got %v", tt.in, tt.wantErr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parsePeriod(%q): expected %s, got %s", tt.in, tt.want, got)
+		}
+	}
+}
+
+func TestRenderDigest(t *testing.T) {
+	previous := 99.0
+	rows := []digestRow{
+		{DomainStats: storage.DomainStats{Domain: "example.com", TotalMessages: 100, ComplianceRate: 90}, PreviousRate: &previous, Declined: true},
+		{DomainStats: storage.DomainStats{Domain: "example.org", TotalMessages: 10, ComplianceRate: 100}},
+	}
+
+	html, err := renderDigest(rows, time.Now().AddDate(0, 0, -7), time.Now())
+	if err != nil {
+		t.Fatalf("Failed to render digest: %v", err)
+	}
+	if !strings.Contains(html, "-9.0 pts") {
+		t.Errorf("Expected trend -9.0 pts in digest, got %s", html)
+	}
+	if strings.Count(html, "background: #fde2e2") != 1 {
+		t.Errorf("Expected exactly one highlighted domain")
+	}
+}
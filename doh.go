@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// dnsTypeTXT is the DNS resource record type of TXT records
+const dnsTypeTXT = 16
+
+// dohResolver looks up TXT records through a DNS-over-HTTPS server that
+// speaks the JSON API served by Cloudflare and Google, rather than the
+// binary RFC 8484 wire format
+type dohResolver struct {
+	server string
+	client *http.Client
+}
+
+// dohResponse is the subset of a DNS JSON API response used for TXT lookups
+type dohResponse struct {
+	Status int `json:"Status"`
+	Answer []struct {
+		Type int    `json:"type"`
+		Data string `json:"data"`
+	} `json:"Answer"`
+}
+
+// LookupTXT returns the TXT records of name, with the character strings of
+// each record joined as net.LookupTXT does
+func (r *dohResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	u, err := url.Parse(r.server)
+	if err != nil {
+		return nil, fmt.Errorf("parse DoH server URL: %w", err)
+	}
+	q := u.Query()
+	q.Set("name", name)
+	q.Set("type", "TXT")
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/dns-json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("query %s: %w", r.server, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("query %s: unexpected status %s", r.server, resp.Status)
+	}
+
+	var body dohResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decode DoH response: %w", err)
+	}
+	// Status is the DNS RCODE; 3 is NXDOMAIN
+	if body.Status != 0 {
+		return nil, fmt.Errorf("lookup %s: DNS status %d", name, body.Status)
+	}
+
+	var txts []string
+	for _, answer := range body.Answer {
+		if answer.Type == dnsTypeTXT {
+			txts = append(txts, joinTXTStrings(answer.Data))
+		}
+	}
+	if len(txts) == 0 {
+		return nil, fmt.Errorf("lookup %s: no TXT records", name)
+	}
+	return txts, nil
+}
+
+// joinTXTStrings joins the quoted character strings of a TXT record, e.g.
+// `"v=DMARC1; " "p=none"`, into one string
+func joinTXTStrings(data string) string {
+	if !strings.HasPrefix(data, `"`) {
+		return data
+	}
+	var b strings.Builder
+	inQuote, escaped := false, false
+	for _, c := range data {
+		switch {
+		case escaped:
+			b.WriteRune(c)
+			escaped = false
+		case c == '\\' && inQuote:
+			escaped = true
+		case c == '"':
+			inQuote = !inQuote
+		case inQuote:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
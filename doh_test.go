@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDOHResolver_LookupTXT(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("name") != "_dmarc.example.com" || r.URL.Query().Get("type") != "TXT" {
+			t.Errorf("Unexpected query %q", r.URL.RawQuery)
+		}
+		if r.Header.Get("Accept") != "application/dns-json" {
+			t.Errorf("Expected Accept application/dns-json, got %q", r.Header.Get("Accept"))
+		}
+		w.Header().Set("Content-Type", "application/dns-json")
+		_, _ = w.Write([]byte(`{"Status":0,"Answer":[
+			{"name":"_dmarc.example.com","type":5,"data":"example.net."},
+			{"name":"_dmarc.example.com","type":16,"data":"\"v=DMARC1; p=reject; \" \"rua=mailto:d@example.com\""}
+		]}`))
+	}))
+	defer srv.Close()
+
+	r := &dohResolver{server: srv.URL, client: srv.Client()}
+	txts, err := r.LookupTXT(context.Background(), "_dmarc.example.com")
+	if err != nil {
+		t.Fatalf("Failed to look up TXT: %v", err)
+	}
+	if len(txts) != 1 || txts[0] != "v=DMARC1; p=reject; rua=mailto:d@example.com" {
+		t.Errorf("Expected joined DMARC record, got %q", txts)
+	}
+}
+
+func TestDOHResolver_NXDomain(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Status":3}`))
+	}))
+	defer srv.Close()
+
+	r := &dohResolver{server: srv.URL, client: srv.Client()}
+	if _, err := r.LookupTXT(context.Background(), "_dmarc.missing.example"); err == nil {
+		t.Error("Expected error for NXDOMAIN")
+	}
+}
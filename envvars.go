@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/meysam81/parse-dmarc/internal/cli/output"
+	"github.com/meysam81/parse-dmarc/internal/config"
+	"github.com/urfave/cli/v3"
+)
+
+func listEnvVarsCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "list-env-vars",
+		Usage: "Print the environment variables that set configuration fields",
+		Description: "PARSE_DMARC_<PATH> variables override the config file. Aliases such as\n" +
+			"IMAP_HOST are read before the config file, which takes precedence over them.",
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			vars := config.EnvVars()
+
+			rows := make([][]string, 0, len(vars))
+			for _, v := range vars {
+				rows = append(rows, []string{v.Name, v.Type, v.Path, v.Alias})
+			}
+
+			out := output.New(os.Stdout, cmd.String("output"))
+			_ = out.Table([]string{"VARIABLE", "TYPE", "PATH", "ALIAS"}, rows)
+			return out.JSON(vars)
+		},
+	}
+}
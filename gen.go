@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/meysam81/parse-dmarc/internal/scaffold"
+	"github.com/urfave/cli/v3"
+)
+
+func genDockerComposeCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "gen-docker-compose",
+		Usage: "Print a docker-compose.yml based on the current configuration",
+		Description: "IMAP settings and ports are taken from the configuration file and\n" +
+			"environment. The IMAP password is never written; set IMAP_PASSWORD in a\n" +
+			".env file next to the generated file instead.",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "with-monitoring",
+				Usage: "Include a Prometheus and Grafana stack",
+			},
+			&cli.StringFlag{
+				Name:  "image",
+				Usage: "Container image to use",
+				Value: scaffold.DefaultImage,
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			configPath := cmd.String("config")
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			opts := scaffold.ComposeOptions{
+				Image:          cmd.String("image"),
+				IMAPHost:       cfg.IMAP.Host,
+				IMAPPort:       cfg.IMAP.Port,
+				IMAPUsername:   cfg.IMAP.Username,
+				IMAPMailbox:    cfg.IMAP.Mailbox,
+				IMAPUseTLS:     cfg.IMAP.UseTLS,
+				ServerPort:     cfg.Server.Port,
+				WithMonitoring: cmd.Bool("with-monitoring"),
+			}
+			// Mount the config file only if it exists and the compose file can
+			// reference it relative to the current directory
+			if _, err := os.Stat(configPath); err == nil && !filepath.IsAbs(configPath) {
+				opts.ConfigFile = filepath.ToSlash(filepath.Clean(configPath))
+			}
+
+			return scaffold.DockerCompose(os.Stdout, opts)
+		},
+	}
+}
+
+func genAlertingRulesCommand() *cli.Command {
+	defaults := scaffold.DefaultAlertingRulesOptions()
+	return &cli.Command{
+		Name:  "gen-alerting-rules",
+		Usage: "Print Prometheus alerting rules for parse-dmarc metrics",
+		Description: "The rules cover low compliance, missing reports, IMAP fetch errors and\n" +
+			"database size. Load the file from rule_files in prometheus.yml and route\n" +
+			"the alerts with Alertmanager.",
+		Flags: []cli.Flag{
+			// --output already selects the output format for every subcommand
+			&cli.StringFlag{
+				Name:    "dest",
+				Aliases: []string{"d"},
+				Usage:   "Write the rules to this file instead of stdout",
+			},
+			&cli.FloatFlag{
+				Name:  "min-compliance",
+				Usage: "Alert when the compliance rate in percent drops below this",
+				Value: defaults.MinCompliance,
+				Validator: func(v float64) error {
+					if v <= 0 || v > 100 {
+						return fmt.Errorf("must be between 0 and 100, got %g", v)
+					}
+					return nil
+				},
+			},
+			&cli.DurationFlag{
+				Name:  "compliance-for",
+				Usage: "How long compliance must stay low before alerting",
+				Value: defaults.ComplianceFor,
+			},
+			&cli.DurationFlag{
+				Name:  "no-reports-for",
+				Usage: "Alert when no new reports were stored for this long",
+				Value: defaults.NoReportsFor,
+			},
+			&cli.FloatFlag{
+				Name:  "max-fetch-errors",
+				Usage: "Alert when IMAP fetch errors per minute exceed this",
+				Value: defaults.MaxFetchErrorsPerMinute,
+			},
+			&cli.DurationFlag{
+				Name:  "fetch-errors-for",
+				Usage: "How long fetch errors must persist before alerting",
+				Value: defaults.FetchErrorsFor,
+			},
+			&cli.FloatFlag{
+				Name:  "max-database-size",
+				Usage: "Alert when the database grows beyond this many GiB",
+				Value: float64(defaults.MaxDatabaseBytes) / (1 << 30),
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			opts := scaffold.AlertingRulesOptions{
+				MinCompliance:           cmd.Float("min-compliance"),
+				ComplianceFor:           cmd.Duration("compliance-for"),
+				NoReportsFor:            cmd.Duration("no-reports-for"),
+				MaxFetchErrorsPerMinute: cmd.Float("max-fetch-errors"),
+				FetchErrorsFor:          cmd.Duration("fetch-errors-for"),
+				MaxDatabaseBytes:        int64(cmd.Float("max-database-size") * (1 << 30)),
+			}
+
+			dest := cmd.String("dest")
+			if dest == "" {
+				return scaffold.AlertingRules(os.Stdout, opts)
+			}
+			f, err := os.Create(dest)
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %w", dest, err)
+			}
+			if err := scaffold.AlertingRules(f, opts); err != nil {
+				_ = f.Close()
+				return err
+			}
+			return f.Close()
+		},
+	}
+}
+
+func genKubernetesManifestsCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "gen-kubernetes-manifests",
+		Usage: "Print Kubernetes manifests based on the current configuration",
+		Description: "Renders a ConfigMap, a Secret template for the IMAP password, a\n" +
+			"PersistentVolumeClaim for the database, a Deployment, a Service, a\n" +
+			"ServiceMonitor for the Prometheus operator and a HorizontalPodAutoscaler.\n" +
+			"IMAP settings and the server port are taken from the configuration; the\n" +
+			"IMAP password is never written.",
+		Flags: []cli.Flag{
+			// --output already selects the output format for every subcommand
+			&cli.StringFlag{
+				Name:    "dest",
+				Aliases: []string{"d"},
+				Usage:   "Write one file per manifest into this directory instead of stdout",
+			},
+			&cli.StringFlag{
+				Name:  "namespace",
+				Usage: "Namespace of the generated resources",
+				Value: "default",
+			},
+			&cli.StringFlag{
+				Name:  "image",
+				Usage: "Container image to use",
+				Value: scaffold.DefaultImage,
+			},
+			&cli.IntFlag{
+				Name:  "replicas",
+				Usage: "Number of replicas, also the autoscaler minimum",
+				Value: 1,
+				Validator: func(n int) error {
+					if n < 1 {
+						return fmt.Errorf("must be at least 1, got %d", n)
+					}
+					return nil
+				},
+			},
+			&cli.IntFlag{
+				Name:  "max-replicas",
+				Usage: "Autoscaler maximum (default: --replicas)",
+			},
+			&cli.StringFlag{
+				Name:  "storage-size",
+				Usage: "Size of the database volume",
+				Value: "1Gi",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			manifests, err := scaffold.KubernetesManifests(scaffold.KubernetesOptions{
+				Namespace:    cmd.String("namespace"),
+				Image:        cmd.String("image"),
+				Replicas:     cmd.Int("replicas"),
+				MaxReplicas:  cmd.Int("max-replicas"),
+				StorageSize:  cmd.String("storage-size"),
+				IMAPHost:     cfg.IMAP.Host,
+				IMAPPort:     cfg.IMAP.Port,
+				IMAPUsername: cfg.IMAP.Username,
+				IMAPMailbox:  cfg.IMAP.Mailbox,
+				IMAPUseTLS:   cfg.IMAP.UseTLS,
+				ServerPort:   cfg.Server.Port,
+			})
+			if err != nil {
+				return err
+			}
+
+			dest := cmd.String("dest")
+			if dest == "" {
+				for i, m := range manifests {
+					if i > 0 {
+						_, _ = fmt.Fprintln(os.Stdout, "---")
+					}
+					if _, err := os.Stdout.Write(m.Content); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+
+			if err := os.MkdirAll(dest, 0o755); err != nil {
+				return fmt.Errorf("failed to create %s: %w", dest, err)
+			}
+			for _, m := range manifests {
+				path := filepath.Join(dest, m.Name)
+				if err := os.WriteFile(path, m.Content, 0o644); err != nil {
+					return fmt.Errorf("failed to write %s: %w", path, err)
+				}
+			}
+			log.Info().Str("dir", dest).Int("files", len(manifests)).Msg("kubernetes manifests written")
+			return nil
+		},
+	}
+}
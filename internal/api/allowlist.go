@@ -0,0 +1,40 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// SetAllowedCIDRs restricts access to clients whose IP falls within one of
+// the given IPv4 or IPv6 CIDR ranges. An empty list allows all clients.
+func (s *Server) SetAllowedCIDRs(cidrs []string) error {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("parse allowed CIDR %q: %w", cidr, err)
+		}
+		nets = append(nets, ipNet)
+	}
+	s.allowedNets = nets
+	return nil
+}
+
+// cidrMiddleware rejects requests from IPs outside nets with 403 Forbidden
+func (s *Server) cidrMiddleware(nets []*net.IPNet, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := net.ParseIP(remoteIP(r))
+		if ip != nil {
+			for _, n := range nets {
+				if n.Contains(ip) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+		}
+
+		s.log.Warn().Str("client_ip", remoteIP(r)).Str("path", r.URL.Path).Msg("blocked request from disallowed IP")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+	})
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+
+	"github.com/meysam81/parse-dmarc/internal/storage"
+)
+
+// maxAnnotationSize bounds the size of an annotation request body
+const maxAnnotationSize = 64 << 10
+
+// annotationRequest is the body of POST /api/reports/:id/annotations
+type annotationRequest struct {
+	Author string `json:"author"`
+	Body   string `json:"body"`
+}
+
+// handleReportAnnotations lists (GET) or adds (POST) the annotations of the
+// report with row ID idStr. When the request is authenticated, the author is
+// the authenticated user rather than the author in the body.
+func (s *Server) handleReportAnnotations(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid report ID", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		annotations, err := s.storage.GetAnnotations(id)
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "Report not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.writeJSON(w, annotations)
+
+	case http.MethodPost:
+		r.Body = http.MaxBytesReader(w, r.Body, maxAnnotationSize)
+		var req annotationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		annotation := &storage.Annotation{
+			ReportID: id,
+			Author:   strings.TrimSpace(req.Author),
+			Body:     strings.TrimSpace(req.Body),
+		}
+		if actor := storage.ActorFromContext(r.Context()); actor != storage.ActorSystem {
+			annotation.Author = actor
+		}
+		if annotation.Author == "" || annotation.Body == "" {
+			http.Error(w, "author and body are required", http.StatusBadRequest)
+			return
+		}
+
+		err := s.storage.AddAnnotation(annotation)
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "Report not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		s.writeJSON(w, annotation)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/meysam81/parse-dmarc/internal/parser"
+	"github.com/meysam81/parse-dmarc/internal/storage"
+)
+
+const testReportXML = `<?xml version="1.0" encoding="UTF-8"?>
+<feedback>
+  <report_metadata>
+    <org_name>google.com</org_name>
+    <email>noreply-dmarc-support@google.com</email>
+    <report_id>annotations-1</report_id>
+    <date_range>
+      <begin>1700000000</begin>
+      <end>1700086400</end>
+    </date_range>
+  </report_metadata>
+  <policy_published>
+    <domain>example.com</domain>
+    <p>none</p>
+  </policy_published>
+  <record>
+    <row>
+      <source_ip>192.0.2.1</source_ip>
+      <count>1</count>
+      <policy_evaluated>
+        <disposition>none</disposition>
+        <dkim>pass</dkim>
+        <spf>pass</spf>
+      </policy_evaluated>
+    </row>
+    <identifiers>
+      <header_from>example.com</header_from>
+    </identifiers>
+  </record>
+</feedback>`
+
+// newTestServer returns a server backed by an in-memory database holding
+// one report, and that report's row ID
+func newTestServer(t *testing.T) (*Server, int64) {
+	t.Helper()
+	store, err := storage.NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	feedback, err := parser.ParseReport([]byte(testReportXML))
+	if err != nil {
+		t.Fatalf("Failed to parse report: %v", err)
+	}
+	if err := store.SaveReport(feedback); err != nil {
+		t.Fatalf("Failed to save report: %v", err)
+	}
+	reports, err := store.GetReports(storage.ReportFilter{}, 1, 0)
+	if err != nil || len(reports) != 1 {
+		t.Fatalf("Failed to get saved report: %v", err)
+	}
+
+	nop := zerolog.Nop()
+	return NewServer(store, "127.0.0.1", 0, nil, &nop), reports[0].ID
+}
+
+func TestHandleReportAnnotations_Post(t *testing.T) {
+	s, id := newTestServer(t)
+	idStr := fmt.Sprint(id)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/reports/"+idStr+"/annotations",
+		strings.NewReader(`{"author":"alice","body":"known forwarder"}`))
+	rec := httptest.NewRecorder()
+	s.handleReportAnnotations(rec, req, idStr)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected Content-Type application/json, got %q", ct)
+	}
+	if !strings.Contains(rec.Body.String(), `"known forwarder"`) {
+		t.Errorf("Expected the annotation in the response, got %s", rec.Body.String())
+	}
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/meysam81/parse-dmarc/internal/mcp/oauth"
+	"github.com/meysam81/parse-dmarc/internal/storage"
+)
+
+// BasicAuthMiddleware requires HTTP Basic credentials matching username and
+// the bcrypt passwordHash. Public paths such as /metrics are not protected.
+// Authenticated requests are attributed to username in the audit log.
+func BasicAuthMiddleware(username, passwordHash string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if publicPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		requireBasicAuth(username, passwordHash, next).ServeHTTP(w, r)
+	})
+}
+
+// requireBasicAuth rejects requests whose Basic credentials don't match
+// username and the bcrypt passwordHash
+func requireBasicAuth(username, passwordHash string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		if !ok ||
+			subtle.ConstantTimeCompare([]byte(user), []byte(username)) != 1 ||
+			bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password)) != nil {
+			w.Header().Set("WWW-Authenticate", `Basic realm="parse-dmarc", charset="UTF-8"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		ctx := storage.WithActor(r.Context(), user)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// authMiddleware applies the configured JWT and Basic Auth checks. When
+// both are set, JWT alone guards /api/*, since a request carries a single
+// Authorization header; Basic Auth still guards the dashboard. The webhook
+// has its own API key check, so push senders need no Basic credentials.
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	handler := next
+	if s.jwtVerifier != nil {
+		handler = s.jwtMiddleware(s.jwtVerifier, s.apiKeys, handler)
+	}
+	if s.basicAuthUser != "" {
+		unprotected := handler
+		protected := BasicAuthMiddleware(s.basicAuthUser, s.basicAuthHash, handler)
+		handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			jwtOnly := s.jwtVerifier != nil && strings.HasPrefix(r.URL.Path, "/api/")
+			if jwtOnly || (s.webhookPath != "" && r.URL.Path == s.webhookPath) {
+				unprotected.ServeHTTP(w, r)
+				return
+			}
+			protected.ServeHTTP(w, r)
+		})
+	}
+	return handler
+}
+
+// jwtMiddleware requires a valid bearer token on /api/* routes. Requests
+// carrying one of apiKeys in the X-API-Key header are let through without a
+// token. The token subject, or the API key's position in apiKeys, is recorded
+// as the audit actor.
+func (s *Server) jwtMiddleware(verifier oauth.TokenVerifier, apiKeys []string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if key := r.Header.Get("X-API-Key"); key != "" {
+			i := matchAPIKey(key, apiKeys)
+			if i < 0 {
+				bearerUnauthorized(w, "invalid_token", "Invalid API key")
+				return
+			}
+			ctx := storage.WithActor(r.Context(), fmt.Sprintf("api-key-%d", i+1))
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		token = strings.TrimSpace(token)
+		if !ok || token == "" {
+			bearerUnauthorized(w, "invalid_request", "Bearer token required")
+			return
+		}
+
+		info, err := verifier.Verify(r.Context(), token)
+		if err != nil {
+			s.log.Debug().Err(err).Msg("token verification failed")
+			bearerUnauthorized(w, "invalid_token", "Token verification failed")
+			return
+		}
+
+		ctx := oauth.ContextWithTokenInfo(r.Context(), info)
+		ctx = storage.WithActor(ctx, info.Subject)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// bearerUnauthorized sends a 401 response with an RFC 6750 challenge
+func bearerUnauthorized(w http.ResponseWriter, errorCode, description string) {
+	w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+		`Bearer realm="parse-dmarc", error="%s", error_description="%s"`, errorCode, description))
+	http.Error(w, description, http.StatusUnauthorized)
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/meysam81/parse-dmarc/internal/mcp/oauth"
+)
+
+// testAuthMux routes the webhook and the report list like Start does
+func testAuthMux(s *Server) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/reports", s.handleReports)
+	mux.HandleFunc(s.webhookPath, s.handleWebhook)
+	return s.authMiddleware(mux)
+}
+
+func setTestBasicAuth(t *testing.T, s *Server) {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("dashboard-password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	s.SetBasicAuth("admin", string(hash))
+}
+
+func TestAuthMiddleware_WebhookWithBasicAuth(t *testing.T) {
+	s, _ := newTestServer(t)
+	setTestBasicAuth(t, s)
+	if err := s.SetWebhook("/ingest", []string{"ingest-key"}); err != nil {
+		t.Fatalf("Failed to set webhook: %v", err)
+	}
+	handler := testAuthMux(s)
+
+	body := strings.Replace(testReportXML, "annotations-1", "webhook-basic-1", 1)
+	req := httptest.NewRequest(http.MethodPost, "/ingest", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/xml")
+	req.Header.Set("X-API-Key", "ingest-key")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Errorf("Expected the webhook to accept the ingest key alone, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/ingest", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/xml")
+	req.SetBasicAuth("admin", "dashboard-password")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected the webhook to require its API key, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/reports", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected the API to still require Basic Auth, got %d", rec.Code)
+	}
+}
+
+// staticVerifier accepts a single token
+type staticVerifier struct{ token string }
+
+func (v staticVerifier) Verify(_ context.Context, token string) (*oauth.TokenInfo, error) {
+	if token != v.token {
+		return nil, errors.New("unknown token")
+	}
+	return &oauth.TokenInfo{Subject: "alice"}, nil
+}
+
+func TestAuthMiddleware_BasicAuthAndJWT(t *testing.T) {
+	s, _ := newTestServer(t)
+	setTestBasicAuth(t, s)
+	s.SetJWTAuth(staticVerifier{token: "good-token"}, []string{"api-key"})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/reports", s.handleReports)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+	handler := s.authMiddleware(mux)
+
+	tests := []struct {
+		name   string
+		path   string
+		header func(*http.Request)
+		want   int
+	}{
+		{"api with bearer token", "/api/reports", func(r *http.Request) { r.Header.Set("Authorization", "Bearer good-token") }, http.StatusOK},
+		{"api with API key", "/api/reports", func(r *http.Request) { r.Header.Set("X-API-Key", "api-key") }, http.StatusOK},
+		{"api with bad token", "/api/reports", func(r *http.Request) { r.Header.Set("Authorization", "Bearer bad-token") }, http.StatusUnauthorized},
+		{"api with Basic only", "/api/reports", func(r *http.Request) { r.SetBasicAuth("admin", "dashboard-password") }, http.StatusUnauthorized},
+		{"dashboard with Basic", "/", func(r *http.Request) { r.SetBasicAuth("admin", "dashboard-password") }, http.StatusOK},
+		{"dashboard without credentials", "/", func(*http.Request) {}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			tt.header(req)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("Expected status %d, got %d: %s", tt.want, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
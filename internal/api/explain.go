@@ -0,0 +1,37 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/meysam81/parse-dmarc/internal/parser"
+	"github.com/meysam81/parse-dmarc/internal/storage"
+)
+
+// handleReportExplain returns a plain-language explanation of the report
+// with row ID idStr: a summary and its main issues with recommendations
+func (s *Server) handleReportExplain(w http.ResponseWriter, r *http.Request, idStr string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid report ID", http.StatusBadRequest)
+		return
+	}
+
+	report, err := s.storage.GetReportByID(id)
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, "Report not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.writeJSON(w, parser.ExplainReport(report))
+}
@@ -0,0 +1,221 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/meysam81/parse-dmarc/internal/imap"
+	"github.com/meysam81/parse-dmarc/internal/parser"
+	"github.com/meysam81/parse-dmarc/internal/storage"
+)
+
+// maxIngestSize bounds the size of an uploaded report
+const maxIngestSize = 32 << 20
+
+// SetReportSavedHook sets a function called after a report uploaded to
+// /api/reports/ingest is saved, such as raising alerts and publishing events
+func (s *Server) SetReportSavedHook(fn func(ctx context.Context, feedback *parser.Feedback)) {
+	s.onReportSaved = fn
+}
+
+// handleReportIngest parses and saves a report uploaded in the "report"
+// field of a multipart form. When API keys are configured, the request
+// must carry one in the X-API-Key header.
+func (s *Server) handleReportIngest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if len(s.apiKeys) > 0 && matchAPIKey(r.Header.Get("X-API-Key"), s.apiKeys) < 0 {
+		http.Error(w, "API key required", http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxIngestSize)
+	file, _, err := r.FormFile("report")
+	if err != nil {
+		http.Error(w, "Missing report file: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer func() { _ = file.Close() }()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	summary, status, err := s.ingestReport(r, data)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	s.writeJSON(w, summary)
+}
+
+// ingestReport parses and saves an uploaded report and returns its summary.
+// On failure it returns the HTTP status to respond with.
+func (s *Server) ingestReport(r *http.Request, data []byte) (*storage.ReportSummary, int, error) {
+	feedback, err := parser.ParseReportWithContext(r.Context(), data)
+	if err != nil {
+		if s.metrics != nil {
+			s.metrics.ReportParseErrors.Inc()
+		}
+		return nil, http.StatusUnprocessableEntity, err
+	}
+
+	if err := s.storage.SaveReportContext(storage.WithSource(r.Context(), storage.SourceHTTP), feedback); err != nil {
+		if errors.Is(err, storage.ErrDuplicateContent) {
+			return nil, http.StatusConflict, err
+		}
+		if s.metrics != nil {
+			s.metrics.ReportStoreErrors.Inc()
+		}
+		return nil, http.StatusInternalServerError, err
+	}
+	if s.metrics != nil {
+		s.metrics.ReportsIngestedHTTP.Inc()
+		s.metrics.ReportsStored.WithLabelValues(storage.SourceHTTP).Inc()
+	}
+
+	reportID := feedback.ReportMetadata.ReportID
+	s.log.Info().
+		Str("report_id", reportID).
+		Str("actor", storage.ActorFromContext(r.Context())).
+		Msg("ingested report via HTTP")
+
+	if s.onReportSaved != nil {
+		s.onReportSaved(context.WithoutCancel(r.Context()), feedback)
+	}
+
+	reports, err := s.storage.GetReports(storage.ReportFilter{ReportID: reportID}, 1, 0)
+	if err != nil || len(reports) == 0 {
+		return nil, http.StatusInternalServerError, errors.New("report saved but could not be read back")
+	}
+	return &reports[0], http.StatusCreated, nil
+}
+
+// ErrWebhookNoAPIKeys is returned by SetWebhook when no API keys are given.
+// The webhook is served outside /api, so the API's authentication does not
+// cover it.
+var ErrWebhookNoAPIKeys = errors.New("webhook requires at least one API key")
+
+// SetWebhook accepts reports POSTed as the raw request body at path from
+// requests carrying one of apiKeys in the X-API-Key header. An empty path
+// disables the webhook.
+func (s *Server) SetWebhook(path string, apiKeys []string) error {
+	if path != "" {
+		if err := CheckWebhookPath(path); err != nil {
+			return err
+		}
+		if len(apiKeys) == 0 {
+			return ErrWebhookNoAPIKeys
+		}
+	}
+	s.webhookPath = path
+	s.webhookKeys = apiKeys
+	return nil
+}
+
+// CheckWebhookPath reports whether path can serve the webhook without
+// shadowing the dashboard, the API or /metrics
+func CheckWebhookPath(path string) error {
+	switch {
+	case !strings.HasPrefix(path, "/"):
+		return fmt.Errorf("webhook path %q must start with /", path)
+	case path == "/" || path == "/metrics" || path == "/api" || strings.HasPrefix(path, "/api/"):
+		return fmt.Errorf("webhook path %q is reserved", path)
+	}
+	return nil
+}
+
+// handleWebhook parses and saves the report in the body of a push from a
+// mail server or email processor. The body is a report file or, for
+// message/rfc822, the whole email, whose report attachments are all saved.
+// The request must carry one of the webhook's API keys in the X-API-Key
+// header.
+func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if matchAPIKey(r.Header.Get("X-API-Key"), s.webhookKeys) < 0 {
+		http.Error(w, "API key required", http.StatusUnauthorized)
+		return
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		http.Error(w, "Invalid Content-Type: "+err.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxIngestSize)
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var files [][]byte
+	switch mediaType {
+	case "application/zip", "application/gzip", "application/x-gzip", "application/xml", "text/xml":
+		// The parser detects the container format from the content
+		files = [][]byte{data}
+	case "message/rfc822":
+		attachments, err := imap.ReadAttachments(bytes.NewReader(data), s.log)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, a := range attachments {
+			files = append(files, a.Data)
+		}
+		if len(files) == 0 {
+			http.Error(w, "Message has no DMARC report attachment", http.StatusUnprocessableEntity)
+			return
+		}
+	default:
+		http.Error(w, "Unsupported Content-Type "+mediaType, http.StatusUnsupportedMediaType)
+		return
+	}
+
+	summaries := make([]storage.ReportSummary, 0, len(files))
+	for _, file := range files {
+		summary, status, err := s.ingestReport(r, file)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		summaries = append(summaries, *summary)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	s.writeJSON(w, summaries)
+}
+
+// matchAPIKey returns the index of key in apiKeys, or -1 if it is not one
+func matchAPIKey(key string, apiKeys []string) int {
+	if key == "" {
+		return -1
+	}
+	for i, k := range apiKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(k)) == 1 {
+			return i
+		}
+	}
+	return -1
+}
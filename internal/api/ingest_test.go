@@ -0,0 +1,51 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSetWebhook_RequiresAPIKey(t *testing.T) {
+	s, _ := newTestServer(t)
+	if err := s.SetWebhook("/ingest", nil); !errors.Is(err, ErrWebhookNoAPIKeys) {
+		t.Errorf("Expected ErrWebhookNoAPIKeys, got %v", err)
+	}
+	if err := s.SetWebhook("", nil); err != nil {
+		t.Errorf("Expected no error disabling the webhook, got %v", err)
+	}
+}
+
+func TestHandleWebhook_APIKey(t *testing.T) {
+	s, _ := newTestServer(t)
+	if err := s.SetWebhook("/ingest", []string{"secret"}); err != nil {
+		t.Fatalf("Failed to set webhook: %v", err)
+	}
+
+	body := strings.Replace(testReportXML, "annotations-1", "webhook-1", 1)
+	tests := []struct {
+		name string
+		key  string
+		want int
+	}{
+		{"missing key", "", http.StatusUnauthorized},
+		{"wrong key", "wrong", http.StatusUnauthorized},
+		{"valid key", "secret", http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/ingest", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/xml")
+			if tt.key != "" {
+				req.Header.Set("X-API-Key", tt.key)
+			}
+			rec := httptest.NewRecorder()
+			s.handleWebhook(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("Expected status %d, got %d: %s", tt.want, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// globalRateMultiplier sizes the global limiter relative to the per-IP one
+	globalRateMultiplier = 10
+	// clientIdleTimeout is how long an idle client's limiter is kept
+	clientIdleTimeout = 5 * time.Minute
+)
+
+// client tracks the limiter for a single remote IP
+type client struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// rateLimiter enforces a per-IP and a global token bucket
+type rateLimiter struct {
+	limit  rate.Limit
+	burst  int
+	global *rate.Limiter
+
+	mu      sync.Mutex
+	clients map[string]*client
+
+	// onReject is called for every rejected request
+	onReject func()
+}
+
+// newRateLimiter creates a limiter allowing rps requests per second with the
+// given burst per IP, and ten times that across all clients
+func newRateLimiter(rps float64, burst int) *rateLimiter {
+	if burst <= 0 {
+		burst = int(math.Max(1, math.Ceil(rps)))
+	}
+	return &rateLimiter{
+		limit:   rate.Limit(rps),
+		burst:   burst,
+		global:  rate.NewLimiter(rate.Limit(rps*globalRateMultiplier), burst*globalRateMultiplier),
+		clients: make(map[string]*client),
+	}
+}
+
+// clientLimiter returns the limiter for ip, creating it if needed
+func (rl *rateLimiter) clientLimiter(ip string) *rate.Limiter {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	c, ok := rl.clients[ip]
+	if !ok {
+		c = &client{limiter: rate.NewLimiter(rl.limit, rl.burst)}
+		rl.clients[ip] = c
+	}
+	c.lastSeen = time.Now()
+	return c.limiter
+}
+
+// cleanup removes limiters for clients idle longer than clientIdleTimeout
+// until ctx is done
+func (rl *rateLimiter) cleanup(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			rl.mu.Lock()
+			for ip, c := range rl.clients {
+				if time.Since(c.lastSeen) > clientIdleTimeout {
+					delete(rl.clients, ip)
+				}
+			}
+			rl.mu.Unlock()
+		}
+	}
+}
+
+// reserve takes a token from lim, returning how long the caller must wait
+// if none is available
+func reserve(lim *rate.Limiter) (time.Duration, bool) {
+	r := lim.Reserve()
+	if !r.OK() {
+		return time.Second, false
+	}
+	if delay := r.Delay(); delay > 0 {
+		r.Cancel()
+		return delay, false
+	}
+	return 0, true
+}
+
+// middleware rejects requests over the limit with 429 Too Many Requests
+func (rl *rateLimiter) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if publicPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		delay, ok := reserve(rl.clientLimiter(remoteIP(r)))
+		if ok {
+			delay, ok = reserve(rl.global)
+		}
+		if !ok {
+			if rl.onReject != nil {
+				rl.onReject()
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// remoteIP returns the IP of the client that sent the request
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -3,28 +3,63 @@ package api
 import (
 	"context"
 	"embed"
+	"errors"
 	"fmt"
 	"io/fs"
+	"net"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/goccy/go-json"
 	"github.com/rs/zerolog"
 
+	"github.com/meysam81/parse-dmarc/internal/mcp/oauth"
 	"github.com/meysam81/parse-dmarc/internal/metrics"
+	"github.com/meysam81/parse-dmarc/internal/parser"
 	"github.com/meysam81/parse-dmarc/internal/storage"
 )
 
 //go:embed dist
 var distFS embed.FS
 
+// publicPaths are never rate limited or authenticated
+var publicPaths = map[string]bool{
+	"/metrics": true,
+	"/healthz": true,
+}
+
 // Server represents the API server
 type Server struct {
 	storage *storage.Storage
 	metrics *metrics.Metrics
 	log     *zerolog.Logger
 	addr    string
+	events  EventSource
+	limiter *rateLimiter
+
+	onReportSaved func(ctx context.Context, feedback *parser.Feedback)
+	webhookPath   string
+	webhookKeys   []string
+
+	basicAuthUser string
+	basicAuthHash string
+
+	metricsAuthUser string
+	metricsAuthHash string
+
+	jwtVerifier oauth.TokenVerifier
+	apiKeys     []string
+
+	tls         *tlsSettings
+	allowedNets []*net.IPNet
+
+	readTimeout       time.Duration
+	writeTimeout      time.Duration
+	idleTimeout       time.Duration
+	readHeaderTimeout time.Duration
 }
 
 // NewServer creates a new API server
@@ -37,19 +72,87 @@ func NewServer(store *storage.Storage, host string, port int, m *metrics.Metrics
 	}
 }
 
+// SetRateLimit limits each client IP to rps requests per second with the
+// given burst. A value of zero or less for rps disables rate limiting.
+func (s *Server) SetRateLimit(rps float64, burst int) {
+	if rps <= 0 {
+		s.limiter = nil
+		return
+	}
+	s.limiter = newRateLimiter(rps, burst)
+}
+
+// SetTimeouts sets the HTTP server timeouts. Zero disables the corresponding
+// timeout; the report stream is never cut off by the write timeout.
+func (s *Server) SetTimeouts(read, write, idle, readHeader time.Duration) {
+	s.readTimeout = read
+	s.writeTimeout = write
+	s.idleTimeout = idle
+	s.readHeaderTimeout = readHeader
+}
+
+// SetBasicAuth protects the dashboard and API with HTTP Basic Auth.
+// An empty username disables authentication.
+func (s *Server) SetBasicAuth(username, passwordHash string) {
+	s.basicAuthUser = username
+	s.basicAuthHash = passwordHash
+}
+
+// SetMetricsBasicAuth protects /metrics with its own HTTP Basic Auth
+// credentials, independent of SetBasicAuth. An empty username disables it.
+func (s *Server) SetMetricsBasicAuth(username, passwordHash string) {
+	s.metricsAuthUser = username
+	s.metricsAuthHash = passwordHash
+}
+
+// SetJWTAuth requires a bearer token accepted by verifier on all /api/*
+// routes. Requests with one of apiKeys in the X-API-Key header bypass the
+// token check. A nil verifier disables JWT authentication.
+func (s *Server) SetJWTAuth(verifier oauth.TokenVerifier, apiKeys []string) {
+	s.jwtVerifier = verifier
+	s.apiKeys = apiKeys
+}
+
 // Start starts the HTTP server
 func (s *Server) Start(ctx context.Context) error {
 	mux := http.NewServeMux()
 
 	// API routes
 	mux.HandleFunc("/api/reports", s.handleReports)
+	mux.HandleFunc("/api/reports/count", s.handleReportsCount)
+	mux.HandleFunc("/api/reports/ids", s.handleReportIDs)
+	mux.HandleFunc("/api/reports/ingest", s.handleReportIngest)
+	mux.HandleFunc("/api/reports/trash", s.handleReportTrash)
+	mux.HandleFunc("/api/reports/by-report-id/", s.handleReportByExternalID)
 	mux.HandleFunc("/api/reports/", s.handleReportDetail)
 	mux.HandleFunc("/api/statistics", s.handleStatistics)
+	mux.HandleFunc("/api/statistics/auth-detail", s.handleAuthDetail)
+	mux.HandleFunc("/api/statistics/overrides", s.handleOverrideStats)
+	mux.HandleFunc("/api/statistics/heatmap", s.handleHeatmap)
+	mux.HandleFunc("/api/statistics/extended", s.handleExtendedStatistics)
 	mux.HandleFunc("/api/top-sources", s.handleTopSources)
+	mux.HandleFunc("/api/records/failing", s.handleFailingRecords)
+	mux.HandleFunc("/api/domains", s.handleDomains)
+	mux.HandleFunc("/api/domains/", s.handleDomainDetail)
+	mux.HandleFunc("/api/sources/", s.handleSourceIPStats)
+	mux.HandleFunc("/api/alerts", s.handleAlerts)
+	mux.HandleFunc("/api/alerts/", s.handleAlertAcknowledge)
+	mux.HandleFunc("/api/stream/reports", s.handleReportStream)
+	mux.HandleFunc("/api/audit", s.handleAudit)
+	if s.webhookPath != "" {
+		mux.HandleFunc(s.webhookPath, s.handleWebhook)
+	}
+
+	// Liveness probe, public like /metrics
+	mux.HandleFunc("/healthz", s.handleHealth)
 
 	// Prometheus metrics endpoint
 	if s.metrics != nil {
-		mux.Handle("/metrics", s.metrics.Handler())
+		var metricsHandler http.Handler = s.metrics.Handler()
+		if s.metricsAuthUser != "" {
+			metricsHandler = requireBasicAuth(s.metricsAuthUser, s.metricsAuthHash, metricsHandler)
+		}
+		mux.Handle("/metrics", metricsHandler)
 	}
 
 	// Serve frontend
@@ -84,16 +187,37 @@ func (s *Server) Start(ctx context.Context) error {
 		})
 	}
 
-	// Build handler chain: CORS -> Metrics -> Routes
-	var handler http.Handler = mux
+	// Build handler chain: CORS -> Metrics -> IP allowlist -> Rate limit -> Auth -> Routes
+	handler := s.authMiddleware(mux)
+	if s.limiter != nil {
+		if s.metrics != nil {
+			s.limiter.onReject = s.metrics.HTTPRateLimited.Inc
+		}
+		go s.limiter.cleanup(ctx)
+		handler = s.limiter.middleware(handler)
+	}
+	if len(s.allowedNets) > 0 {
+		handler = s.cidrMiddleware(s.allowedNets, handler)
+	}
 	if s.metrics != nil {
+		s.metrics.SetWebhookPath(s.webhookPath)
 		handler = s.metrics.HTTPMiddleware(handler)
 	}
 	handler = s.corsMiddleware(handler)
+	if s.tls != nil {
+		handler = clientCertMiddleware(handler)
+	}
 
 	server := &http.Server{
-		Addr:    s.addr,
-		Handler: handler,
+		Addr:              s.addr,
+		Handler:           handler,
+		ReadTimeout:       s.readTimeout,
+		WriteTimeout:      s.writeTimeout,
+		IdleTimeout:       s.idleTimeout,
+		ReadHeaderTimeout: s.readHeaderTimeout,
+	}
+	if s.tls != nil {
+		server.TLSConfig = s.tls.config
 	}
 
 	go func() {
@@ -106,8 +230,13 @@ func (s *Server) Start(ctx context.Context) error {
 		}
 	}()
 
-	s.log.Info().Str("addr", s.addr).Msg("starting server")
-	err = server.ListenAndServe()
+	if s.tls != nil {
+		s.log.Info().Str("addr", s.addr).Msg("starting HTTPS server")
+		err = server.ListenAndServeTLS(s.tls.certFile, s.tls.keyFile)
+	} else {
+		s.log.Info().Str("addr", s.addr).Msg("starting server")
+		err = server.ListenAndServe()
+	}
 	if err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("HTTP server listen on %s: %w", s.addr, err)
 	}
@@ -118,8 +247,9 @@ func (s *Server) Start(ctx context.Context) error {
 func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Total-Count, Link")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -130,6 +260,13 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// reportsResponse is the response body for /api/reports and
+// /api/domains/:domain/reports
+type reportsResponse struct {
+	Total   int                     `json:"total"`
+	Reports []storage.ReportSummary `json:"reports"`
+}
+
 // handleReports returns a list of reports
 func (s *Server) handleReports(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -153,39 +290,235 @@ func (s *Server) handleReports(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	reports, err := s.storage.GetReports(limit, offset)
+	filter, err := reportFilterFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reports, err := s.storage.GetReports(filter, limit, offset)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if reports == nil {
+		reports = []storage.ReportSummary{}
+	}
+
+	total, err := s.storage.CountReports(filter)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	s.writeJSON(w, reports)
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	w.Header().Set("Link", paginationLinks(r.URL, offset, limit, total))
+	s.writeJSON(w, reportsResponse{Total: total, Reports: reports})
 }
 
-// handleReportDetail returns a single report detail
-func (s *Server) handleReportDetail(w http.ResponseWriter, r *http.Request) {
+// paginationLinks builds an RFC 8288 Link header with first, prev, next and
+// last pages of a list of total items. Other query parameters, such as
+// filters, are kept in every link.
+func paginationLinks(u *url.URL, offset, limit, total int) string {
+	link := func(rel string, offset int) string {
+		query := u.Query()
+		query.Set("offset", strconv.Itoa(offset))
+		query.Set("limit", strconv.Itoa(limit))
+		return fmt.Sprintf(`<%s?%s>; rel="%s"`, u.Path, query.Encode(), rel)
+	}
+
+	last := 0
+	if total > 0 {
+		last = (total - 1) / limit * limit
+	}
+	links := []string{link("first", 0)}
+	if offset > 0 {
+		links = append(links, link("prev", max(offset-limit, 0)))
+	}
+	if offset+limit < total {
+		links = append(links, link("next", offset+limit))
+	}
+	links = append(links, link("last", last))
+	return strings.Join(links, ", ")
+}
+
+// reportFilterFromQuery builds a report filter from the domain, report_id,
+// from and to query parameters. from and to are Unix timestamps bounding
+// the report's date range.
+func reportFilterFromQuery(r *http.Request) (storage.ReportFilter, error) {
+	query := r.URL.Query()
+	filter := storage.ReportFilter{
+		Domain:   query.Get("domain"),
+		ReportID: query.Get("report_id"),
+	}
+
+	if fromStr := query.Get("from"); fromStr != "" {
+		from, err := strconv.ParseInt(fromStr, 10, 64)
+		if err != nil {
+			return filter, errors.New("invalid from parameter")
+		}
+		filter.From = from
+	}
+
+	if toStr := query.Get("to"); toStr != "" {
+		to, err := strconv.ParseInt(toStr, 10, 64)
+		if err != nil {
+			return filter, errors.New("invalid to parameter")
+		}
+		filter.To = to
+	}
+
+	return filter, nil
+}
+
+// handleReportsCount returns the number of reports matching the filter
+func (s *Server) handleReportsCount(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter, err := reportFilterFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	total, err := s.storage.CountReports(filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.writeJSON(w, map[string]int{"total": total})
+}
+
+// maxReportIDs caps the limit of /api/reports/ids
+const maxReportIDs = 10000
+
+// handleReportIDs lists stored report_id values, in the order they were
+// stored, so sync tools can tell which reports they already have. since is a
+// Unix timestamp matched against the time each report was stored.
+func (s *Server) handleReportIDs(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	query := r.URL.Query()
+	limit := 1000
+	if limitStr := query.Get("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l <= 0 {
+			http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+			return
+		}
+		limit = min(l, maxReportIDs)
+	}
+
+	var ids []string
+	var err error
+	if sinceStr := query.Get("since"); sinceStr != "" {
+		since, parseErr := strconv.ParseInt(sinceStr, 10, 64)
+		if parseErr != nil {
+			http.Error(w, "Invalid since parameter", http.StatusBadRequest)
+			return
+		}
+		ids, err = s.storage.ListReportIDsSince(time.Unix(since, 0), limit)
+	} else {
+		offset := 0
+		if offsetStr := query.Get("offset"); offsetStr != "" {
+			if o, convErr := strconv.Atoi(offsetStr); convErr == nil && o >= 0 {
+				offset = o
+			}
+		}
+		ids, err = s.storage.ListReportIDs(limit, offset)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.writeJSON(w, map[string][]string{"report_ids": ids})
+}
+
+// handleReportDetail returns a single report detail
+func (s *Server) handleReportDetail(w http.ResponseWriter, r *http.Request) {
 	// Extract ID from URL
 	idStr := r.URL.Path[len("/api/reports/"):]
+	if reportID, ok := strings.CutSuffix(idStr, "/annotations"); ok {
+		s.handleReportAnnotations(w, r, reportID)
+		return
+	}
+	if reportID, ok := strings.CutSuffix(idStr, "/explain"); ok {
+		s.handleReportExplain(w, r, reportID)
+		return
+	}
+	if reportID, ok := strings.CutSuffix(idStr, "/restore"); ok {
+		s.handleReportRestore(w, r, reportID)
+		return
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
 		http.Error(w, "Invalid report ID", http.StatusBadRequest)
 		return
 	}
 
+	if r.Method == http.MethodDelete {
+		s.handleReportDelete(w, r, id)
+		return
+	}
+
 	report, err := s.storage.GetReportByID(id)
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, "Report not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.writeJSON(w, report)
+}
+
+// handleReportByExternalID returns the report with the reporter-assigned
+// report_id given in the path, which may be URL-encoded
+func (s *Server) handleReportByExternalID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	reportID := strings.TrimPrefix(r.URL.Path, "/api/reports/by-report-id/")
+	if reportID == "" {
+		http.Error(w, "Missing report ID", http.StatusBadRequest)
+		return
+	}
+
+	report, err := s.storage.GetReportByExternalID(reportID)
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, "Report not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	s.writeJSON(w, report)
 }
 
+// statisticsTrendDays is the number of days covered by the optional daily
+// trend in /api/statistics
+const statisticsTrendDays = 30
+
 // handleStatistics returns dashboard statistics
 func (s *Server) handleStatistics(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -199,9 +532,106 @@ func (s *Server) handleStatistics(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if include, _ := strconv.ParseBool(r.URL.Query().Get("include_trend")); include {
+		stats.DailyTrend, err = s.storage.GetComplianceTrend("", statisticsTrendDays)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
 	s.writeJSON(w, stats)
 }
 
+// handleExtendedStatistics returns statistics with report-level averages,
+// recent report counts and the best and worst domains
+func (s *Server) handleExtendedStatistics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	stats, err := s.storage.GetExtendedStatistics()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.writeJSON(w, stats)
+}
+
+// authDetailResponse is the response body for /api/statistics/auth-detail
+type authDetailResponse struct {
+	SPF  []storage.AuthDetailStats `json:"spf"`
+	DKIM []storage.AuthDetailStats `json:"dkim"`
+}
+
+// handleAuthDetail returns SPF and DKIM results broken down by domain
+func (s *Server) handleAuthDetail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	spf, err := s.storage.GetDetailedSPFStats()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	dkim, err := s.storage.GetDetailedDKIMStats()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.writeJSON(w, authDetailResponse{SPF: spf, DKIM: dkim})
+}
+
+// handleOverrideStats returns how often reporters overrode the published
+// policy, by reason
+func (s *Server) handleOverrideStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	stats, err := s.storage.GetOverrideReasonStats()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.writeJSON(w, stats)
+}
+
+// handleHeatmap returns message volume and compliance per day of the year
+// given by ?year=, the current year by default
+func (s *Server) handleHeatmap(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	year := time.Now().UTC().Year()
+	if yearStr := r.URL.Query().Get("year"); yearStr != "" {
+		y, err := strconv.Atoi(yearStr)
+		if err != nil || y < 1970 || y > 9999 {
+			http.Error(w, "Invalid year", http.StatusBadRequest)
+			return
+		}
+		year = y
+	}
+
+	days, err := s.storage.GetReportHeatmapData(year)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.writeJSON(w, days)
+}
+
 // handleTopSources returns top source IPs
 func (s *Server) handleTopSources(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -225,6 +655,286 @@ func (s *Server) handleTopSources(w http.ResponseWriter, r *http.Request) {
 	s.writeJSON(w, sources)
 }
 
+// handleFailingRecords returns records that failed DMARC, for incident response
+func (s *Server) handleFailingRecords(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	records, err := s.storage.GetFailingRecords(limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.writeJSON(w, records)
+}
+
+// handleDomains returns every domain with reports, sorted
+func (s *Server) handleDomains(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	domains, err := s.storage.ListDomains()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// The list is not paginated; the count is sent for consistency with the
+	// other list endpoints
+	w.Header().Set("X-Total-Count", strconv.Itoa(len(domains)))
+	s.writeJSON(w, map[string][]string{"domains": domains})
+}
+
+// handleDomainDetail serves /api/domains/:domain/policy and
+// /api/domains/:domain/reports
+func (s *Server) handleDomainDetail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	rest := strings.TrimPrefix(r.URL.Path, "/api/domains/")
+	domain, resource, ok := strings.Cut(rest, "/")
+	if !ok || domain == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch resource {
+	case "policy":
+		s.handleDomainPolicy(w, domain)
+	case "reports":
+		s.handleDomainReports(w, r, domain)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// handleDomainReports returns the reports for a domain, newest first. The
+// domain is matched case-insensitively.
+func (s *Server) handleDomainReports(w http.ResponseWriter, r *http.Request, domain string) {
+	limit := 50
+	offset := 0
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	reports, err := s.storage.GetReportsByDomain(domain, limit, offset)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	total, err := s.storage.CountReportsByDomain(domain)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	w.Header().Set("Link", paginationLinks(r.URL, offset, limit, total))
+
+	s.writeJSON(w, reportsResponse{Total: total, Reports: reports})
+}
+
+// handleDomainPolicy returns the current published policy for a domain
+func (s *Server) handleDomainPolicy(w http.ResponseWriter, domain string) {
+	policy, err := s.storage.GetDomainPolicy(domain)
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.writeJSON(w, policy)
+}
+
+// handleSourceIPStats returns the authentication breakdown for a source IP
+func (s *Server) handleSourceIPStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract IP from /api/sources/:ip/stats
+	rest := strings.TrimPrefix(r.URL.Path, "/api/sources/")
+	ip, ok := strings.CutSuffix(rest, "/stats")
+	if !ok || net.ParseIP(ip) == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	stats, err := s.storage.GetSourceIPStats(ip)
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.writeJSON(w, stats)
+}
+
+// handleAlerts returns triggered alerts filtered by since, domain and acknowledged
+func (s *Server) handleAlerts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := storage.AlertFilter{Domain: query.Get("domain")}
+
+	if sinceStr := query.Get("since"); sinceStr != "" {
+		since, err := strconv.ParseInt(sinceStr, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid since parameter", http.StatusBadRequest)
+			return
+		}
+		filter.Since = since
+	}
+
+	if ackStr := query.Get("acknowledged"); ackStr != "" {
+		ack, err := strconv.ParseBool(ackStr)
+		if err != nil {
+			http.Error(w, "Invalid acknowledged parameter", http.StatusBadRequest)
+			return
+		}
+		filter.Acknowledged = &ack
+	}
+
+	alerts, err := s.storage.GetAlerts(filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.writeJSON(w, alerts)
+}
+
+// handleAlertAcknowledge marks an alert as acknowledged
+func (s *Server) handleAlertAcknowledge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract ID from /api/alerts/:id/acknowledge
+	rest := strings.TrimPrefix(r.URL.Path, "/api/alerts/")
+	idStr, ok := strings.CutSuffix(rest, "/acknowledge")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid alert ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.storage.AcknowledgeAlert(id); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// healthTimeout bounds how long a health probe waits for the database
+const healthTimeout = 5 * time.Second
+
+// handleHealth reports whether the database is responsive
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), healthTimeout)
+	defer cancel()
+	if err := s.storage.Ping(ctx); err != nil {
+		s.log.Error().Err(err).Msg("health check failed")
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	s.writeJSON(w, map[string]string{"status": "ok"})
+}
+
+// handleAudit returns audit log entries filtered by from and to
+func (s *Server) handleAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	var filter storage.AuditFilter
+
+	if fromStr := query.Get("from"); fromStr != "" {
+		from, err := strconv.ParseInt(fromStr, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid from parameter", http.StatusBadRequest)
+			return
+		}
+		filter.From = from
+	}
+
+	if toStr := query.Get("to"); toStr != "" {
+		to, err := strconv.ParseInt(toStr, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid to parameter", http.StatusBadRequest)
+			return
+		}
+		filter.To = to
+	}
+
+	limit := 100
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	entries, err := s.storage.GetAuditLog(filter, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.writeJSON(w, entries)
+}
+
 // writeJSON writes JSON response
 func (s *Server) writeJSON(w http.ResponseWriter, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -254,6 +964,12 @@ func (s *Server) RefreshMetrics() {
 		)
 	}
 
+	if size, err := s.storage.DatabaseSize(); err != nil {
+		s.log.Error().Err(err).Msg("failed to get database size for metrics")
+	} else {
+		s.metrics.DatabaseSizeBytes.Set(float64(size))
+	}
+
 	// Update per-domain metrics
 	domainStats, err := s.storage.GetDomainStats()
 	if err != nil {
@@ -274,6 +990,15 @@ func (s *Server) RefreshMetrics() {
 		}
 	}
 
+	orgMessageStats, err := s.storage.GetOrgMessageStats()
+	if err != nil {
+		s.log.Error().Err(err).Msg("failed to get org message stats for metrics")
+	} else {
+		for _, om := range orgMessageStats {
+			s.metrics.UpdateOrgMessageMetrics(om.OrgName, om.Messages)
+		}
+	}
+
 	// Update disposition metrics
 	dispStats, err := s.storage.GetDispositionStats()
 	if err != nil {
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+)
+
+func TestHandleDomainPolicy(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	tests := []struct {
+		name   string
+		domain string
+		want   int
+	}{
+		{"known domain", "example.com", http.StatusOK},
+		{"unknown domain", "missing.example", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.handleDomainPolicy(rec, tt.domain)
+			if rec.Code != tt.want {
+				t.Errorf("Expected status %d, got %d: %s", tt.want, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	// A storage failure is not a missing domain
+	_ = s.storage.Close()
+	rec := httptest.NewRecorder()
+	s.handleDomainPolicy(rec, "example.com")
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500 after closing the database, got %d", rec.Code)
+	}
+}
+
+func TestHandleDomainReports_Pagination(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/domains/EXAMPLE.com/reports?limit=1", nil)
+	rec := httptest.NewRecorder()
+	s.handleDomainDetail(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("X-Total-Count"); got != "1" {
+		t.Errorf("Expected X-Total-Count 1, got %q", got)
+	}
+	if got := rec.Header().Get("Link"); !strings.Contains(got, `rel="first"`) || !strings.Contains(got, `rel="last"`) {
+		t.Errorf("Expected first and last links, got %q", got)
+	}
+
+	var body reportsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body.Total != 1 || len(body.Reports) != 1 {
+		t.Errorf("Expected total 1 and 1 report, got total %d and %d reports", body.Total, len(body.Reports))
+	}
+}
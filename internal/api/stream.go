@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// streamKeepAlive is how often a comment is sent to keep idle SSE connections open
+const streamKeepAlive = 30 * time.Second
+
+// EventSource provides a live feed of report events encoded as JSON
+type EventSource interface {
+	Subscribe(ctx context.Context) (<-chan []byte, error)
+}
+
+// SetEventSource enables the /api/stream/reports endpoint
+func (s *Server) SetEventSource(src EventSource) {
+	s.events = src
+}
+
+// handleReportStream streams report events as Server-Sent Events
+func (s *Server) handleReportStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.events == nil {
+		http.Error(w, "Report stream is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	events, err := s.events.Subscribe(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// The stream outlives the server write timeout
+	_ = rc.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		s.log.Error().Err(err).Msg("streaming not supported")
+		return
+	}
+
+	ticker := time.NewTicker(streamKeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case data, ok := <-events:
+			if !ok {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "event: report\ndata: %s\n\n", data); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
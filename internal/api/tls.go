@@ -0,0 +1,76 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/meysam81/parse-dmarc/internal/storage"
+)
+
+// Client certificate authentication modes
+const (
+	ClientAuthNone    = "none"
+	ClientAuthRequest = "request"
+	ClientAuthRequire = "require"
+)
+
+// tlsSettings holds the certificate files used to serve HTTPS
+type tlsSettings struct {
+	certFile string
+	keyFile  string
+	config   *tls.Config
+}
+
+// SetTLS serves HTTPS using the given certificate and key. When clientCA is
+// set, client certificates signed by it are verified according to
+// clientAuth, one of "none", "request" or "require" (the default).
+func (s *Server) SetTLS(certFile, keyFile, clientCA, clientAuth string) error {
+	if certFile == "" || keyFile == "" {
+		return fmt.Errorf("TLS requires both a certificate and a key file")
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if clientCA != "" {
+		pem, err := os.ReadFile(clientCA)
+		if err != nil {
+			return fmt.Errorf("read client CA %s: %w", clientCA, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in client CA %s", clientCA)
+		}
+		cfg.ClientCAs = pool
+
+		switch clientAuth {
+		case "", ClientAuthRequire:
+			cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		case ClientAuthRequest:
+			cfg.ClientAuth = tls.VerifyClientCertIfGiven
+		case ClientAuthNone:
+			cfg.ClientAuth = tls.NoClientCert
+		default:
+			return fmt.Errorf("invalid client auth mode %q: must be %q, %q or %q",
+				clientAuth, ClientAuthNone, ClientAuthRequest, ClientAuthRequire)
+		}
+	}
+
+	s.tls = &tlsSettings{certFile: certFile, keyFile: keyFile, config: cfg}
+	return nil
+}
+
+// clientCertMiddleware records the verified client certificate's subject
+// common name as the audit actor
+func clientCertMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+			if cn := r.TLS.VerifiedChains[0][0].Subject.CommonName; cn != "" {
+				r = r.WithContext(storage.WithActor(r.Context(), cn))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
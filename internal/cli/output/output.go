@@ -0,0 +1,98 @@
+// Package output renders subcommand results for humans or for scripts.
+//
+// Subcommands describe their result once through a Writer: Text and Table
+// for human-readable output and JSON for the machine-readable form. Each
+// Writer only renders its own format and ignores the others, so subcommands
+// can call all three without branching on the selected format.
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/goccy/go-json"
+)
+
+const (
+	// FormatText renders human-readable text (the default)
+	FormatText = "text"
+	// FormatJSON renders a single JSON document
+	FormatJSON = "json"
+)
+
+// Writer renders subcommand output in a single format
+type Writer interface {
+	// Text writes a line of human-readable text
+	Text(s string) error
+	// JSON writes v as the machine-readable result
+	JSON(v interface{}) error
+	// Table writes rows aligned under headers
+	Table(headers []string, rows [][]string) error
+}
+
+// ValidateFormat returns an error if format is not a supported output format
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatText, FormatJSON:
+		return nil
+	default:
+		return fmt.Errorf("unsupported output format %q: must be %q or %q", format, FormatText, FormatJSON)
+	}
+}
+
+// New returns a Writer for format that writes to w.
+// Unknown formats fall back to text.
+func New(w io.Writer, format string) Writer {
+	if format == FormatJSON {
+		return &jsonWriter{w: w}
+	}
+	return &textWriter{w: w}
+}
+
+// textWriter renders Text and Table output and ignores JSON
+type textWriter struct {
+	w io.Writer
+}
+
+func (t *textWriter) Text(s string) error {
+	_, err := fmt.Fprintln(t.w, s)
+	return err
+}
+
+func (t *textWriter) JSON(v interface{}) error {
+	return nil
+}
+
+func (t *textWriter) Table(headers []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(t.w, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, strings.Join(headers, "\t")); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if _, err := fmt.Fprintln(tw, strings.Join(row, "\t")); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+// jsonWriter renders JSON output and ignores Text and Table
+type jsonWriter struct {
+	w io.Writer
+}
+
+func (j *jsonWriter) Text(s string) error {
+	return nil
+}
+
+func (j *jsonWriter) JSON(v interface{}) error {
+	enc := json.NewEncoder(j.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (j *jsonWriter) Table(headers []string, rows [][]string) error {
+	return nil
+}
@@ -0,0 +1,47 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriter_Text(t *testing.T) {
+	var buf bytes.Buffer
+	out := New(&buf, FormatText)
+
+	_ = out.Text("hello")
+	_ = out.JSON(map[string]int{"ignored": 1})
+	_ = out.Table([]string{"NAME", "COUNT"}, [][]string{{"a", "1"}})
+
+	got := buf.String()
+	if !strings.Contains(got, "hello") || !strings.Contains(got, "NAME") {
+		t.Errorf("Expected text and table output, got %q", got)
+	}
+	if strings.Contains(got, "ignored") {
+		t.Errorf("Expected JSON to be ignored in text mode, got %q", got)
+	}
+}
+
+func TestWriter_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	out := New(&buf, FormatJSON)
+
+	_ = out.Text("hello")
+	_ = out.Table([]string{"NAME"}, [][]string{{"a"}})
+	_ = out.JSON(map[string]int{"count": 1})
+
+	got := strings.TrimSpace(buf.String())
+	if got != "{\n  \"count\": 1\n}" {
+		t.Errorf("Expected only the JSON document, got %q", got)
+	}
+}
+
+func TestValidateFormat(t *testing.T) {
+	if err := ValidateFormat("json"); err != nil {
+		t.Errorf("Expected json to be valid, got %v", err)
+	}
+	if err := ValidateFormat("yaml"); err == nil {
+		t.Errorf("Expected yaml to be rejected")
+	}
+}
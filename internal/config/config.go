@@ -1,13 +1,17 @@
 package config
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/BurntSushi/toml"
 	"github.com/caarlos0/env/v11"
 	"github.com/goccy/go-json"
+	"gopkg.in/yaml.v3"
 )
 
 var (
@@ -17,36 +21,285 @@ var (
 	ErrMissingIMAPUsername = errors.New("IMAP_USERNAME is required: set via environment variable or config file")
 	// ErrMissingIMAPPassword is returned when IMAP password is not configured
 	ErrMissingIMAPPassword = errors.New("IMAP_PASSWORD is required: set via environment variable or config file")
+	// ErrAutoVacuumFull is returned for database.auto_vacuum "full", which
+	// is reserved until reports can be purged
+	ErrAutoVacuumFull = errors.New(`database.auto_vacuum "full" is not supported yet: use "none" or "incremental"`)
 )
 
 // Config holds the application configuration
 type Config struct {
-	LogLevel    string         `json:"log_level" env:"LOG_LEVEL" envDefault:"info"`
-	ColoredLogs bool           `json:"colored_logs" env:"COLORED_LOGS" envDefault:"false"`
-	IMAP        IMAPConfig     `json:"imap"`
-	Database    DatabaseConfig `json:"database"`
-	Server      ServerConfig   `json:"server"`
+	LogLevel      string              `json:"log_level" toml:"log_level" yaml:"log_level" env:"LOG_LEVEL" envDefault:"info"`
+	ColoredLogs   bool                `json:"colored_logs" toml:"colored_logs" yaml:"colored_logs" env:"COLORED_LOGS" envDefault:"false"`
+	IMAP          IMAPConfig          `json:"imap" toml:"imap" yaml:"imap"`
+	Database      DatabaseConfig      `json:"database" toml:"database" yaml:"database"`
+	Server        ServerConfig        `json:"server" toml:"server" yaml:"server"`
+	Metrics       MetricsConfig       `json:"metrics" toml:"metrics" yaml:"metrics"`
+	Geo           GeoConfig           `json:"geo" toml:"geo" yaml:"geo"`
+	Alerts        []AlertConfig       `json:"alerts,omitempty" toml:"alerts,omitempty" yaml:"alerts,omitempty"`
+	Notifications NotificationsConfig `json:"notifications" toml:"notifications" yaml:"notifications"`
+	Backup        BackupConfig        `json:"backup" toml:"backup" yaml:"backup"`
+
+	// Workers is the number of report attachments processed in parallel
+	Workers int `json:"workers" toml:"workers" yaml:"workers" env:"WORKERS" envDefault:"4"`
+	// FetchJitterSeconds delays each fetch by a random 0 to N seconds so
+	// instances sharing a mailbox don't connect at the same time
+	FetchJitterSeconds int `json:"fetch_jitter_seconds,omitempty" toml:"fetch_jitter_seconds,omitempty" yaml:"fetch_jitter_seconds,omitempty" env:"FETCH_JITTER_SECONDS" envDefault:"0"`
+	// FetchInterval is the time between fetches in continuous mode, as a
+	// duration such as "5m" or whole seconds. --fetch-interval takes
+	// precedence when given.
+	FetchInterval string `json:"fetch_interval,omitempty" toml:"fetch_interval,omitempty" yaml:"fetch_interval,omitempty"`
+	// StrictMode stops a fetch at the first report that fails to parse or
+	// save, and exits with an error listing the failed attachments
+	StrictMode bool `json:"strict_mode,omitempty" toml:"strict_mode,omitempty" yaml:"strict_mode,omitempty" env:"STRICT_MODE"`
 }
 
 // IMAPConfig holds IMAP server configuration
 type IMAPConfig struct {
-	Host     string `json:"host" env:"IMAP_HOST"`
-	Port     int    `json:"port" env:"IMAP_PORT" envDefault:"993"`
-	Username string `json:"username" env:"IMAP_USERNAME"`
-	Password string `json:"password" env:"IMAP_PASSWORD"`
-	Mailbox  string `json:"mailbox" env:"IMAP_MAILBOX" envDefault:"INBOX"`
-	UseTLS   bool   `json:"use_tls" env:"IMAP_USE_TLS" envDefault:"true"`
+	Host     string `json:"host" toml:"host" yaml:"host" env:"IMAP_HOST"`
+	Port     int    `json:"port" toml:"port" yaml:"port" env:"IMAP_PORT" envDefault:"993"`
+	Username string `json:"username" toml:"username" yaml:"username" env:"IMAP_USERNAME"`
+	Password string `json:"password" toml:"password" yaml:"password" env:"IMAP_PASSWORD"`
+	Mailbox  string `json:"mailbox" toml:"mailbox" yaml:"mailbox" env:"IMAP_MAILBOX" envDefault:"INBOX"`
+	UseTLS   bool   `json:"use_tls" toml:"use_tls" yaml:"use_tls" env:"IMAP_USE_TLS" envDefault:"true"`
 }
 
 // DatabaseConfig holds database configuration
 type DatabaseConfig struct {
-	Path string `json:"path" env:"DATABASE_PATH"`
+	Path string `json:"path" toml:"path" yaml:"path" env:"DATABASE_PATH"`
+	// AutoVacuum is "none" or "incremental" (sets auto_vacuum=INCREMENTAL
+	// and returns freed pages to the filesystem after writes that free
+	// them). "full" is rejected until reports can be purged.
+	AutoVacuum string `json:"auto_vacuum,omitempty" toml:"auto_vacuum,omitempty" yaml:"auto_vacuum,omitempty" env:"DATABASE_AUTO_VACUUM" envDefault:"none"`
+	// DeduplicateByContent skips reports whose domain, reporter and date
+	// range match a stored report with a different report_id
+	DeduplicateByContent bool `json:"deduplicate_by_content,omitempty" toml:"deduplicate_by_content,omitempty" yaml:"deduplicate_by_content,omitempty" env:"DATABASE_DEDUPLICATE_BY_CONTENT"`
+}
+
+// BackupConfig holds scheduled database backup configuration.
+// Scheduled backups are disabled when Schedule is empty.
+type BackupConfig struct {
+	// Schedule is a standard five-field cron expression, e.g. "0 3 * * *"
+	Schedule string `json:"schedule,omitempty" toml:"schedule,omitempty" yaml:"schedule,omitempty" env:"BACKUP_SCHEDULE"`
+	// Dir receives timestamped backup files. Defaults to a backups
+	// directory next to the database.
+	Dir string `json:"dir,omitempty" toml:"dir,omitempty" yaml:"dir,omitempty" env:"BACKUP_DIR"`
 }
 
 // ServerConfig holds web server configuration
 type ServerConfig struct {
-	Port int    `json:"port" env:"SERVER_PORT" envDefault:"8080"`
-	Host string `json:"host" env:"SERVER_HOST" envDefault:""`
+	Port      int             `json:"port" toml:"port" yaml:"port" env:"SERVER_PORT" envDefault:"8080"`
+	Host      string          `json:"host" toml:"host" yaml:"host" env:"SERVER_HOST" envDefault:""`
+	RateLimit RateLimitConfig `json:"rate_limit" toml:"rate_limit" yaml:"rate_limit"`
+	BasicAuth BasicAuthConfig `json:"basic_auth" toml:"basic_auth" yaml:"basic_auth"`
+	JWT       JWTConfig       `json:"jwt" toml:"jwt" yaml:"jwt"`
+	// AllowedCIDRs restricts access to these client networks; empty allows all
+	AllowedCIDRs []string `json:"allowed_cidrs,omitempty" toml:"allowed_cidrs,omitempty" yaml:"allowed_cidrs,omitempty" env:"SERVER_ALLOWED_CIDRS"`
+
+	// TLSCertFile and TLSKeyFile enable HTTPS when both are set
+	TLSCertFile string `json:"tls_cert_file,omitempty" toml:"tls_cert_file,omitempty" yaml:"tls_cert_file,omitempty" env:"SERVER_TLS_CERT_FILE"`
+	TLSKeyFile  string `json:"tls_key_file,omitempty" toml:"tls_key_file,omitempty" yaml:"tls_key_file,omitempty" env:"SERVER_TLS_KEY_FILE"`
+	// TLSClientCA is a PEM file of CAs used to verify client certificates
+	TLSClientCA string `json:"tls_client_ca,omitempty" toml:"tls_client_ca,omitempty" yaml:"tls_client_ca,omitempty" env:"SERVER_TLS_CLIENT_CA"`
+	// TLSClientAuth is "none", "request" or "require" (the default when TLSClientCA is set)
+	TLSClientAuth string `json:"tls_client_auth,omitempty" toml:"tls_client_auth,omitempty" yaml:"tls_client_auth,omitempty" env:"SERVER_TLS_CLIENT_AUTH"`
+
+	// IngestEnabled accepts reports POSTed as the raw request body to
+	// IngestPath, for mail servers and email processors that push reports
+	IngestEnabled bool   `json:"ingest_enabled" toml:"ingest_enabled" yaml:"ingest_enabled" env:"SERVER_INGEST_ENABLED"`
+	IngestPath    string `json:"ingest_path" toml:"ingest_path" yaml:"ingest_path" env:"SERVER_INGEST_PATH" envDefault:"/ingest"`
+	// IngestAPIKeys are accepted in the X-API-Key header of webhook
+	// requests, as are JWT.APIKeys. The webhook requires one of them.
+	IngestAPIKeys []string `json:"ingest_api_keys,omitempty" toml:"ingest_api_keys,omitempty" yaml:"ingest_api_keys,omitempty" env:"SERVER_INGEST_API_KEYS"`
+
+	// Timeouts guard against slow clients, as duration strings such as "30s"
+	ReadTimeout       Duration `json:"read_timeout,omitempty" toml:"read_timeout,omitempty" yaml:"read_timeout,omitempty" env:"SERVER_READ_TIMEOUT" envDefault:"30s"`
+	WriteTimeout      Duration `json:"write_timeout,omitempty" toml:"write_timeout,omitempty" yaml:"write_timeout,omitempty" env:"SERVER_WRITE_TIMEOUT" envDefault:"60s"`
+	IdleTimeout       Duration `json:"idle_timeout,omitempty" toml:"idle_timeout,omitempty" yaml:"idle_timeout,omitempty" env:"SERVER_IDLE_TIMEOUT" envDefault:"120s"`
+	ReadHeaderTimeout Duration `json:"read_header_timeout,omitempty" toml:"read_header_timeout,omitempty" yaml:"read_header_timeout,omitempty" env:"SERVER_READ_HEADER_TIMEOUT" envDefault:"10s"`
+}
+
+// MetricsConfig holds Prometheus endpoint configuration
+type MetricsConfig struct {
+	// BasicAuth protects /metrics separately from the dashboard.
+	// Set via METRICS_BASIC_AUTH_USERNAME and METRICS_BASIC_AUTH_PASSWORD_HASH.
+	BasicAuth BasicAuthConfig `json:"basic_auth" toml:"basic_auth" yaml:"basic_auth" envPrefix:"METRICS_"`
+}
+
+// JWTConfig holds bearer token authentication for the REST API.
+// Authentication is disabled when Issuer is empty.
+type JWTConfig struct {
+	Issuer   string `json:"issuer" toml:"issuer" yaml:"issuer" env:"JWT_ISSUER"`
+	Audience string `json:"audience" toml:"audience" yaml:"audience" env:"JWT_AUDIENCE"`
+	// JWKSURI skips OIDC discovery and fetches signing keys from this URL
+	JWKSURI string `json:"jwks_uri,omitempty" toml:"jwks_uri,omitempty" yaml:"jwks_uri,omitempty" env:"JWT_JWKS_URI"`
+	// APIKeys are accepted in the X-API-Key header instead of a token
+	APIKeys []string `json:"api_keys,omitempty" toml:"api_keys,omitempty" yaml:"api_keys,omitempty" env:"JWT_API_KEYS"`
+}
+
+// BasicAuthConfig holds HTTP Basic Auth credentials.
+// Authentication is disabled when Username is empty.
+type BasicAuthConfig struct {
+	Username string `json:"username" toml:"username" yaml:"username" env:"BASIC_AUTH_USERNAME"`
+	// PasswordHash is a bcrypt hash, as printed by gen-password-hash
+	PasswordHash string `json:"password_hash" toml:"password_hash" yaml:"password_hash" env:"BASIC_AUTH_PASSWORD_HASH"`
+}
+
+// RateLimitConfig holds per-IP API rate limiting configuration.
+// Rate limiting is disabled when RequestsPerSecond is zero.
+type RateLimitConfig struct {
+	RequestsPerSecond float64 `json:"requests_per_second" toml:"requests_per_second" yaml:"requests_per_second" env:"RATE_LIMIT_RPS"`
+	Burst             int     `json:"burst" toml:"burst" yaml:"burst" env:"RATE_LIMIT_BURST"`
+}
+
+// GeoConfig holds GeoIP enrichment configuration
+type GeoConfig struct {
+	// DatabasePath is the directory containing GeoLite2-ASN.mmdb and
+	// GeoLite2-Country.mmdb. Enrichment is disabled when empty.
+	DatabasePath string `json:"database_path" toml:"database_path" yaml:"database_path" env:"GEO_DATABASE_PATH"`
+}
+
+// AlertConfig holds a compliance threshold for a domain.
+// An empty Domain applies the threshold to every domain.
+type AlertConfig struct {
+	Domain              string  `json:"domain" toml:"domain" yaml:"domain"`
+	MinComplianceRate   float64 `json:"min_compliance_rate" toml:"min_compliance_rate" yaml:"min_compliance_rate"`
+	NotificationWebhook string  `json:"notification_webhook,omitempty" toml:"notification_webhook,omitempty" yaml:"notification_webhook,omitempty"`
+}
+
+// NotificationsConfig holds report event publisher configuration
+type NotificationsConfig struct {
+	AMQP      AMQPConfig      `json:"amqp" toml:"amqp" yaml:"amqp"`
+	Redis     RedisConfig     `json:"redis" toml:"redis" yaml:"redis"`
+	Kafka     KafkaConfig     `json:"kafka" toml:"kafka" yaml:"kafka"`
+	SNS       SNSConfig       `json:"sns" toml:"sns" yaml:"sns"`
+	SQS       SQSConfig       `json:"sqs" toml:"sqs" yaml:"sqs"`
+	AWS       AWSConfig       `json:"aws" toml:"aws" yaml:"aws"`
+	Slack     SlackConfig     `json:"slack" toml:"slack" yaml:"slack"`
+	PagerDuty PagerDutyConfig `json:"pagerduty" toml:"pagerduty" yaml:"pagerduty"`
+	Discord   DiscordConfig   `json:"discord" toml:"discord" yaml:"discord"`
+	// Channels receive each saved report that passes their filters
+	Channels []NotificationChannel `json:"channels,omitempty" toml:"channels,omitempty" yaml:"channels,omitempty"`
+	// DashboardURL is the public dashboard address used to link to reports
+	DashboardURL string `json:"dashboard_url,omitempty" toml:"dashboard_url,omitempty" yaml:"dashboard_url,omitempty" env:"DASHBOARD_URL"`
+}
+
+// SNSConfig holds the SNS topic report events are published to.
+// Publishing is disabled when TopicARN is empty.
+type SNSConfig struct {
+	TopicARN string `json:"topic_arn" toml:"topic_arn" yaml:"topic_arn" env:"SNS_TOPIC_ARN"`
+}
+
+// SQSConfig holds the SQS queue report events are sent to.
+// Publishing is disabled when QueueURL is empty.
+type SQSConfig struct {
+	QueueURL string `json:"queue_url" toml:"queue_url" yaml:"queue_url" env:"SQS_QUEUE_URL"`
+}
+
+// AWSConfig holds the region and credentials shared by the SNS and SQS
+// publishers. When AccessKey is empty, the standard AWS credential chain is
+// used, including AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY.
+type AWSConfig struct {
+	Region    string `json:"region" toml:"region" yaml:"region" env:"AWS_REGION"`
+	AccessKey string `json:"access_key,omitempty" toml:"access_key,omitempty" yaml:"access_key,omitempty"`
+	SecretKey string `json:"secret_key,omitempty" toml:"secret_key,omitempty" yaml:"secret_key,omitempty"`
+}
+
+// SlackConfig holds a Slack incoming webhook notified when a report's
+// compliance rate is below MinComplianceBelow.
+// Notifications are disabled when WebhookURL is empty.
+type SlackConfig struct {
+	WebhookURL         string  `json:"webhook_url" toml:"webhook_url" yaml:"webhook_url" env:"SLACK_WEBHOOK_URL"`
+	MinComplianceBelow float64 `json:"min_compliance_below" toml:"min_compliance_below" yaml:"min_compliance_below" env:"SLACK_MIN_COMPLIANCE_BELOW" envDefault:"95"`
+}
+
+// Notification channel types
+const (
+	ChannelWebhook   = "webhook"
+	ChannelSMTP      = "smtp"
+	ChannelSlack     = "slack"
+	ChannelPagerDuty = "pagerduty"
+	ChannelDiscord   = "discord"
+)
+
+// PagerDutyConfig holds a PagerDuty Events API v2 integration. An incident
+// is triggered when a report's compliance rate is below MinComplianceBelow
+// and resolved by the next report at or above it.
+// Incidents are disabled when RoutingKey is empty.
+type PagerDutyConfig struct {
+	RoutingKey         string  `json:"routing_key" toml:"routing_key" yaml:"routing_key" env:"PAGERDUTY_ROUTING_KEY"`
+	MinComplianceBelow float64 `json:"min_compliance_below" toml:"min_compliance_below" yaml:"min_compliance_below" env:"PAGERDUTY_MIN_COMPLIANCE_BELOW" envDefault:"80"`
+}
+
+// DiscordConfig holds a Discord webhook notified when a report's compliance
+// rate is below MinComplianceBelow.
+// Notifications are disabled when WebhookURL is empty.
+type DiscordConfig struct {
+	WebhookURL         string  `json:"webhook_url" toml:"webhook_url" yaml:"webhook_url" env:"DISCORD_WEBHOOK_URL"`
+	MinComplianceBelow float64 `json:"min_compliance_below" toml:"min_compliance_below" yaml:"min_compliance_below" env:"DISCORD_MIN_COMPLIANCE_BELOW" envDefault:"95"`
+}
+
+// NotificationChannel configures a single notification destination.
+// Only the fields for its Type are used.
+type NotificationChannel struct {
+	Type string `json:"type" toml:"type" yaml:"type"`
+	// URL is the endpoint for webhook, slack and discord channels
+	URL string `json:"url,omitempty" toml:"url,omitempty" yaml:"url,omitempty"`
+	// RoutingKey is the integration key for pagerduty channels, which
+	// trigger below filters.max_compliance_below and resolve above it
+	RoutingKey string              `json:"routing_key,omitempty" toml:"routing_key,omitempty" yaml:"routing_key,omitempty"`
+	SMTP       SMTPConfig          `json:"smtp,omitempty" toml:"smtp,omitempty" yaml:"smtp,omitempty"`
+	Filters    NotificationFilters `json:"filters,omitempty" toml:"filters,omitempty" yaml:"filters,omitempty"`
+}
+
+// SMTPConfig holds email delivery settings for smtp channels
+type SMTPConfig struct {
+	Host     string   `json:"host" toml:"host" yaml:"host"`
+	Port     int      `json:"port" toml:"port" yaml:"port"`
+	Username string   `json:"username,omitempty" toml:"username,omitempty" yaml:"username,omitempty"`
+	Password string   `json:"password,omitempty" toml:"password,omitempty" yaml:"password,omitempty"`
+	From     string   `json:"from" toml:"from" yaml:"from"`
+	To       []string `json:"to" toml:"to" yaml:"to"`
+}
+
+// NotificationFilters selects the reports a channel is notified about.
+// A report matches when its compliance rate is at least MinComplianceBelow
+// and below MaxComplianceBelow, and its domain is in Domains. Zero values
+// disable the corresponding check.
+type NotificationFilters struct {
+	MinComplianceBelow float64  `json:"min_compliance_below,omitempty" toml:"min_compliance_below,omitempty" yaml:"min_compliance_below,omitempty"`
+	MaxComplianceBelow float64  `json:"max_compliance_below,omitempty" toml:"max_compliance_below,omitempty" yaml:"max_compliance_below,omitempty"`
+	Domains            []string `json:"domains,omitempty" toml:"domains,omitempty" yaml:"domains,omitempty"`
+}
+
+// AMQPConfig holds RabbitMQ publisher configuration.
+// Publishing is disabled when URL is empty.
+type AMQPConfig struct {
+	URL      string `json:"url" toml:"url" yaml:"url" env:"AMQP_URL"`
+	Exchange string `json:"exchange" toml:"exchange" yaml:"exchange" env:"AMQP_EXCHANGE"`
+}
+
+// RedisConfig holds Redis pub/sub publisher configuration.
+// Publishing is disabled when Addr is empty.
+type RedisConfig struct {
+	Addr    string `json:"addr" toml:"addr" yaml:"addr" env:"REDIS_ADDR"`
+	Channel string `json:"channel" toml:"channel" yaml:"channel" env:"REDIS_CHANNEL" envDefault:"dmarc:reports"`
+}
+
+// KafkaConfig holds Kafka producer configuration.
+// Publishing is disabled when Brokers is empty.
+type KafkaConfig struct {
+	Brokers         []string `json:"brokers" toml:"brokers" yaml:"brokers" env:"KAFKA_BROKERS"`
+	Topic           string   `json:"topic" toml:"topic" yaml:"topic" env:"KAFKA_TOPIC" envDefault:"dmarc-reports"`
+	DeadLetterTopic string   `json:"dead_letter_topic,omitempty" toml:"dead_letter_topic,omitempty" yaml:"dead_letter_topic,omitempty" env:"KAFKA_DEAD_LETTER_TOPIC"`
+	TLS             bool     `json:"tls" toml:"tls" yaml:"tls" env:"KAFKA_TLS"`
+	SASLMechanism   string   `json:"sasl_mechanism,omitempty" toml:"sasl_mechanism,omitempty" yaml:"sasl_mechanism,omitempty" env:"KAFKA_SASL_MECHANISM"`
+	SASLUser        string   `json:"sasl_user,omitempty" toml:"sasl_user,omitempty" yaml:"sasl_user,omitempty" env:"KAFKA_SASL_USER"`
+	SASLPassword    string   `json:"sasl_password,omitempty" toml:"sasl_password,omitempty" yaml:"sasl_password,omitempty" env:"KAFKA_SASL_PASSWORD"`
+}
+
+// Matches reports whether the alert applies to the given domain
+func (a AlertConfig) Matches(domain string) bool {
+	return a.Domain == "" || strings.EqualFold(a.Domain, domain)
 }
 
 func defaultDBPath() (string, error) {
@@ -73,8 +326,58 @@ func ensureDBPathExists(dbPath string) error {
 	return nil
 }
 
-// Load loads configuration from a JSON file
+// Config file formats, chosen by file extension
+const (
+	formatJSON = "json"
+	formatTOML = "toml"
+	formatYAML = "yaml"
+	// formatAuto reads valid JSON as JSON and anything else as YAML, for
+	// paths without an extension
+	formatAuto = "auto"
+)
+
+// configFormat returns the config file format for path: TOML for .toml,
+// YAML for .yaml and .yml, auto-detection without an extension, and JSON
+// otherwise
+func configFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		return formatTOML
+	case ".yaml", ".yml":
+		return formatYAML
+	case "":
+		return formatAuto
+	default:
+		return formatJSON
+	}
+}
+
+// unmarshal decodes config file data in the format matching the path
+func unmarshal(path string, data []byte, cfg *Config) error {
+	switch configFormat(path) {
+	case formatTOML:
+		return toml.Unmarshal(data, cfg)
+	case formatYAML:
+		return yaml.Unmarshal(data, cfg)
+	case formatAuto:
+		if json.Valid(data) {
+			return json.Unmarshal(data, cfg)
+		}
+		return yaml.Unmarshal(data, cfg)
+	default:
+		return json.Unmarshal(data, cfg)
+	}
+}
+
+// Load loads configuration from a JSON, TOML or YAML file
 func Load(path string) (*Config, error) {
+	return LoadWithOverrides(path, nil)
+}
+
+// LoadWithOverrides loads configuration like Load, then applies key=value
+// overrides such as imap.port=143. Overrides take precedence over the file
+// and environment variables.
+func LoadWithOverrides(path string, overrides []string) (*Config, error) {
 	var cfg Config
 	var err error
 
@@ -88,9 +391,19 @@ func Load(path string) (*Config, error) {
 			return nil, fmt.Errorf("read config file %s: %w", path, err)
 		}
 
-		if err := json.Unmarshal(data, &cfg); err != nil {
+		if err := unmarshal(path, data, &cfg); err != nil {
 			return nil, fmt.Errorf("parse config file %s: %w", path, err)
 		}
+		if err := expandEnvRefs(&cfg, os.LookupEnv); err != nil {
+			return nil, fmt.Errorf("parse config file %s: %w", path, err)
+		}
+	}
+
+	if err := applyPathEnv(&cfg, os.LookupEnv); err != nil {
+		return nil, fmt.Errorf("parse env config: %w", err)
+	}
+	if err := applyOverrides(&cfg, overrides); err != nil {
+		return nil, fmt.Errorf("apply --set: %w", err)
 	}
 
 	if cfg.IMAP.Port == 0 {
@@ -115,6 +428,12 @@ func Load(path string) (*Config, error) {
 	if cfg.Server.Port == 0 {
 		cfg.Server.Port = 8080
 	}
+	if cfg.Server.IngestPath == "" {
+		cfg.Server.IngestPath = "/ingest"
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
 
 	return &cfg, nil
 }
@@ -132,10 +451,23 @@ func (c *Config) Validate() error {
 	if c.IMAP.Password == "" {
 		return ErrMissingIMAPPassword
 	}
+	if c.Database.AutoVacuum == "full" {
+		return ErrAutoVacuumFull
+	}
 	return nil
 }
 
-// GenerateSample creates a sample configuration file
+// WebhookAPIKeys returns the API keys accepted by the report webhook:
+// IngestAPIKeys followed by JWT.APIKeys
+func (s *ServerConfig) WebhookAPIKeys() []string {
+	keys := make([]string, 0, len(s.IngestAPIKeys)+len(s.JWT.APIKeys))
+	keys = append(keys, s.IngestAPIKeys...)
+	return append(keys, s.JWT.APIKeys...)
+}
+
+// GenerateSample creates a sample configuration file.
+// The output format is TOML when path ends in .toml, YAML when it ends in
+// .yaml or .yml, and JSON otherwise.
 func GenerateSample(path string) error {
 	dbPath, err := defaultDBPath()
 	if err != nil {
@@ -143,6 +475,7 @@ func GenerateSample(path string) error {
 	}
 	sample := Config{
 		LogLevel: "info",
+		Workers:  4,
 		IMAP: IMAPConfig{
 			Host:     "imap.example.com",
 			Port:     993,
@@ -155,12 +488,13 @@ func GenerateSample(path string) error {
 			Path: dbPath,
 		},
 		Server: ServerConfig{
-			Port: 8080,
-			Host: "0.0.0.0",
+			Port:       8080,
+			Host:       "0.0.0.0",
+			IngestPath: "/ingest",
 		},
 	}
 
-	data, err := json.MarshalIndent(sample, "", "  ")
+	data, err := marshal(path, sample)
 	if err != nil {
 		return fmt.Errorf("marshal sample config: %w", err)
 	}
@@ -171,3 +505,27 @@ func GenerateSample(path string) error {
 
 	return nil
 }
+
+// marshal encodes the config in the format matching the path extension
+func marshal(path string, cfg Config) ([]byte, error) {
+	var buf bytes.Buffer
+	switch configFormat(path) {
+	case formatTOML:
+		if err := toml.NewEncoder(&buf).Encode(cfg); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case formatYAML:
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(cfg); err != nil {
+			return nil, err
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return json.MarshalIndent(cfg, "", "  ")
+	}
+}
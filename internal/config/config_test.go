@@ -0,0 +1,503 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad_TOML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	data := `log_level = "debug"
+
+[imap]
+host = "imap.example.com"
+port = 143
+username = "dmarc@example.com"
+password = "secret"
+use_tls = false
+
+[database]
+path = "/tmp/parse-dmarc-test.sqlite"
+
+[server]
+host = "127.0.0.1"
+port = 9090
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if cfg.LogLevel != "debug" {
+		t.Errorf("Expected LogLevel debug, got %s", cfg.LogLevel)
+	}
+	if cfg.IMAP.Host != "imap.example.com" {
+		t.Errorf("Expected IMAP host imap.example.com, got %s", cfg.IMAP.Host)
+	}
+	if cfg.IMAP.Port != 143 {
+		t.Errorf("Expected IMAP port 143, got %d", cfg.IMAP.Port)
+	}
+	if cfg.IMAP.UseTLS {
+		t.Errorf("Expected UseTLS to be false")
+	}
+	if cfg.IMAP.Mailbox != "INBOX" {
+		t.Errorf("Expected default mailbox INBOX, got %s", cfg.IMAP.Mailbox)
+	}
+	if cfg.Server.Port != 9090 {
+		t.Errorf("Expected server port 9090, got %d", cfg.Server.Port)
+	}
+}
+
+func TestGenerateSample_TOML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := GenerateSample(path); err != nil {
+		t.Fatalf("Failed to generate sample: %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load generated sample: %v", err)
+	}
+
+	if cfg.IMAP.Host != "imap.example.com" {
+		t.Errorf("Expected IMAP host imap.example.com, got %s", cfg.IMAP.Host)
+	}
+	if cfg.Server.Host != "0.0.0.0" {
+		t.Errorf("Expected server host 0.0.0.0, got %s", cfg.Server.Host)
+	}
+}
+
+func TestLoad_YAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	data := `log_level: debug
+imap:
+  host: imap.example.com
+  port: 143
+  username: dmarc@example.com
+  password: secret
+  use_tls: false
+server:
+  port: 9090
+  read_timeout: 45s
+  rate_limit:
+    requests_per_second: 2.5
+  allowed_cidrs:
+    - 10.0.0.0/8
+notifications:
+  channels:
+    - type: webhook
+      url: https://hooks.example.com/dmarc
+      filters:
+        domains: [example.com]
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if cfg.LogLevel != "debug" {
+		t.Errorf("Expected LogLevel debug, got %s", cfg.LogLevel)
+	}
+	if cfg.IMAP.Port != 143 || cfg.IMAP.UseTLS {
+		t.Errorf("Expected IMAP port 143 without TLS, got %+v", cfg.IMAP)
+	}
+	if cfg.IMAP.Mailbox != "INBOX" {
+		t.Errorf("Expected default mailbox INBOX, got %s", cfg.IMAP.Mailbox)
+	}
+	if cfg.Server.ReadTimeout != Duration(45*time.Second) {
+		t.Errorf("Expected read timeout 45s, got %s", cfg.Server.ReadTimeout)
+	}
+	if cfg.Server.RateLimit.RequestsPerSecond != 2.5 {
+		t.Errorf("Expected 2.5 requests per second, got %v", cfg.Server.RateLimit.RequestsPerSecond)
+	}
+	if len(cfg.Server.AllowedCIDRs) != 1 || cfg.Server.AllowedCIDRs[0] != "10.0.0.0/8" {
+		t.Errorf("Expected allowed CIDRs [10.0.0.0/8], got %v", cfg.Server.AllowedCIDRs)
+	}
+	channels := cfg.Notifications.Channels
+	if len(channels) != 1 || channels[0].URL != "https://hooks.example.com/dmarc" || len(channels[0].Filters.Domains) != 1 {
+		t.Errorf("Expected one webhook channel filtered to example.com, got %+v", channels)
+	}
+}
+
+func TestGenerateSample_YAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := GenerateSample(path); err != nil {
+		t.Fatalf("Failed to generate sample: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read sample: %v", err)
+	}
+	if !strings.Contains(string(data), "imap:\n  host: imap.example.com\n") {
+		t.Errorf("Expected YAML sample, got:\n%s", data)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load generated sample: %v", err)
+	}
+	if cfg.IMAP.Host != "imap.example.com" {
+		t.Errorf("Expected IMAP host imap.example.com, got %s", cfg.IMAP.Host)
+	}
+	if cfg.Server.Host != "0.0.0.0" {
+		t.Errorf("Expected server host 0.0.0.0, got %s", cfg.Server.Host)
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	want := Config{
+		LogLevel: "debug",
+		Workers:  8,
+		IMAP:     IMAPConfig{Host: "imap.example.com", Port: 993, Username: "dmarc", Password: "secret", Mailbox: "DMARC", UseTLS: true},
+		Database: DatabaseConfig{Path: "/data/db.sqlite", AutoVacuum: "incremental"},
+		Server: ServerConfig{
+			Port:         8080,
+			ReadTimeout:  Duration(30 * time.Second),
+			AllowedCIDRs: []string{"10.0.0.0/8"},
+			RateLimit:    RateLimitConfig{RequestsPerSecond: 1.5, Burst: 3},
+		},
+		Notifications: NotificationsConfig{
+			Kafka: KafkaConfig{Brokers: []string{"kafka:9092"}, Topic: "dmarc-reports"},
+			Channels: []NotificationChannel{{
+				Type:    ChannelSMTP,
+				SMTP:    SMTPConfig{Host: "smtp.example.com", Port: 587, From: "dmarc@example.com", To: []string{"ops@example.com"}},
+				Filters: NotificationFilters{MaxComplianceBelow: 90},
+			}},
+		},
+	}
+
+	for _, name := range []string{"config.json", "config.toml", "config.yaml", "config.yml"} {
+		t.Run(name, func(t *testing.T) {
+			data, err := marshal(name, want)
+			if err != nil {
+				t.Fatalf("Failed to marshal: %v", err)
+			}
+			var got Config
+			if err := unmarshal(name, data, &got); err != nil {
+				t.Fatalf("Failed to unmarshal: %v\n%s", err, data)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Config did not round-trip:\nwant: %+v\ngot:  %+v", want, got)
+			}
+		})
+	}
+}
+
+func TestConfigFormat(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"config.json", formatJSON},
+		{"config.toml", formatTOML},
+		{"/etc/parse-dmarc/config.TOML", formatTOML},
+		{"config.yaml", formatYAML},
+		{"config.yml", formatYAML},
+		{"config.YML", formatYAML},
+		{"config", formatAuto},
+		{"/etc/parse-dmarc.d/config", formatAuto},
+		{"config.conf", formatJSON},
+	}
+
+	for _, tt := range tests {
+		if got := configFormat(tt.path); got != tt.want {
+			t.Errorf("configFormat(%q): expected %s, got %s", tt.path, tt.want, got)
+		}
+	}
+}
+
+func TestLoad_NoExtension(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"json", `{"imap": {"host": "imap.example.com"}}`},
+		{"yaml", "imap:\n  host: imap.example.com\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config")
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+
+			cfg, err := Load(path)
+			if err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			if cfg.IMAP.Host != "imap.example.com" {
+				t.Errorf("Expected IMAP host imap.example.com, got %s", cfg.IMAP.Host)
+			}
+		})
+	}
+}
+
+func TestLoad_MetricsBasicAuthEnv(t *testing.T) {
+	t.Setenv("BASIC_AUTH_USERNAME", "dashboard")
+	t.Setenv("METRICS_BASIC_AUTH_USERNAME", "prometheus")
+
+	cfg, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if cfg.Server.BasicAuth.Username != "dashboard" {
+		t.Errorf("Expected dashboard username dashboard, got %s", cfg.Server.BasicAuth.Username)
+	}
+	if cfg.Metrics.BasicAuth.Username != "prometheus" {
+		t.Errorf("Expected metrics username prometheus, got %s", cfg.Metrics.BasicAuth.Username)
+	}
+}
+
+func TestLoad_ServerTimeouts(t *testing.T) {
+	t.Setenv("SERVER_WRITE_TIMEOUT", "5m")
+
+	cfg, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if cfg.Server.ReadTimeout != Duration(30*time.Second) {
+		t.Errorf("Expected default read timeout 30s, got %s", cfg.Server.ReadTimeout)
+	}
+	if cfg.Server.WriteTimeout != Duration(5*time.Minute) {
+		t.Errorf("Expected write timeout 5m, got %s", cfg.Server.WriteTimeout)
+	}
+	if cfg.Server.ReadHeaderTimeout != Duration(10*time.Second) {
+		t.Errorf("Expected default read header timeout 10s, got %s", cfg.Server.ReadHeaderTimeout)
+	}
+}
+
+func TestLoad_Ingest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"server": {"ingest_enabled": true}}`), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if !cfg.Server.IngestEnabled {
+		t.Error("Expected ingest to be enabled")
+	}
+	if cfg.Server.IngestPath != "/ingest" {
+		t.Errorf("Expected default ingest path /ingest, got %s", cfg.Server.IngestPath)
+	}
+
+	t.Setenv("SERVER_INGEST_PATH", "/dmarc/push")
+	cfg, err = Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Server.IngestPath != "/dmarc/push" {
+		t.Errorf("Expected ingest path /dmarc/push, got %s", cfg.Server.IngestPath)
+	}
+}
+
+func TestLoad_PathEnvOverridesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	data := `[server]
+read_timeout = "10s"
+
+[notifications.slack]
+webhook_url = "https://hooks.slack.com/services/file"
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	t.Setenv("PARSE_DMARC_SERVER_READ_TIMEOUT", "45s")
+	t.Setenv("PARSE_DMARC_NOTIFICATIONS_SLACK_WEBHOOK_URL", "https://hooks.slack.com/services/env")
+	t.Setenv("PARSE_DMARC_SERVER_JWT_API_KEYS", "one, two")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if cfg.Server.ReadTimeout != Duration(45*time.Second) {
+		t.Errorf("Expected read timeout 45s, got %s", cfg.Server.ReadTimeout)
+	}
+	if cfg.Notifications.Slack.WebhookURL != "https://hooks.slack.com/services/env" {
+		t.Errorf("Expected Slack webhook from env, got %s", cfg.Notifications.Slack.WebhookURL)
+	}
+	if len(cfg.Server.JWT.APIKeys) != 2 || cfg.Server.JWT.APIKeys[1] != "two" {
+		t.Errorf("Expected API keys [one two], got %v", cfg.Server.JWT.APIKeys)
+	}
+}
+
+func TestLoad_PathEnvInvalid(t *testing.T) {
+	t.Setenv("PARSE_DMARC_SERVER_PORT", "http")
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Errorf("Expected error for invalid PARSE_DMARC_SERVER_PORT, got nil")
+	}
+}
+
+func TestLoadWithOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	data := `[imap]
+port = 993
+use_tls = true
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	t.Setenv("PARSE_DMARC_IMAP_HOST", "imap.env.example.com")
+
+	cfg, err := LoadWithOverrides(path, []string{
+		"imap.port=143",
+		"imap.use_tls=false",
+		"imap.host=imap.example.com",
+		"server.read_timeout=1m",
+	})
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if cfg.IMAP.Port != 143 {
+		t.Errorf("Expected port 143, got %d", cfg.IMAP.Port)
+	}
+	if cfg.IMAP.UseTLS {
+		t.Error("Expected use_tls false")
+	}
+	if cfg.IMAP.Host != "imap.example.com" {
+		t.Errorf("Expected override to win over env, got %s", cfg.IMAP.Host)
+	}
+	if cfg.Server.ReadTimeout != Duration(time.Minute) {
+		t.Errorf("Expected read timeout 1m, got %s", cfg.Server.ReadTimeout)
+	}
+
+	for _, override := range []string{"imap.port", "imap.nope=1", "imap.port=http", "imap=x"} {
+		if _, err := LoadWithOverrides(path, []string{override}); err == nil {
+			t.Errorf("Expected error for override %q", override)
+		}
+	}
+}
+
+func TestLoad_EnvInterpolation(t *testing.T) {
+	t.Setenv("TEST_IMAP_PASSWORD", "s3cret")
+	t.Setenv("TEST_SLACK_TOKEN", "T000/B000")
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	data := `
+[imap]
+host = "imap.example.com"
+password = "${TEST_IMAP_PASSWORD}"
+
+[[notifications.channels]]
+type = "slack"
+url = "https://hooks.slack.com/services/${TEST_SLACK_TOKEN}"
+`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.IMAP.Password != "s3cret" {
+		t.Errorf("Expected expanded password, got %q", cfg.IMAP.Password)
+	}
+	if got := cfg.Notifications.Channels[0].URL; got != "https://hooks.slack.com/services/T000/B000" {
+		t.Errorf("Expected expanded channel URL, got %q", got)
+	}
+}
+
+func TestLoad_EnvInterpolationUnset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"imap": {"password": "${TEST_UNSET_VARIABLE}"}}`), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	_, err := Load(path)
+	if err == nil {
+		t.Fatal("Expected error for unset variable")
+	}
+	if !strings.Contains(err.Error(), "imap.password") || !strings.Contains(err.Error(), "TEST_UNSET_VARIABLE") {
+		t.Errorf("Expected error to name the field and variable, got %v", err)
+	}
+}
+
+func TestExpandEnvString(t *testing.T) {
+	lookup := func(name string) (string, bool) {
+		if name == "EMPTY" {
+			return "", true
+		}
+		if name == "USER" {
+			return "dmarc", true
+		}
+		return "", false
+	}
+	tests := map[string]string{
+		"plain":              "plain",
+		"${USER}":            "dmarc",
+		"${USER}@${USER}.io": "dmarc@dmarc.io",
+		"pre${EMPTY}post":    "prepost",
+		"$USER":              "$USER",
+		"$2a$10$hash":        "$2a$10$hash",
+	}
+	for in, want := range tests {
+		got, err := expandEnvString(in, lookup)
+		if err != nil {
+			t.Errorf("expandEnvString(%q): unexpected error: %v", in, err)
+		} else if got != want {
+			t.Errorf("expandEnvString(%q): expected %q, got %q", in, want, got)
+		}
+	}
+}
+
+func TestLoad_JSONDurations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"server": {"read_timeout": "45s"}}`), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Server.ReadTimeout != Duration(45*time.Second) {
+		t.Errorf("Expected read timeout 45s, got %s", cfg.Server.ReadTimeout)
+	}
+
+	// A bare number would otherwise be read as nanoseconds
+	if err := os.WriteFile(path, []byte(`{"server": {"read_timeout": 30}}`), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Expected an error for a numeric JSON duration")
+	}
+
+	data, err := marshal("config.json", *cfg)
+	if err != nil {
+		t.Fatalf("Failed to marshal config: %v", err)
+	}
+	if !strings.Contains(string(data), `"read_timeout": "45s"`) {
+		t.Errorf("Expected read_timeout written as a duration string, got %s", data)
+	}
+}
+
+func TestValidate_AutoVacuum(t *testing.T) {
+	cfg := &Config{IMAP: IMAPConfig{Host: "imap.example.com", Username: "u", Password: "p"}}
+	for mode, wantErr := range map[string]bool{"": false, "none": false, "incremental": false, "full": true} {
+		cfg.Database.AutoVacuum = mode
+		if err := cfg.Validate(); (err != nil) != wantErr {
+			t.Errorf("auto_vacuum %q: expected error %v, got %v", mode, wantErr, err)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// Duration is a time.Duration read and written as a string such as "30s"
+// in every config format and environment variable
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using time.ParseDuration
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// UnmarshalJSON accepts a duration string. Bare numbers are rejected rather
+// than read as nanoseconds, which would make "read_timeout": 30 mean 30ns.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	if len(data) == 0 || data[0] != '"' {
+		return fmt.Errorf("invalid duration %s: expected a string such as \"30s\"", bytes.TrimSpace(data))
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return d.UnmarshalText([]byte(s))
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pathEnvPrefix starts environment variables that set a config field by its
+// path, e.g. PARSE_DMARC_SERVER_READ_TIMEOUT sets server.read_timeout
+const pathEnvPrefix = "PARSE_DMARC_"
+
+var durationType = reflect.TypeOf(Duration(0))
+
+// EnvVar describes an environment variable that sets a config field
+type EnvVar struct {
+	// Name is the PARSE_DMARC_ variable derived from the field path
+	Name string `json:"name"`
+	// Path is the dotted TOML key of the field
+	Path string `json:"path"`
+	Type string `json:"type"`
+	// Alias is the field's shorter variable, such as IMAP_HOST, if it has one
+	Alias string `json:"alias,omitempty"`
+}
+
+// EnvVars lists the variables that can set config fields. Lists of tables,
+// such as notification channels, can only be set in the config file.
+func EnvVars() []EnvVar {
+	var vars []EnvVar
+	walkEnvFields(reflect.TypeOf(Config{}), "", "", nil, func(v EnvVar, _ []int) {
+		vars = append(vars, v)
+	})
+	return vars
+}
+
+// applyPathEnv sets config fields from PARSE_DMARC_ variables. It runs
+// after the config file is read, so these variables take precedence.
+func applyPathEnv(cfg *Config, lookup func(string) (string, bool)) error {
+	root := reflect.ValueOf(cfg).Elem()
+	var err error
+	walkEnvFields(root.Type(), "", "", nil, func(v EnvVar, index []int) {
+		raw, ok := lookup(v.Name)
+		if !ok || err != nil {
+			return
+		}
+		if setErr := setEnvField(root.FieldByIndex(index), raw); setErr != nil {
+			err = fmt.Errorf("%s: %w", v.Name, setErr)
+		}
+	})
+	return err
+}
+
+// walkEnvFields calls fn for every settable leaf field of t
+func walkEnvFields(t reflect.Type, path, aliasPrefix string, index []int, fn func(EnvVar, []int)) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		key, _, _ := strings.Cut(f.Tag.Get("toml"), ",")
+		if key == "" || key == "-" || !f.IsExported() {
+			continue
+		}
+		fieldPath := key
+		if path != "" {
+			fieldPath = path + "." + key
+		}
+		fieldIndex := append(append([]int{}, index...), i)
+
+		if f.Type.Kind() == reflect.Struct {
+			walkEnvFields(f.Type, fieldPath, aliasPrefix+f.Tag.Get("envPrefix"), fieldIndex, fn)
+			continue
+		}
+
+		typ := envFieldType(f.Type)
+		if typ == "" {
+			continue
+		}
+		v := EnvVar{
+			Name: pathEnvPrefix + strings.ToUpper(strings.ReplaceAll(fieldPath, ".", "_")),
+			Path: fieldPath,
+			Type: typ,
+		}
+		if alias, _, _ := strings.Cut(f.Tag.Get("env"), ","); alias != "" {
+			v.Alias = aliasPrefix + alias
+		}
+		fn(v, fieldIndex)
+	}
+}
+
+// envFieldType names the value type of a settable field, or returns "" if
+// the field cannot be set from a single variable
+func envFieldType(t reflect.Type) string {
+	if t == durationType {
+		return "duration"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int64:
+		return "int"
+	case reflect.Float64:
+		return "float"
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.String {
+			return "list"
+		}
+	}
+	return ""
+}
+
+// setEnvField parses raw into v. Lists are comma-separated.
+func setEnvField(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// envRefPattern matches ${NAME} references in config values
+var envRefPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnvRefs replaces ${NAME} in every string field of cfg with the value
+// of the environment variable NAME, so secrets can stay out of the config
+// file. A reference to a variable that is not set is an error.
+func expandEnvRefs(cfg *Config, lookup func(string) (string, bool)) error {
+	return expandValue(reflect.ValueOf(cfg).Elem(), "", lookup)
+}
+
+// expandValue expands references in v and everything it contains. path is
+// the dotted TOML key of v, used in errors.
+func expandValue(v reflect.Value, path string, lookup func(string) (string, bool)) error {
+	switch v.Kind() {
+	case reflect.String:
+		expanded, err := expandEnvString(v.String(), lookup)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		v.SetString(expanded)
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			key, _, _ := strings.Cut(f.Tag.Get("toml"), ",")
+			if key == "" || key == "-" {
+				key = f.Name
+			}
+			if path != "" {
+				key = path + "." + key
+			}
+			if err := expandValue(v.Field(i), key, lookup); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := expandValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), lookup); err != nil {
+				return err
+			}
+		}
+	case reflect.Pointer:
+		if !v.IsNil() {
+			return expandValue(v.Elem(), path, lookup)
+		}
+	}
+	return nil
+}
+
+// expandEnvString replaces the ${NAME} references in s
+func expandEnvString(s string, lookup func(string) (string, bool)) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	var missing string
+	expanded := envRefPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		name := ref[2 : len(ref)-1]
+		value, ok := lookup(name)
+		if !ok && missing == "" {
+			missing = name
+		}
+		return value
+	})
+	if missing != "" {
+		return "", fmt.Errorf("environment variable %s is not set", missing)
+	}
+	return expanded, nil
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// applyOverrides sets config fields from key=value pairs, such as those
+// given with --set, where key is the dotted path of the field, e.g.
+// imap.port=143. Keys are resolved like the PARSE_DMARC_ variables, and
+// later pairs win.
+func applyOverrides(cfg *Config, overrides []string) error {
+	if len(overrides) == 0 {
+		return nil
+	}
+
+	root := reflect.ValueOf(cfg).Elem()
+	fields := make(map[string][]int)
+	walkEnvFields(root.Type(), "", "", nil, func(v EnvVar, index []int) {
+		fields[v.Path] = index
+	})
+
+	for _, override := range overrides {
+		key, value, ok := strings.Cut(override, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !ok || key == "" {
+			return fmt.Errorf("invalid override %q: expected key=value", override)
+		}
+		index, ok := fields[key]
+		if !ok {
+			return fmt.Errorf("unknown config key %q", key)
+		}
+		if err := setEnvField(root.FieldByIndex(index), value); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return nil
+}
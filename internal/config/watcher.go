@@ -0,0 +1,53 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Watcher reloads the configuration file when the process receives SIGHUP
+type Watcher struct {
+	// Overrides are applied to every reload, as by LoadWithOverrides
+	Overrides []string
+	// OnError is called when a reload fails; the previous configuration
+	// stays in effect
+	OnError func(error)
+
+	sig chan os.Signal
+}
+
+// Notify starts listening for SIGHUP. Watch calls it itself; call it
+// beforehand when Watch runs in a goroutine, so a SIGHUP that arrives before
+// Watch starts is queued instead of terminating the process.
+func (w *Watcher) Notify() {
+	if w.sig == nil {
+		w.sig = make(chan os.Signal, 1)
+		signal.Notify(w.sig, syscall.SIGHUP)
+	}
+}
+
+// Watch reloads the configuration from path on every SIGHUP and passes it
+// to onChange, until ctx is done. It blocks and returns ctx.Err(). While
+// Watch runs, SIGHUP no longer terminates the process.
+func (w *Watcher) Watch(ctx context.Context, path string, onChange func(*Config)) error {
+	w.Notify()
+	defer signal.Stop(w.sig)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-w.sig:
+			cfg, err := LoadWithOverrides(path, w.Overrides)
+			if err != nil {
+				if w.OnError != nil {
+					w.OnError(err)
+				}
+				continue
+			}
+			onChange(cfg)
+		}
+	}
+}
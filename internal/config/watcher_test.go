@@ -0,0 +1,164 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestWatcher_ReloadsOnSIGHUP(t *testing.T) {
+	// Keep SIGHUP from terminating the test binary before Watch is listening
+	guard := make(chan os.Signal, 1)
+	signal.Notify(guard, syscall.SIGHUP)
+	defer signal.Stop(guard)
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	writeConfig := func(host, interval string) {
+		t.Helper()
+		data := "fetch_interval = \"" + interval + "\"\n\n[imap]\nhost = \"" + host + "\"\n"
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+	}
+	writeConfig("imap.example.com", "5m")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changed := make(chan *Config, 1)
+	done := make(chan error, 1)
+	w := &Watcher{
+		Overrides: []string{"imap.username=override@example.com"},
+		OnError:   func(err error) { t.Errorf("Unexpected reload error: %v", err) },
+	}
+	go func() {
+		done <- w.Watch(ctx, path, func(cfg *Config) {
+			select {
+			case changed <- cfg:
+			default:
+			}
+		})
+	}()
+
+	writeConfig("imap.new.example.com", "10m")
+
+	// Watch may not have registered for SIGHUP yet, so keep signalling
+	// until the reload is observed
+	var cfg *Config
+	deadline := time.After(5 * time.Second)
+	tick := time.NewTicker(50 * time.Millisecond)
+	defer tick.Stop()
+	for cfg == nil {
+		if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+			t.Fatalf("Failed to send SIGHUP: %v", err)
+		}
+		select {
+		case cfg = <-changed:
+		case <-tick.C:
+		case <-deadline:
+			t.Fatal("Timed out waiting for config reload")
+		}
+	}
+
+	if cfg.IMAP.Host != "imap.new.example.com" {
+		t.Errorf("Expected IMAP host imap.new.example.com, got %s", cfg.IMAP.Host)
+	}
+	if cfg.FetchInterval != "10m" {
+		t.Errorf("Expected FetchInterval 10m, got %s", cfg.FetchInterval)
+	}
+	if cfg.IMAP.Username != "override@example.com" {
+		t.Errorf("Expected overridden IMAP username, got %s", cfg.IMAP.Username)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watch did not return after cancel")
+	}
+}
+
+func TestWatcher_KeepsConfigOnError(t *testing.T) {
+	guard := make(chan os.Signal, 1)
+	signal.Notify(guard, syscall.SIGHUP)
+	defer signal.Stop(guard)
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errs := make(chan error, 1)
+	w := &Watcher{OnError: func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	}}
+	go func() {
+		_ = w.Watch(ctx, path, func(*Config) {
+			t.Error("Expected onChange not to be called for an invalid config")
+		})
+	}()
+
+	deadline := time.After(5 * time.Second)
+	tick := time.NewTicker(50 * time.Millisecond)
+	defer tick.Stop()
+	for {
+		if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+			t.Fatalf("Failed to send SIGHUP: %v", err)
+		}
+		select {
+		case <-errs:
+			return
+		case <-tick.C:
+		case <-deadline:
+			t.Fatal("Timed out waiting for reload error")
+		}
+	}
+}
+
+func TestWatcher_NotifyQueuesEarlySIGHUP(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("[imap]\nhost = \"imap.example.com\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	w := &Watcher{OnError: func(err error) { t.Errorf("Unexpected reload error: %v", err) }}
+	w.Notify()
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatalf("Failed to send SIGHUP: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changed := make(chan *Config, 1)
+	go func() {
+		_ = w.Watch(ctx, path, func(cfg *Config) {
+			select {
+			case changed <- cfg:
+			default:
+			}
+		})
+	}()
+
+	select {
+	case cfg := <-changed:
+		if cfg.IMAP.Host != "imap.example.com" {
+			t.Errorf("Expected IMAP host imap.example.com, got %s", cfg.IMAP.Host)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the queued SIGHUP")
+	}
+}
@@ -0,0 +1,128 @@
+// Package geoip enriches source IPs with country and ASN data from
+// MaxMind GeoLite2 databases.
+package geoip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/oschwald/geoip2-golang"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// ASNDatabase is the GeoLite2 ASN database file name
+	ASNDatabase = "GeoLite2-ASN.mmdb"
+	// CountryDatabase is the GeoLite2 Country database file name
+	CountryDatabase = "GeoLite2-Country.mmdb"
+	// ReloadInterval is how often the databases are reopened from disk
+	ReloadInterval = 24 * time.Hour
+)
+
+// Resolver looks up country and ASN information for IP addresses
+type Resolver struct {
+	dir string
+	log *zerolog.Logger
+
+	mu      sync.RWMutex
+	asn     *geoip2.Reader
+	country *geoip2.Reader
+}
+
+// NewResolver opens the GeoLite2 databases found in dir
+func NewResolver(dir string, log *zerolog.Logger) (*Resolver, error) {
+	r := &Resolver{dir: dir, log: log}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reopens both databases, swapping them in atomically so lookups
+// keep working while updated files are picked up
+func (r *Resolver) Reload() error {
+	asn, err := geoip2.Open(filepath.Join(r.dir, ASNDatabase))
+	if err != nil {
+		return fmt.Errorf("open ASN database: %w", err)
+	}
+
+	country, err := geoip2.Open(filepath.Join(r.dir, CountryDatabase))
+	if err != nil {
+		_ = asn.Close()
+		return fmt.Errorf("open country database: %w", err)
+	}
+
+	r.mu.Lock()
+	oldASN, oldCountry := r.asn, r.country
+	r.asn, r.country = asn, country
+	r.mu.Unlock()
+
+	if oldASN != nil {
+		_ = oldASN.Close()
+	}
+	if oldCountry != nil {
+		_ = oldCountry.Close()
+	}
+
+	return nil
+}
+
+// Run reloads the databases every ReloadInterval until ctx is cancelled
+func (r *Resolver) Run(ctx context.Context) {
+	ticker := time.NewTicker(ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.Reload(); err != nil {
+				r.log.Error().Err(err).Msg("failed to reload GeoIP databases")
+				continue
+			}
+			r.log.Info().Str("path", r.dir).Msg("reloaded GeoIP databases")
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// LookupIP returns the ISO country code, AS number and AS organization for ip.
+// Unknown or invalid addresses yield zero values.
+func (r *Resolver) LookupIP(ip string) (country string, asn int, asnOrg string) {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return "", 0, ""
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if rec, err := r.country.Country(addr); err == nil {
+		country = rec.Country.IsoCode
+	}
+	if rec, err := r.asn.ASN(addr); err == nil {
+		asn = int(rec.AutonomousSystemNumber)
+		asnOrg = rec.AutonomousSystemOrganization
+	}
+
+	return country, asn, asnOrg
+}
+
+// Close closes both databases
+func (r *Resolver) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var firstErr error
+	if err := r.asn.Close(); err != nil {
+		firstErr = err
+	}
+	if err := r.country.Close(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
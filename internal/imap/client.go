@@ -1,10 +1,13 @@
 package imap
 
 import (
+	"bytes"
 	"crypto/tls"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/emersion/go-imap"
 	"github.com/emersion/go-imap/client"
@@ -81,8 +84,27 @@ type Attachment struct {
 	Data     []byte
 }
 
-// FetchDMARCReports fetches DMARC reports from the mailbox
+// FetchDMARCReports fetches unseen DMARC reports from the mailbox
 func (c *Client) FetchDMARCReports() ([]Report, error) {
+	criteria := imap.NewSearchCriteria()
+	criteria.WithoutFlags = []string{imap.SeenFlag}
+	return c.fetchReports(criteria, false)
+}
+
+// FetchDMARCReportsBetween fetches the DMARC reports received on or after
+// since and before before, whether or not they have been seen. IMAP compares
+// dates only, so times are truncated to the day; a zero time leaves that end
+// of the window open. Messages are read without setting \Seen.
+func (c *Client) FetchDMARCReportsBetween(since, before time.Time) ([]Report, error) {
+	criteria := imap.NewSearchCriteria()
+	criteria.Since = since
+	criteria.Before = before
+	return c.fetchReports(criteria, true)
+}
+
+// fetchReports fetches the messages matching criteria and returns those with
+// report attachments. With peek set, the \Seen flag is left unchanged.
+func (c *Client) fetchReports(criteria *imap.SearchCriteria, peek bool) ([]Report, error) {
 	// Select mailbox
 	mbox, err := c.client.Select(c.config.Mailbox, false)
 	if err != nil {
@@ -94,21 +116,17 @@ func (c *Client) FetchDMARCReports() ([]Report, error) {
 		return []Report{}, nil
 	}
 
-	// Search for unseen messages
-	criteria := imap.NewSearchCriteria()
-	criteria.WithoutFlags = []string{imap.SeenFlag}
-
 	ids, err := c.client.Search(criteria)
 	if err != nil {
 		return nil, fmt.Errorf("search failed: %w", err)
 	}
 
 	if len(ids) == 0 {
-		c.log.Info().Msg("no new messages found")
+		c.log.Info().Msg("no matching messages found")
 		return []Report{}, nil
 	}
 
-	c.log.Info().Int("count", len(ids)).Msg("found new messages")
+	c.log.Info().Int("count", len(ids)).Msg("found messages")
 
 	seqSet := new(imap.SeqSet)
 	seqSet.AddNum(ids...)
@@ -116,7 +134,7 @@ func (c *Client) FetchDMARCReports() ([]Report, error) {
 	messages := make(chan *imap.Message, 10)
 	done := make(chan error, 1)
 
-	section := &imap.BodySectionName{}
+	section := &imap.BodySectionName{Peek: peek}
 	items := []imap.FetchItem{section.FetchItem(), imap.FetchEnvelope, imap.FetchFlags}
 
 	go func() {
@@ -147,35 +165,7 @@ func (c *Client) FetchDMARCReports() ([]Report, error) {
 			report.From = msg.Envelope.From[0].Address()
 		}
 
-		// Process email parts
-		for {
-			part, err := mr.NextPart()
-			if err == io.EOF {
-				break
-			}
-			if err != nil {
-				c.log.Warn().Err(err).Msg("error reading part")
-				break
-			}
-
-			switch h := part.Header.(type) {
-			case *mail.AttachmentHeader:
-				filename, _ := h.Filename()
-				// Only process DMARC-related attachments
-				if isDMARCAttachment(filename) {
-					data, err := io.ReadAll(part.Body)
-					if err != nil {
-						c.log.Warn().Err(err).Msg("error reading attachment")
-						continue
-					}
-
-					report.Attachments = append(report.Attachments, Attachment{
-						Filename: filename,
-						Data:     data,
-					})
-				}
-			}
-		}
+		report.Attachments = readAttachments(mr, c.log)
 
 		// Only add reports with attachments
 		if len(report.Attachments) > 0 {
@@ -190,6 +180,76 @@ func (c *Client) FetchDMARCReports() ([]Report, error) {
 	return reports, nil
 }
 
+// ReadAttachments returns the DMARC report attachments of the message read
+// from r, such as one pushed by a mail server instead of fetched over IMAP
+func ReadAttachments(r io.Reader, log *zerolog.Logger) ([]Attachment, error) {
+	mr, err := mail.CreateReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("read message: %w", err)
+	}
+	return readAttachments(mr, log), nil
+}
+
+// readAttachments returns the DMARC report attachments of a message. The
+// mail reader undoes each part's Content-Transfer-Encoding, so the data
+// handed to the parser starts with the gzip, zip or XML magic bytes it
+// detects formats by, whatever the filename says.
+func readAttachments(mr *mail.Reader, log *zerolog.Logger) []Attachment {
+	var attachments []Attachment
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Warn().Err(err).Msg("error reading part")
+			break
+		}
+
+		switch h := part.Header.(type) {
+		case *mail.AttachmentHeader:
+			filename, _ := h.Filename()
+			// Only process DMARC-related attachments
+			if isDMARCAttachment(filename) {
+				data, err := io.ReadAll(part.Body)
+				if err != nil {
+					log.Warn().Err(err).Msg("error reading attachment")
+					continue
+				}
+
+				attachments = append(attachments, Attachment{
+					Filename: filename,
+					Data:     decodeAttachment(data),
+				})
+			}
+		}
+	}
+	return attachments
+}
+
+// decodeAttachment base64-decodes data that is still encoded, as sent by
+// mailers that omit the Content-Transfer-Encoding header. Data that is
+// already XML, gzip or zip, or does not decode to one of them, is returned
+// unchanged.
+func decodeAttachment(data []byte) []byte {
+	if looksLikeReport(data) {
+		return data
+	}
+	decoded, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, bytes.NewReader(bytes.Join(bytes.Fields(data), nil))))
+	if err != nil || !looksLikeReport(decoded) {
+		return data
+	}
+	return decoded
+}
+
+// looksLikeReport reports whether data starts like an XML, gzip or zip file
+func looksLikeReport(data []byte) bool {
+	trimmed := bytes.TrimLeft(data, " \t\r\n\xef\xbb\xbf")
+	return bytes.HasPrefix(trimmed, []byte("<")) ||
+		bytes.HasPrefix(data, []byte{0x1f, 0x8b}) ||
+		bytes.HasPrefix(data, []byte("PK\x03\x04"))
+}
+
 // MarkAsSeen marks messages as seen
 func (c *Client) MarkAsSeen(messageIDs []uint32) error {
 	if len(messageIDs) == 0 {
@@ -209,7 +269,7 @@ func (c *Client) MarkAsSeen(messageIDs []uint32) error {
 func isDMARCAttachment(filename string) bool {
 	lower := strings.ToLower(filename)
 	return strings.HasSuffix(lower, ".xml") ||
-		strings.HasSuffix(lower, ".xml.gz") ||
+		strings.HasSuffix(lower, ".gz") ||
 		strings.HasSuffix(lower, ".zip") ||
 		strings.Contains(lower, "dmarc")
 }
@@ -0,0 +1,55 @@
+package imap
+
+import (
+	"os"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/meysam81/parse-dmarc/internal/parser"
+)
+
+func TestReadAttachments_Base64Gzip(t *testing.T) {
+	nop := zerolog.Nop()
+
+	for _, fixture := range []string{"testdata/base64_gzip.eml", "testdata/base64_gzip_no_cte.eml"} {
+		t.Run(fixture, func(t *testing.T) {
+			f, err := os.Open(fixture)
+			if err != nil {
+				t.Fatalf("Failed to open fixture: %v", err)
+			}
+			defer func() { _ = f.Close() }()
+
+			attachments, err := ReadAttachments(f, &nop)
+			if err != nil {
+				t.Fatalf("Failed to read message: %v", err)
+			}
+			if len(attachments) != 1 {
+				t.Fatalf("Expected 1 attachment, got %d", len(attachments))
+			}
+			if got := parser.DetectFormat(attachments[0].Data); got != parser.FormatGzip {
+				t.Errorf("Expected gzip data despite the .xml filename, got %s", got)
+			}
+
+			feedback, err := parser.ParseReport(attachments[0].Data)
+			if err != nil {
+				t.Fatalf("Failed to parse attachment: %v", err)
+			}
+			if feedback.ReportMetadata.ReportID != "base64-gzip" {
+				t.Errorf("Expected report ID 'base64-gzip', got '%s'", feedback.ReportMetadata.ReportID)
+			}
+		})
+	}
+}
+
+func TestDecodeAttachment(t *testing.T) {
+	xml := []byte("<feedback></feedback>")
+	if got := decodeAttachment(xml); string(got) != string(xml) {
+		t.Errorf("Expected XML to be returned unchanged, got %q", got)
+	}
+
+	text := []byte("not a report")
+	if got := decodeAttachment(text); string(got) != string(text) {
+		t.Errorf("Expected undecodable data to be returned unchanged, got %q", got)
+	}
+}
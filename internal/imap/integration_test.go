@@ -0,0 +1,110 @@
+//go:build integration
+
+package imap
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-imap/backend/memory"
+	"github.com/emersion/go-imap/server"
+	"github.com/rs/zerolog"
+
+	"github.com/meysam81/parse-dmarc/internal/config"
+	"github.com/meysam81/parse-dmarc/internal/parser"
+	"github.com/meysam81/parse-dmarc/internal/storage"
+)
+
+// TestIMAPIntegration fetches a report from an in-process IMAP server,
+// parses it and stores it. Run with: go test -tags integration ./internal/imap/
+func TestIMAPIntegration(t *testing.T) {
+	message, err := os.ReadFile("testdata/base64_gzip.eml")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	// The memory backend has a single user, username/password
+	be := memory.New()
+	user, err := be.Login(nil, "username", "password")
+	if err != nil {
+		t.Fatalf("Failed to log in to backend: %v", err)
+	}
+	mbox, err := user.GetMailbox("INBOX")
+	if err != nil {
+		t.Fatalf("Failed to get mailbox: %v", err)
+	}
+	if err := mbox.CreateMessage(nil, time.Now(), bytes.NewBuffer(message)); err != nil {
+		t.Fatalf("Failed to add message: %v", err)
+	}
+
+	srv := server.New(be)
+	srv.AllowInsecureAuth = true
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go func() { _ = srv.Serve(ln) }()
+	defer func() { _ = srv.Close() }()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	nop := zerolog.Nop()
+	c := NewClient(&config.IMAPConfig{
+		Host:     addr.IP.String(),
+		Port:     addr.Port,
+		Username: "username",
+		Password: "password",
+		Mailbox:  "INBOX",
+	}, &nop)
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer func() { _ = c.Disconnect() }()
+
+	reports, err := c.FetchDMARCReports()
+	if err != nil {
+		t.Fatalf("Failed to fetch reports: %v", err)
+	}
+	// The backend's seed message is already seen and has no attachment
+	if len(reports) != 1 || len(reports[0].Attachments) != 1 {
+		t.Fatalf("Expected 1 report with 1 attachment, got %+v", reports)
+	}
+	if reports[0].From != "dmarc@example.net" {
+		t.Errorf("Expected sender dmarc@example.net, got %s", reports[0].From)
+	}
+
+	feedback, err := parser.ParseReport(reports[0].Attachments[0].Data)
+	if err != nil {
+		t.Fatalf("Failed to parse attachment: %v", err)
+	}
+
+	store, err := storage.NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	if err := store.SaveReport(feedback); err != nil {
+		t.Fatalf("Failed to save report: %v", err)
+	}
+
+	saved, err := store.GetReports(storage.ReportFilter{ReportID: "base64-gzip"}, 1, 0)
+	if err != nil {
+		t.Fatalf("Failed to get reports: %v", err)
+	}
+	if len(saved) != 1 {
+		t.Fatalf("Expected 1 stored report, got %d", len(saved))
+	}
+	got := saved[0]
+	if got.OrgName != "example.net" || got.Domain != "example.com" {
+		t.Errorf("Expected example.net report for example.com, got %s for %s", got.OrgName, got.Domain)
+	}
+	if got.TotalMessages != 3 || got.CompliantMessages != 3 {
+		t.Errorf("Expected 3 compliant messages, got %d of %d", got.CompliantMessages, got.TotalMessages)
+	}
+	if got.DateBegin != 1609459200 || got.DateEnd != 1609545600 {
+		t.Errorf("Expected date range 1609459200-1609545600, got %d-%d", got.DateBegin, got.DateEnd)
+	}
+}
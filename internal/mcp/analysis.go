@@ -0,0 +1,318 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/meysam81/parse-dmarc/internal/storage"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// CompareDomainsInput is used for comparing two domains.
+type CompareDomainsInput struct {
+	DomainA       string `json:"domain_a" jsonschema:"the first domain to compare"`
+	DomainB       string `json:"domain_b" jsonschema:"the second domain to compare"`
+	DateRangeDays int    `json:"date_range_days,omitempty" jsonschema:"number of days to look back (default: 30)"`
+}
+
+// DomainComparison holds the compliance summary of one side of a comparison.
+type DomainComparison struct {
+	Domain            string                `json:"domain"`
+	TotalMessages     int                   `json:"total_messages"`
+	CompliantMessages int                   `json:"compliant_messages"`
+	FailedMessages    int                   `json:"failed_messages"`
+	ComplianceRate    float64               `json:"compliance_rate"`
+	SPFFailures       int                   `json:"spf_failures"`
+	DKIMFailures      int                   `json:"dkim_failures"`
+	Trend             []storage.TrendPoint  `json:"trend"`
+	TopSourceIPs      []storage.TopSourceIP `json:"top_source_ips"`
+}
+
+// CompareDomainsOutput wraps the domain comparison response.
+type CompareDomainsOutput struct {
+	DateRangeDays int              `json:"date_range_days"`
+	DomainA       DomainComparison `json:"domain_a"`
+	DomainB       DomainComparison `json:"domain_b"`
+	// OnlyInA and OnlyInB list top source IPs seen for just one of the domains.
+	OnlyInA    []string `json:"only_in_a"`
+	OnlyInB    []string `json:"only_in_b"`
+	Assessment string   `json:"assessment"`
+}
+
+// compareTopSourceLimit bounds the source IPs fetched per domain
+const compareTopSourceLimit = 10
+
+func (s *Server) compareDomains(ctx context.Context, req *mcp.CallToolRequest, input CompareDomainsInput) (*mcp.CallToolResult, CompareDomainsOutput, error) {
+	if input.DomainA == "" || input.DomainB == "" {
+		return nil, CompareDomainsOutput{}, fmt.Errorf("domain_a and domain_b are required")
+	}
+
+	days := input.DateRangeDays
+	if days <= 0 {
+		days = 30
+	}
+
+	a, err := s.summarizeDomain(input.DomainA, days)
+	if err != nil {
+		return nil, CompareDomainsOutput{}, err
+	}
+	b, err := s.summarizeDomain(input.DomainB, days)
+	if err != nil {
+		return nil, CompareDomainsOutput{}, err
+	}
+
+	onlyInA, onlyInB := diffSourceIPs(a.TopSourceIPs, b.TopSourceIPs)
+
+	return nil, CompareDomainsOutput{
+		DateRangeDays: days,
+		DomainA:       a,
+		DomainB:       b,
+		OnlyInA:       onlyInA,
+		OnlyInB:       onlyInB,
+		Assessment:    assessComparison(a, b, days),
+	}, nil
+}
+
+// summarizeDomain aggregates the compliance trend and top sources of a domain
+func (s *Server) summarizeDomain(domain string, days int) (DomainComparison, error) {
+	trend, err := s.store.GetComplianceTrend(domain, days)
+	if err != nil {
+		return DomainComparison{}, fmt.Errorf("failed to get compliance trend for %s: %w", domain, err)
+	}
+
+	sources, err := s.store.GetDomainTopSourceIPs(domain, days, compareTopSourceLimit)
+	if err != nil {
+		return DomainComparison{}, fmt.Errorf("failed to get top source IPs for %s: %w", domain, err)
+	}
+
+	c := DomainComparison{
+		Domain:       domain,
+		Trend:        trend,
+		TopSourceIPs: sources,
+	}
+	for _, p := range trend {
+		c.TotalMessages += p.TotalMessages
+		c.CompliantMessages += p.CompliantMessages
+		c.SPFFailures += p.SPFFailures
+		c.DKIMFailures += p.DKIMFailures
+	}
+	c.FailedMessages = c.TotalMessages - c.CompliantMessages
+	if c.TotalMessages > 0 {
+		c.ComplianceRate = float64(c.CompliantMessages) / float64(c.TotalMessages) * 100
+	}
+
+	return c, nil
+}
+
+// diffSourceIPs returns the source IPs present in only one of the lists
+func diffSourceIPs(a, b []storage.TopSourceIP) (onlyInA, onlyInB []string) {
+	inA := make(map[string]bool, len(a))
+	for _, ip := range a {
+		inA[ip.SourceIP] = true
+	}
+	inB := make(map[string]bool, len(b))
+	for _, ip := range b {
+		inB[ip.SourceIP] = true
+	}
+
+	onlyInA, onlyInB = []string{}, []string{}
+	for _, ip := range a {
+		if !inB[ip.SourceIP] {
+			onlyInA = append(onlyInA, ip.SourceIP)
+		}
+	}
+	for _, ip := range b {
+		if !inA[ip.SourceIP] {
+			onlyInB = append(onlyInB, ip.SourceIP)
+		}
+	}
+	return onlyInA, onlyInB
+}
+
+// assessComparison describes which domain fails more and why
+func assessComparison(a, b DomainComparison, days int) string {
+	for _, c := range []DomainComparison{a, b} {
+		if c.TotalMessages == 0 {
+			return fmt.Sprintf("No DMARC data for %s in the last %d days; comparison is not possible.", c.Domain, days)
+		}
+	}
+
+	failA := 100 - a.ComplianceRate
+	failB := 100 - b.ComplianceRate
+	if math.Abs(failA-failB) < 1 {
+		return fmt.Sprintf("%s and %s have similar failure rates (%.1f%% vs %.1f%%).", a.Domain, b.Domain, failA, failB)
+	}
+
+	worse, better, worseRate, betterRate := a, b, failA, failB
+	if failB > failA {
+		worse, better, worseRate, betterRate = b, a, failB, failA
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s has %.1f percentage points more failures than %s (%.1f%% vs %.1f%%)",
+		worse.Domain, worseRate-betterRate, better.Domain, worseRate, betterRate)
+
+	if worse.SPFFailures >= worse.DKIMFailures {
+		sb.WriteString(", primarily from SPF failures")
+	} else {
+		sb.WriteString(", primarily from DKIM failures")
+	}
+
+	var top *storage.TopSourceIP
+	for i := range worse.TopSourceIPs {
+		if ip := &worse.TopSourceIPs[i]; ip.Fail > 0 && (top == nil || ip.Fail > top.Fail) {
+			top = ip
+		}
+	}
+	if top != nil {
+		fmt.Fprintf(&sb, "; top failing source is %s with %d failed messages", top.SourceIP, top.Fail)
+	}
+	sb.WriteString(".")
+
+	return sb.String()
+}
+
+// Anomaly detection windows and thresholds
+const (
+	anomalyRecentDays      = 7
+	anomalyBaselineDays    = 30
+	anomalyVolumeIncrease  = 200 // percent
+	anomalyFailureIncrease = 20  // percentage points
+	anomalyMaxSourceIPs    = 200
+)
+
+// DetectAnomaliesInput is used for anomaly detection.
+type DetectAnomaliesInput struct {
+	Limit int `json:"limit,omitempty" jsonschema:"maximum number of anomalies to return (default: 20)"`
+}
+
+// SourceIPAnomaly describes a source IP whose recent behavior deviates from its baseline.
+type SourceIPAnomaly struct {
+	SourceIP            string   `json:"source_ip"`
+	Severity            string   `json:"severity"`
+	Score               float64  `json:"score"`
+	Reasons             []string `json:"reasons"`
+	RecentMessages      int      `json:"recent_messages"`
+	BaselineMessages    float64  `json:"baseline_messages"`
+	VolumeIncrease      float64  `json:"volume_increase_pct"`
+	RecentFailureRate   float64  `json:"recent_failure_rate"`
+	BaselineFailureRate float64  `json:"baseline_failure_rate"`
+}
+
+// DetectAnomaliesOutput wraps the anomaly detection response.
+type DetectAnomaliesOutput struct {
+	RecentDays   int               `json:"recent_days"`
+	BaselineDays int               `json:"baseline_days"`
+	Anomalies    []SourceIPAnomaly `json:"anomalies"`
+	Count        int               `json:"count"`
+}
+
+func (s *Server) detectAnomalies(ctx context.Context, req *mcp.CallToolRequest, input DetectAnomaliesInput) (*mcp.CallToolResult, DetectAnomaliesOutput, error) {
+	limit := input.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	ips, err := s.store.GetActiveSourceIPs(anomalyRecentDays, anomalyMaxSourceIPs)
+	if err != nil {
+		return nil, DetectAnomaliesOutput{}, fmt.Errorf("failed to get active source IPs: %w", err)
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -anomalyRecentDays).UTC().Format("2006-01-02")
+	anomalies := []SourceIPAnomaly{}
+	for _, ip := range ips {
+		trend, err := s.store.GetSourceIPTrend(ip, anomalyRecentDays+anomalyBaselineDays)
+		if err != nil {
+			return nil, DetectAnomaliesOutput{}, fmt.Errorf("failed to get source IP trend: %w", err)
+		}
+		if a, ok := evaluateAnomaly(ip, trend, cutoff); ok {
+			anomalies = append(anomalies, a)
+		}
+	}
+
+	sort.Slice(anomalies, func(i, j int) bool {
+		return anomalies[i].Score > anomalies[j].Score
+	})
+	if len(anomalies) > limit {
+		anomalies = anomalies[:limit]
+	}
+
+	return nil, DetectAnomaliesOutput{
+		RecentDays:   anomalyRecentDays,
+		BaselineDays: anomalyBaselineDays,
+		Anomalies:    anomalies,
+		Count:        len(anomalies),
+	}, nil
+}
+
+// evaluateAnomaly compares days on or after cutoff with the baseline before
+// it, scaled to the same window length. The score grows with the size of the
+// deviation and is capped at 100.
+func evaluateAnomaly(ip string, trend []storage.TrendPoint, cutoff string) (SourceIPAnomaly, bool) {
+	var recentTotal, recentFailed, baseTotal, baseFailed int
+	for _, p := range trend {
+		failed := p.TotalMessages - p.CompliantMessages
+		if p.Date >= cutoff {
+			recentTotal += p.TotalMessages
+			recentFailed += failed
+		} else {
+			baseTotal += p.TotalMessages
+			baseFailed += failed
+		}
+	}
+	if recentTotal == 0 {
+		return SourceIPAnomaly{}, false
+	}
+
+	a := SourceIPAnomaly{
+		SourceIP:          ip,
+		Reasons:           []string{},
+		RecentMessages:    recentTotal,
+		BaselineMessages:  float64(baseTotal) / anomalyBaselineDays * anomalyRecentDays,
+		RecentFailureRate: float64(recentFailed) / float64(recentTotal) * 100,
+	}
+
+	if baseTotal == 0 {
+		// A new sender is only interesting if it is failing authentication
+		if a.RecentFailureRate < anomalyFailureIncrease {
+			return SourceIPAnomaly{}, false
+		}
+		a.Reasons = append(a.Reasons, fmt.Sprintf("new source with %.1f%% failure rate", a.RecentFailureRate))
+		a.Score = 25 + math.Min(25, a.RecentFailureRate/4)
+	} else {
+		a.BaselineFailureRate = float64(baseFailed) / float64(baseTotal) * 100
+		a.VolumeIncrease = (float64(recentTotal) - a.BaselineMessages) / a.BaselineMessages * 100
+
+		if a.VolumeIncrease > anomalyVolumeIncrease {
+			a.Reasons = append(a.Reasons, fmt.Sprintf("message volume up %.0f%% over baseline", a.VolumeIncrease))
+			a.Score += math.Min(50, a.VolumeIncrease/10)
+		}
+		if delta := a.RecentFailureRate - a.BaselineFailureRate; delta > anomalyFailureIncrease {
+			a.Reasons = append(a.Reasons, fmt.Sprintf("failure rate up %.1f percentage points", delta))
+			a.Score += math.Min(50, delta)
+		}
+	}
+
+	if len(a.Reasons) == 0 {
+		return SourceIPAnomaly{}, false
+	}
+
+	a.Score = math.Round(math.Min(100, a.Score)*10) / 10
+	switch {
+	case a.Score >= 60:
+		a.Severity = "high"
+	case a.Score >= 30:
+		a.Severity = "medium"
+	default:
+		a.Severity = "low"
+	}
+
+	return a, true
+}
@@ -0,0 +1,49 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/meysam81/parse-dmarc/internal/storage"
+)
+
+func TestEvaluateAnomaly(t *testing.T) {
+	cutoff := "2024-01-24"
+
+	t.Run("volume spike", func(t *testing.T) {
+		trend := []storage.TrendPoint{
+			{Date: "2024-01-01", TotalMessages: 300, CompliantMessages: 300},
+			{Date: "2024-01-25", TotalMessages: 700, CompliantMessages: 700},
+		}
+		a, ok := evaluateAnomaly("192.0.2.1", trend, cutoff)
+		if !ok {
+			t.Fatalf("Expected anomaly for volume spike")
+		}
+		if a.VolumeIncrease <= anomalyVolumeIncrease {
+			t.Errorf("Expected volume increase above threshold, got %f", a.VolumeIncrease)
+		}
+	})
+
+	t.Run("failure rate jump", func(t *testing.T) {
+		trend := []storage.TrendPoint{
+			{Date: "2024-01-01", TotalMessages: 300, CompliantMessages: 300},
+			{Date: "2024-01-25", TotalMessages: 70, CompliantMessages: 35},
+		}
+		a, ok := evaluateAnomaly("192.0.2.1", trend, cutoff)
+		if !ok {
+			t.Fatalf("Expected anomaly for failure rate jump")
+		}
+		if a.Severity != "medium" {
+			t.Errorf("Expected medium severity, got %s (score %f)", a.Severity, a.Score)
+		}
+	})
+
+	t.Run("steady source", func(t *testing.T) {
+		trend := []storage.TrendPoint{
+			{Date: "2024-01-01", TotalMessages: 300, CompliantMessages: 290},
+			{Date: "2024-01-25", TotalMessages: 70, CompliantMessages: 68},
+		}
+		if _, ok := evaluateAnomaly("192.0.2.1", trend, cutoff); ok {
+			t.Errorf("Expected no anomaly for steady source")
+		}
+	})
+}
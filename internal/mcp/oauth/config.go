@@ -37,6 +37,10 @@ type Config struct {
 	// If empty, no scope validation is performed.
 	RequiredScopes []string
 
+	// JWKSURI is the URL of the issuer's JSON Web Key Set.
+	// If set, keys are fetched from it directly instead of via OIDC discovery.
+	JWKSURI string
+
 	// IntrospectionEndpoint is the URL for token introspection (RFC 7662).
 	// If set, tokens will be validated via introspection instead of local JWT validation.
 	IntrospectionEndpoint string
@@ -52,6 +52,21 @@ func (v *OIDCVerifier) init(ctx context.Context) error {
 
 		ctx = oidc.ClientContext(ctx, httpClient)
 
+		verifierConfig := &oidc.Config{
+			ClientID:          v.config.Audience,
+			SkipClientIDCheck: v.config.Audience == "",
+			SkipIssuerCheck:   v.config.SkipIssuerCheck,
+		}
+
+		// Skip discovery when the key set location is known
+		if v.config.JWKSURI != "" {
+			// The key set outlives this call, so it must not inherit its cancellation
+			keySetCtx := oidc.ClientContext(context.Background(), httpClient)
+			keySet := oidc.NewRemoteKeySet(keySetCtx, v.config.JWKSURI)
+			v.verifier = oidc.NewVerifier(v.config.Issuer, keySet, verifierConfig)
+			return
+		}
+
 		provider, err := oidc.NewProvider(ctx, v.config.Issuer)
 		if err != nil {
 			v.initError = fmt.Errorf("failed to create OIDC provider: %w", err)
@@ -59,12 +74,6 @@ func (v *OIDCVerifier) init(ctx context.Context) error {
 		}
 		v.provider = provider
 
-		verifierConfig := &oidc.Config{
-			ClientID:          v.config.Audience,
-			SkipClientIDCheck: v.config.Audience == "",
-			SkipIssuerCheck:   v.config.SkipIssuerCheck,
-		}
-
 		v.verifier = provider.Verifier(verifierConfig)
 	})
 
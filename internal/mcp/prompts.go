@@ -0,0 +1,138 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/meysam81/parse-dmarc/internal/storage"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// policyUpgradeTrendDays is the window of recent data included in the prompt
+const policyUpgradeTrendDays = 30
+
+// registerPrompts registers all reusable prompts with the MCP server.
+func (s *Server) registerPrompts() {
+	// suggest_policy_upgrade - Assess whether a domain can move to a stricter policy
+	s.mcpServer.AddPrompt(&mcp.Prompt{
+		Name:        "suggest_policy_upgrade",
+		Description: "Analyze whether a domain can safely move to a stricter DMARC policy (none → quarantine or quarantine → reject), with risks and a recommended timeline.",
+		Arguments: []*mcp.PromptArgument{
+			{
+				Name:        "domain",
+				Description: "The domain to analyze",
+				Required:    true,
+			},
+		},
+	}, s.suggestPolicyUpgrade)
+}
+
+func (s *Server) suggestPolicyUpgrade(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	domain := strings.TrimSpace(req.Params.Arguments["domain"])
+	if domain == "" {
+		return nil, fmt.Errorf("domain argument is required")
+	}
+
+	policy, err := s.store.GetDomainPolicy(domain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get policy for %s: %w", domain, err)
+	}
+
+	domainStats, err := s.store.GetDomainStats()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain stats: %w", err)
+	}
+	var stats storage.DomainStats
+	for _, ds := range domainStats {
+		if ds.Domain == domain {
+			stats = ds
+			break
+		}
+	}
+
+	trend, err := s.store.GetComplianceTrend(domain, policyUpgradeTrendDays)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get compliance trend for %s: %w", domain, err)
+	}
+
+	sources, err := s.store.GetDomainTopSourceIPs(domain, policyUpgradeTrendDays, 10)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top source IPs for %s: %w", domain, err)
+	}
+
+	return &mcp.GetPromptResult{
+		Description: fmt.Sprintf("DMARC policy upgrade assessment for %s", domain),
+		Messages: []*mcp.PromptMessage{
+			{
+				Role: "user",
+				Content: &mcp.TextContent{
+					Text: buildPolicyUpgradePrompt(policy, stats, trend, sources),
+				},
+			},
+		},
+	}, nil
+}
+
+// nextPolicy returns the next stricter DMARC policy, or "" if already at reject
+func nextPolicy(p string) string {
+	switch strings.ToLower(p) {
+	case "", "none":
+		return "quarantine"
+	case "quarantine":
+		return "reject"
+	default:
+		return ""
+	}
+}
+
+// buildPolicyUpgradePrompt renders the data and instructions for the assistant
+func buildPolicyUpgradePrompt(policy storage.PolicyInfo, stats storage.DomainStats, trend []storage.TrendPoint, sources []storage.TopSourceIP) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "Assess whether the domain %s can safely move to a stricter DMARC policy.\n\n", policy.Domain)
+
+	sb.WriteString("## Current published policy\n")
+	fmt.Fprintf(&sb, "- p=%s, sp=%s, pct=%d\n", policy.P, policy.SP, policy.PCT)
+	fmt.Fprintf(&sb, "- adkim=%s, aspf=%s, fo=%s\n\n", policy.ADKIM, policy.ASPF, policy.FO)
+
+	sb.WriteString("## All-time compliance\n")
+	fmt.Fprintf(&sb, "- Total messages: %d\n", stats.TotalMessages)
+	fmt.Fprintf(&sb, "- Compliant messages: %d\n", stats.CompliantMessages)
+	fmt.Fprintf(&sb, "- Non-compliant messages: %d\n", stats.TotalMessages-stats.CompliantMessages)
+	fmt.Fprintf(&sb, "- Compliance rate: %.2f%%\n\n", stats.ComplianceRate)
+
+	fmt.Fprintf(&sb, "## Daily compliance (last %d days)\n", policyUpgradeTrendDays)
+	if len(trend) == 0 {
+		sb.WriteString("No reports in this period.\n")
+	}
+	for _, p := range trend {
+		fmt.Fprintf(&sb, "- %s: %d messages, %.2f%% compliant, %d SPF failures, %d DKIM failures\n",
+			p.Date, p.TotalMessages, p.ComplianceRate, p.SPFFailures, p.DKIMFailures)
+	}
+	sb.WriteString("\n")
+
+	fmt.Fprintf(&sb, "## Top sending sources (last %d days)\n", policyUpgradeTrendDays)
+	if len(sources) == 0 {
+		sb.WriteString("No sources in this period.\n")
+	}
+	for _, ip := range sources {
+		fmt.Fprintf(&sb, "- %s: %d messages, %d pass, %d fail\n", ip.SourceIP, ip.Count, ip.Pass, ip.Fail)
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString("## Task\n")
+	if next := nextPolicy(policy.P); next != "" {
+		fmt.Fprintf(&sb, "Decide whether upgrading from p=%s to p=%s is safe now.\n", policy.P, next)
+	} else {
+		sb.WriteString("The domain already publishes p=reject. Check whether pct, sp and alignment modes can be tightened.\n")
+	}
+	sb.WriteString(`Respond with:
+1. A recommendation (upgrade now, upgrade gradually, or wait) with reasoning.
+2. The risks, naming the source IPs whose legitimate mail would be affected.
+3. The SPF/DKIM fixes needed before upgrading, if any.
+4. A recommended timeline, including pct ramp-up steps if appropriate.
+`)
+
+	return sb.String()
+}
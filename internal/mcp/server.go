@@ -52,7 +52,12 @@ Available tools:
 - get_org_stats: Get statistics by reporting organization
 - get_spf_stats: Get SPF authentication result statistics
 - get_dkim_stats: Get DKIM authentication result statistics
-- parse_dmarc_report: Parse a raw DMARC XML report`,
+- parse_dmarc_report: Parse a raw DMARC XML report
+- compare_domains: Compare compliance of two domains side by side
+- detect_anomalies: Flag source IPs deviating from their historical baseline
+
+Available prompts:
+- suggest_policy_upgrade: Assess whether a domain can move to a stricter policy`,
 	}
 
 	mcpServer := mcp.NewServer(
@@ -73,8 +78,9 @@ Available tools:
 		mcpServer.AddReceivingMiddleware(s.loggingMiddleware())
 	}
 
-	// Register all tools
+	// Register all tools and prompts
 	s.registerTools()
+	s.registerPrompts()
 
 	return s
 }
@@ -123,6 +129,30 @@ func (s *Server) loggingMiddleware() mcp.Middleware {
 	}
 }
 
+// ListTools returns the result of a tools/list call, as seen by a client.
+// It connects an in-memory client session, so no listener is started.
+func (s *Server) ListTools(ctx context.Context) (*mcp.ListToolsResult, error) {
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	serverSession, err := s.mcpServer.Connect(ctx, serverTransport, nil)
+	if err != nil {
+		return nil, fmt.Errorf("connect server session: %w", err)
+	}
+	defer func() { _ = serverSession.Close() }()
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "parse-dmarc-inspect", Version: "dev"}, nil)
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		return nil, fmt.Errorf("connect client session: %w", err)
+	}
+	defer func() { _ = session.Close() }()
+
+	result, err := session.ListTools(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("list tools: %w", err)
+	}
+	return result, nil
+}
+
 // RunStdio runs the MCP server over stdio transport.
 func (s *Server) RunStdio(ctx context.Context) error {
 	if s.logger != nil {
@@ -256,4 +286,16 @@ func (s *Server) registerTools() {
 		Name:        "parse_dmarc_report",
 		Description: "Parse a raw DMARC aggregate report from XML data. Accepts gzip, zip, or plain XML. The report_data should be base64 encoded. Returns the parsed report structure.",
 	}, s.parseDMARCReport)
+
+	// compare_domains - Compare compliance of two domains
+	mcp.AddTool(s.mcpServer, &mcp.Tool{
+		Name:        "compare_domains",
+		Description: "Compare DMARC compliance of two domains over a date range (default 30 days). Returns side-by-side compliance rates, message volumes, daily trends, top source IPs unique to each domain, and a qualitative assessment of which domain fails more and why.",
+	}, s.compareDomains)
+
+	// detect_anomalies - Flag source IPs deviating from their baseline
+	mcp.AddTool(s.mcpServer, &mcp.Tool{
+		Name:        "detect_anomalies",
+		Description: "Detect source IPs whose last 7 days deviate from their 30-day baseline: message volume up more than 200% or failure rate up more than 20 percentage points. New sources with high failure rates are also flagged. Returns anomalies ordered by severity score (0-100).",
+	}, s.detectAnomalies)
 }
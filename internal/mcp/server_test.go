@@ -0,0 +1,28 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+)
+
+func TestListTools(t *testing.T) {
+	server := NewServer(nil, &Config{})
+
+	result, err := server.ListTools(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to list tools: %v", err)
+	}
+
+	tools := map[string]bool{}
+	for _, tool := range result.Tools {
+		tools[tool.Name] = true
+		if tool.InputSchema == nil {
+			t.Errorf("Expected tool %s to have an input schema", tool.Name)
+		}
+	}
+	for _, name := range []string{"get_statistics", "get_reports", "parse_dmarc_report", "detect_anomalies"} {
+		if !tools[name] {
+			t.Errorf("Expected tool %s to be listed, got %v", name, tools)
+		}
+	}
+}
@@ -47,6 +47,7 @@ type StatisticsOutput struct {
 type ReportsOutput struct {
 	Reports []storage.ReportSummary `json:"reports"`
 	Count   int                     `json:"count"`
+	Total   int                     `json:"total"`
 }
 
 // ReportOutput wraps a single report response.
@@ -113,11 +114,16 @@ func (s *Server) getReports(ctx context.Context, req *mcp.CallToolRequest, input
 		offset = 0
 	}
 
-	reports, err := s.store.GetReports(limit, offset)
+	reports, err := s.store.GetReports(storage.ReportFilter{}, limit, offset)
 	if err != nil {
 		return nil, ReportsOutput{}, fmt.Errorf("failed to get reports: %w", err)
 	}
 
+	total, err := s.store.CountReports(storage.ReportFilter{})
+	if err != nil {
+		return nil, ReportsOutput{}, fmt.Errorf("failed to count reports: %w", err)
+	}
+
 	if reports == nil {
 		reports = []storage.ReportSummary{}
 	}
@@ -125,6 +131,7 @@ func (s *Server) getReports(ctx context.Context, req *mcp.CallToolRequest, input
 	return nil, ReportsOutput{
 		Reports: reports,
 		Count:   len(reports),
+		Total:   total,
 	}, nil
 }
 
@@ -249,7 +256,7 @@ func (s *Server) parseDMARCReport(ctx context.Context, req *mcp.CallToolRequest,
 		return nil, ParsedReportOutput{}, fmt.Errorf("report data exceeds maximum size of %d bytes", maxReportSize)
 	}
 	// Parse the report
-	report, err := parser.ParseReport(data)
+	report, err := parser.ParseReportWithContext(ctx, data)
 	if err != nil {
 		return nil, ParsedReportOutput{}, fmt.Errorf("failed to parse DMARC report: %w", err)
 	}
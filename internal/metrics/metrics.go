@@ -3,6 +3,7 @@ package metrics
 import (
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -22,16 +23,17 @@ type Metrics struct {
 	BuildInfo *prometheus.GaugeVec
 
 	// Report processing metrics
-	ReportsFetched     prometheus.Counter
-	ReportsParsed      prometheus.Counter
-	ReportsStored      prometheus.Counter
-	ReportParseErrors  prometheus.Counter
-	ReportStoreErrors  prometheus.Counter
-	AttachmentsTotal   prometheus.Counter
-	FetchDuration      prometheus.Histogram
-	LastFetchTimestamp prometheus.Gauge
-	FetchCyclesTotal   prometheus.Counter
-	FetchErrors        prometheus.Counter
+	ReportsFetched      prometheus.Counter
+	ReportsParsed       prometheus.Counter
+	ReportsStored       *prometheus.CounterVec
+	ReportParseErrors   prometheus.Counter
+	ReportStoreErrors   prometheus.Counter
+	AttachmentsTotal    prometheus.Counter
+	FetchDuration       prometheus.Histogram
+	LastFetchTimestamp  prometheus.Gauge
+	FetchCyclesTotal    prometheus.Counter
+	FetchErrors         prometheus.Counter
+	ReportsIngestedHTTP prometheus.Counter
 
 	// IMAP connection metrics
 	IMAPConnectionsTotal   *prometheus.CounterVec
@@ -45,10 +47,14 @@ type Metrics struct {
 	UniqueSourceIPs   prometheus.Gauge
 	UniqueDomains     prometheus.Gauge
 
+	// DatabaseSizeBytes is the size of the SQLite database file
+	DatabaseSizeBytes prometheus.Gauge
+
 	// Per-domain metrics
 	MessagesByDomain      *prometheus.GaugeVec
 	ComplianceByDomain    *prometheus.GaugeVec
 	ReportsByOrg          *prometheus.GaugeVec
+	MessagesByOrg         *prometheus.GaugeVec
 	MessagesByDisposition *prometheus.GaugeVec
 
 	// Authentication results
@@ -59,6 +65,11 @@ type Metrics struct {
 	HTTPRequestsTotal    *prometheus.CounterVec
 	HTTPRequestDuration  *prometheus.HistogramVec
 	HTTPRequestsInFlight prometheus.Gauge
+	HTTPRateLimited      prometheus.Counter
+
+	// webhookPath is the report webhook's configurable route, labelled
+	// as is rather than grouped under /other
+	webhookPath string
 }
 
 // New creates and registers all Prometheus metrics
@@ -94,13 +105,17 @@ func New(version, commit, buildDate string) *Metrics {
 				Help:      "Total number of DMARC reports successfully parsed",
 			},
 		),
-		ReportsStored: prometheus.NewCounter(
+		ReportsStored: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Namespace: namespace,
 				Subsystem: "reports",
 				Name:      "stored_total",
 				Help:      "Total number of DMARC reports successfully stored in database",
 			},
+			// "imap" from the IMAP fetcher, "http" from /api/reports/ingest
+			// and the webhook. Replay updates stored reports in place and
+			// is not counted.
+			[]string{"source"},
 		),
 		ReportParseErrors: prometheus.NewCounter(
 			prometheus.CounterOpts{
@@ -126,6 +141,14 @@ func New(version, commit, buildDate string) *Metrics {
 				Help:      "Total number of attachments processed",
 			},
 		),
+		ReportsIngestedHTTP: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "reports",
+				Name:      "ingested_via_http_total",
+				Help:      "Total number of DMARC reports uploaded to the ingest endpoint",
+			},
+		),
 		FetchDuration: prometheus.NewHistogram(
 			prometheus.HistogramOpts{
 				Namespace: namespace,
@@ -230,6 +253,15 @@ func New(version, commit, buildDate string) *Metrics {
 			},
 		),
 
+		DatabaseSizeBytes: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "database",
+				Name:      "size_bytes",
+				Help:      "Size of the SQLite database in bytes",
+			},
+		),
+
 		// Per-domain metrics
 		MessagesByDomain: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
@@ -258,6 +290,15 @@ func New(version, commit, buildDate string) *Metrics {
 			},
 			[]string{"org_name"},
 		),
+		MessagesByOrg: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "dmarc",
+				Name:      "messages_by_org",
+				Help:      "Number of messages per reporting organization",
+			},
+			[]string{"org_name"},
+		),
 		MessagesByDisposition: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Namespace: namespace,
@@ -316,6 +357,14 @@ func New(version, commit, buildDate string) *Metrics {
 				Help:      "Number of HTTP requests currently being processed",
 			},
 		),
+		HTTPRateLimited: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "http",
+				Name:      "rate_limited_total",
+				Help:      "Total number of HTTP requests rejected by the rate limiter",
+			},
+		),
 	}
 
 	// Register all metrics
@@ -338,6 +387,7 @@ func New(version, commit, buildDate string) *Metrics {
 		m.LastFetchTimestamp,
 		m.FetchCyclesTotal,
 		m.FetchErrors,
+		m.ReportsIngestedHTTP,
 
 		// IMAP
 		m.IMAPConnectionsTotal,
@@ -351,10 +401,14 @@ func New(version, commit, buildDate string) *Metrics {
 		m.UniqueSourceIPs,
 		m.UniqueDomains,
 
+		// Database
+		m.DatabaseSizeBytes,
+
 		// Per-domain
 		m.MessagesByDomain,
 		m.ComplianceByDomain,
 		m.ReportsByOrg,
+		m.MessagesByOrg,
 		m.MessagesByDisposition,
 
 		// Authentication
@@ -365,6 +419,7 @@ func New(version, commit, buildDate string) *Metrics {
 		m.HTTPRequestsTotal,
 		m.HTTPRequestDuration,
 		m.HTTPRequestsInFlight,
+		m.HTTPRateLimited,
 	)
 
 	// Set build info
@@ -377,6 +432,8 @@ func New(version, commit, buildDate string) *Metrics {
 func (m *Metrics) Handler() http.Handler {
 	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{
 		EnableOpenMetrics: true,
+		// Compress responses for scrapers that send Accept-Encoding: gzip
+		DisableCompression: false,
 	})
 }
 
@@ -416,6 +473,11 @@ func (m *Metrics) UpdateOrgMetrics(orgName string, reports int) {
 	m.ReportsByOrg.WithLabelValues(orgName).Set(float64(reports))
 }
 
+// UpdateOrgMessageMetrics updates per-organization message volumes
+func (m *Metrics) UpdateOrgMessageMetrics(orgName string, messages int) {
+	m.MessagesByOrg.WithLabelValues(orgName).Set(float64(messages))
+}
+
 // UpdateDispositionMetrics updates disposition counts
 func (m *Metrics) UpdateDispositionMetrics(disposition string, count int) {
 	m.MessagesByDisposition.WithLabelValues(disposition).Set(float64(count))
@@ -431,6 +493,12 @@ func (m *Metrics) UpdateAuthResults(spfResults, dkimResults map[string]int) {
 	}
 }
 
+// SetWebhookPath sets the path the report webhook is served at, so that
+// HTTPMiddleware labels its requests with it. An empty path disables it.
+func (m *Metrics) SetWebhookPath(path string) {
+	m.webhookPath = path
+}
+
 // HTTPMiddleware wraps an HTTP handler with metrics instrumentation
 func (m *Metrics) HTTPMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -449,7 +517,7 @@ func (m *Metrics) HTTPMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(wrapped, r)
 
 		duration := time.Since(start)
-		path := normalizePath(r.URL.Path)
+		path := normalizePath(r.URL.Path, m.webhookPath)
 
 		m.HTTPRequestsTotal.WithLabelValues(r.Method, path, strconv.Itoa(wrapped.statusCode)).Inc()
 		m.HTTPRequestDuration.WithLabelValues(r.Method, path).Observe(duration.Seconds())
@@ -467,22 +535,73 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap exposes the underlying writer to http.ResponseController
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // normalizePath normalizes URL paths to prevent high cardinality
-func normalizePath(path string) string {
+func normalizePath(path, webhookPath string) string {
 	// Normalize common API paths
 	switch {
 	case path == "/":
 		return "/"
+	case webhookPath != "" && path == webhookPath:
+		return webhookPath
 	case path == "/api/statistics":
 		return "/api/statistics"
+	case path == "/api/statistics/auth-detail":
+		return "/api/statistics/auth-detail"
+	case path == "/api/statistics/overrides":
+		return "/api/statistics/overrides"
+	case path == "/api/statistics/heatmap":
+		return "/api/statistics/heatmap"
+	case path == "/api/statistics/extended":
+		return "/api/statistics/extended"
 	case path == "/api/reports":
 		return "/api/reports"
+	case path == "/api/reports/count":
+		return "/api/reports/count"
+	case path == "/api/reports/ids":
+		return "/api/reports/ids"
 	case path == "/api/top-sources":
 		return "/api/top-sources"
+	case path == "/api/records/failing":
+		return "/api/records/failing"
+	case path == "/api/reports/ingest":
+		return "/api/reports/ingest"
+	case path == "/api/domains":
+		return "/api/domains"
+	case path == "/api/reports/trash":
+		return "/api/reports/trash"
+	case strings.HasPrefix(path, "/api/reports/by-report-id/"):
+		return "/api/reports/by-report-id/:report_id"
+	case strings.HasPrefix(path, "/api/reports/") && strings.HasSuffix(path, "/annotations"):
+		return "/api/reports/:id/annotations"
+	case strings.HasPrefix(path, "/api/reports/") && strings.HasSuffix(path, "/explain"):
+		return "/api/reports/:id/explain"
+	case strings.HasPrefix(path, "/api/reports/") && strings.HasSuffix(path, "/restore"):
+		return "/api/reports/:id/restore"
 	case len(path) > 13 && path[:13] == "/api/reports/":
 		return "/api/reports/:id"
+	case strings.HasPrefix(path, "/api/domains/") && strings.HasSuffix(path, "/policy"):
+		return "/api/domains/:domain/policy"
+	case strings.HasPrefix(path, "/api/domains/") && strings.HasSuffix(path, "/reports"):
+		return "/api/domains/:domain/reports"
+	case strings.HasPrefix(path, "/api/sources/") && strings.HasSuffix(path, "/stats"):
+		return "/api/sources/:ip/stats"
+	case path == "/api/alerts":
+		return "/api/alerts"
+	case strings.HasPrefix(path, "/api/alerts/"):
+		return "/api/alerts/:id/acknowledge"
+	case path == "/api/stream/reports":
+		return "/api/stream/reports"
+	case path == "/api/audit":
+		return "/api/audit"
 	case path == "/metrics":
 		return "/metrics"
+	case path == "/healthz":
+		return "/healthz"
 	default:
 		// Group static assets
 		if len(path) >= 7 && path[:7] == "/assets" {
@@ -0,0 +1,222 @@
+package metrics
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestHandler_Gzip(t *testing.T) {
+	m := New("test", "none", "unknown")
+	srv := httptest.NewServer(m.HTTPMiddleware(m.Handler()))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/metrics", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	// Setting the header disables the transport's transparent decompression
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to scrape metrics: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if got := resp.Header.Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Expected Content-Encoding gzip, got %q", got)
+	}
+
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to open gzip body: %v", err)
+	}
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("Failed to read gzip body: %v", err)
+	}
+	if !strings.Contains(string(body), "parse_dmarc_build_info") {
+		t.Errorf("Expected parse_dmarc_build_info in metrics output")
+	}
+}
+
+// populate gives every labelled metric a child, so all families are exposed
+func populate(m *Metrics) {
+	m.ReportsStored.WithLabelValues("imap").Inc()
+	m.RecordFetchDuration(2 * time.Second)
+	m.RecordIMAPConnection(true, time.Second)
+	m.UpdateStatistics(10, 100, 90, 5, 2, 90)
+	m.UpdateDomainMetrics("example.com", 100, 90)
+	m.UpdateOrgMetrics("google.com", 10)
+	m.UpdateOrgMessageMetrics("google.com", 100)
+	m.UpdateDispositionMetrics("none", 100)
+	m.UpdateAuthResults(map[string]int{"pass": 90, "fail": 10}, map[string]int{"pass": 95, "fail": 5})
+	m.HTTPRateLimited.Inc()
+
+	// The middleware doesn't record requests for /metrics itself
+	api := m.HTTPMiddleware(http.NotFoundHandler())
+	api.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/statistics", nil))
+}
+
+func TestHandler_OpenMetrics(t *testing.T) {
+	m := New("test", "none", "unknown")
+	populate(m)
+
+	srv := httptest.NewServer(m.HTTPMiddleware(m.Handler()))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/metrics", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to scrape metrics: %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Failed to read metrics: %v", err)
+	}
+
+	if got := resp.Header.Get("Content-Type"); !strings.HasPrefix(got, "application/openmetrics-text") {
+		t.Fatalf("Expected OpenMetrics content type, got %q", got)
+	}
+
+	if !strings.HasSuffix(string(body), "# EOF\n") {
+		t.Errorf("Expected OpenMetrics output to end with # EOF")
+	}
+	if !strings.Contains(string(body), "# TYPE parse_dmarc_reports_fetched counter\n") {
+		t.Errorf("Expected OpenMetrics counter family without the _total suffix\n%s", body)
+	}
+
+	problems, err := testutil.GatherAndLint(m.registry)
+	if err != nil {
+		t.Fatalf("Failed to lint metrics: %v", err)
+	}
+	// These gauges predate the lint check; renaming them would break
+	// existing dashboards
+	legacyTotalGauges := map[string]bool{
+		"parse_dmarc_dmarc_reports_total":            true,
+		"parse_dmarc_dmarc_messages_total":           true,
+		"parse_dmarc_dmarc_compliant_messages_total": true,
+	}
+	for _, p := range problems {
+		if legacyTotalGauges[p.Metric] && strings.Contains(p.Text, `"_total" suffix`) {
+			continue
+		}
+		t.Errorf("Metric %s: %s", p.Metric, p.Text)
+	}
+}
+
+// expectedMetrics lists every metric family the application exposes. Add new
+// metrics here when adding them to Metrics.
+var expectedMetrics = []string{
+	"parse_dmarc_build_info",
+	"parse_dmarc_reports_fetched_total",
+	"parse_dmarc_reports_parsed_total",
+	"parse_dmarc_reports_stored_total",
+	"parse_dmarc_reports_parse_errors_total",
+	"parse_dmarc_reports_store_errors_total",
+	"parse_dmarc_reports_attachments_total",
+	"parse_dmarc_reports_ingested_via_http_total",
+	"parse_dmarc_reports_fetch_duration_seconds",
+	"parse_dmarc_reports_last_fetch_timestamp_seconds",
+	"parse_dmarc_reports_fetch_cycles_total",
+	"parse_dmarc_reports_fetch_errors_total",
+	"parse_dmarc_imap_connections_total",
+	"parse_dmarc_imap_connection_duration_seconds",
+	"parse_dmarc_dmarc_reports_total",
+	"parse_dmarc_dmarc_messages_total",
+	"parse_dmarc_dmarc_compliant_messages_total",
+	"parse_dmarc_dmarc_compliance_rate",
+	"parse_dmarc_dmarc_unique_source_ips",
+	"parse_dmarc_dmarc_unique_domains",
+	"parse_dmarc_database_size_bytes",
+	"parse_dmarc_dmarc_messages_by_domain",
+	"parse_dmarc_dmarc_compliance_rate_by_domain",
+	"parse_dmarc_dmarc_reports_by_org",
+	"parse_dmarc_dmarc_messages_by_org",
+	"parse_dmarc_dmarc_messages_by_disposition",
+	"parse_dmarc_dmarc_spf_results",
+	"parse_dmarc_dmarc_dkim_results",
+	"parse_dmarc_http_requests_total",
+	"parse_dmarc_http_request_duration_seconds",
+	"parse_dmarc_http_requests_in_flight",
+	"parse_dmarc_http_rate_limited_total",
+}
+
+func TestMetricsCompleteness(t *testing.T) {
+	m := New("test", "none", "unknown")
+	populate(m)
+
+	srv := httptest.NewServer(m.HTTPMiddleware(m.Handler()))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("Failed to scrape metrics: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	for _, name := range expectedMetrics {
+		count, err := testutil.GatherAndCount(m.registry, name)
+		if err != nil {
+			t.Fatalf("Failed to gather %s: %v", name, err)
+		}
+		if count == 0 {
+			t.Errorf("Expected metric %s to be exposed", name)
+		}
+	}
+
+	// Every collector field must already be registered, so registering it
+	// again has to fail
+	v := reflect.ValueOf(m).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		c, ok := v.Field(i).Interface().(prometheus.Collector)
+		if !ok {
+			continue
+		}
+		err := m.registry.Register(c)
+		if err == nil {
+			t.Errorf("Expected %s to be registered", field.Name)
+			continue
+		}
+		var are prometheus.AlreadyRegisteredError
+		if !errors.As(err, &are) {
+			t.Errorf("Expected %s to be registered, got %v", field.Name, err)
+		}
+	}
+}
+
+func TestHTTPMiddleware_WebhookPath(t *testing.T) {
+	m := New("test", "none", "unknown")
+	m.SetWebhookPath("/hooks/dmarc")
+	handler := m.HTTPMiddleware(http.NotFoundHandler())
+
+	for _, path := range []string{"/hooks/dmarc", "/ingest"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, path, nil))
+	}
+
+	if got := testutil.ToFloat64(m.HTTPRequestsTotal.WithLabelValues(http.MethodPost, "/hooks/dmarc", "404")); got != 1 {
+		t.Errorf("Expected 1 request labelled with the webhook path, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.HTTPRequestsTotal.WithLabelValues(http.MethodPost, "/other", "404")); got != 1 {
+		t.Errorf("Expected /ingest to be labelled /other when it is not the webhook path, got %v", got)
+	}
+}
@@ -0,0 +1,181 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/meysam81/parse-dmarc/internal/publisher"
+)
+
+// sendTimeout bounds a single delivery to a channel
+const sendTimeout = 30 * time.Second
+
+// Sender delivers a report event to a single destination
+type Sender interface {
+	Send(ctx context.Context, event *publisher.Event) error
+}
+
+// SenderFunc adapts a function to the Sender interface
+type SenderFunc func(ctx context.Context, event *publisher.Event) error
+
+// Send calls f(ctx, event)
+func (f SenderFunc) Send(ctx context.Context, event *publisher.Event) error {
+	return f(ctx, event)
+}
+
+// NotificationChannel is a Sender that names itself, so it can be
+// registered without the caller choosing a name. Packages outside parse-dmarc
+// can implement it to plug in their own destinations.
+type NotificationChannel interface {
+	Sender
+	Name() string
+}
+
+// The built-in channels all implement NotificationChannel
+var (
+	_ NotificationChannel = (*SMTP)(nil)
+	_ NotificationChannel = (*publisher.SlackPublisher)(nil)
+	_ NotificationChannel = (*publisher.DiscordPublisher)(nil)
+	_ NotificationChannel = (*publisher.PagerDutyPublisher)(nil)
+)
+
+// Filter selects which events a channel receives. Zero values disable the
+// corresponding check.
+type Filter struct {
+	// MinComplianceBelow and MaxComplianceBelow bound the compliance rate
+	// band the channel covers: an event matches when its rate is at least
+	// MinComplianceBelow and below MaxComplianceBelow.
+	MinComplianceBelow float64
+	MaxComplianceBelow float64
+	// Domains limits the channel to these domains
+	Domains []string
+}
+
+// Matches reports whether the event passes the filter
+func (f Filter) Matches(event *publisher.Event) bool {
+	if f.MaxComplianceBelow > 0 && event.ComplianceRate >= f.MaxComplianceBelow {
+		return false
+	}
+	if event.ComplianceRate < f.MinComplianceBelow {
+		return false
+	}
+	if len(f.Domains) == 0 {
+		return true
+	}
+	for _, d := range f.Domains {
+		if strings.EqualFold(d, event.Domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// channel is a registered destination and its filter
+type channel struct {
+	name   string
+	sender Sender
+	filter Filter
+}
+
+// NotificationDispatcher fans report events out to every matching channel.
+// Deliveries run in the background on at most workers goroutines.
+type NotificationDispatcher struct {
+	channels []channel
+	sem      chan struct{}
+	wg       sync.WaitGroup
+	log      *zerolog.Logger
+}
+
+// NewDispatcher creates a dispatcher running at most workers deliveries at once
+func NewDispatcher(workers int, log *zerolog.Logger) *NotificationDispatcher {
+	if workers <= 0 {
+		workers = 1
+	}
+	return &NotificationDispatcher{
+		sem: make(chan struct{}, workers),
+		log: log,
+	}
+}
+
+// Add registers a channel that receives events matching filter
+func (d *NotificationDispatcher) Add(name string, sender Sender, filter Filter) {
+	d.channels = append(d.channels, channel{name: name, sender: sender, filter: filter})
+}
+
+// Register adds ch under its own name. It receives every event; use Add to
+// attach a filter.
+func (d *NotificationDispatcher) Register(ch NotificationChannel) {
+	d.Add(ch.Name(), ch, Filter{})
+}
+
+// Len returns the number of registered channels
+func (d *NotificationDispatcher) Len() int {
+	return len(d.channels)
+}
+
+// Publish queues the event for every matching channel. It blocks only while
+// all workers are busy; delivery errors are logged, not returned.
+func (d *NotificationDispatcher) Publish(ctx context.Context, event *publisher.Event) error {
+	for _, ch := range d.channels {
+		if !ch.filter.Matches(event) {
+			continue
+		}
+
+		select {
+		case d.sem <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		d.wg.Add(1)
+		go func(ch channel) {
+			defer d.wg.Done()
+			defer func() { <-d.sem }()
+
+			sendCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sendTimeout)
+			defer cancel()
+
+			if err := ch.sender.Send(sendCtx, event); err != nil {
+				d.log.Error().Err(err).
+					Str("channel", ch.name).
+					Str("report_id", event.ReportID).
+					Msg("failed to send notification")
+			}
+		}(ch)
+	}
+	return nil
+}
+
+// SendTo delivers the event synchronously to the channels called name,
+// bypassing their filters. A bare type such as "webhook" also selects the
+// numbered channels of that type, e.g. "webhook[0]". It returns how many
+// channels were selected and every delivery error.
+func (d *NotificationDispatcher) SendTo(ctx context.Context, name string, event *publisher.Event) (int, error) {
+	var sent int
+	var errs []error
+	for _, ch := range d.channels {
+		if ch.name != name && !strings.HasPrefix(ch.name, name+"[") {
+			continue
+		}
+		sent++
+
+		sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+		if err := ch.sender.Send(sendCtx, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ch.name, err))
+		}
+		cancel()
+	}
+	return sent, errors.Join(errs...)
+}
+
+// Close waits for queued deliveries to finish
+func (d *NotificationDispatcher) Close() error {
+	d.wg.Wait()
+	return nil
+}
@@ -0,0 +1,140 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/meysam81/parse-dmarc/internal/publisher"
+)
+
+func TestFilterMatches(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		event  publisher.Event
+		want   bool
+	}{
+		{"empty filter", Filter{}, publisher.Event{Domain: "example.com", ComplianceRate: 100}, true},
+		{"below max", Filter{MaxComplianceBelow: 95}, publisher.Event{ComplianceRate: 90}, true},
+		{"at max", Filter{MaxComplianceBelow: 95}, publisher.Event{ComplianceRate: 95}, false},
+		{"below min", Filter{MinComplianceBelow: 80, MaxComplianceBelow: 95}, publisher.Event{ComplianceRate: 70}, false},
+		{"in band", Filter{MinComplianceBelow: 80, MaxComplianceBelow: 95}, publisher.Event{ComplianceRate: 80}, true},
+		{"domain match", Filter{Domains: []string{"Example.com"}}, publisher.Event{Domain: "example.com"}, true},
+		{"domain mismatch", Filter{Domains: []string{"example.org"}}, publisher.Event{Domain: "example.com"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(&tt.event); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDispatcherPublish(t *testing.T) {
+	log := zerolog.Nop()
+	d := NewDispatcher(2, &log)
+
+	var matched, skipped atomic.Int32
+	for i := 0; i < 3; i++ {
+		d.Add("matched", SenderFunc(func(context.Context, *publisher.Event) error {
+			matched.Add(1)
+			return nil
+		}), Filter{MaxComplianceBelow: 95})
+	}
+	d.Add("skipped", SenderFunc(func(context.Context, *publisher.Event) error {
+		skipped.Add(1)
+		return nil
+	}), Filter{Domains: []string{"example.org"}})
+
+	if err := d.Publish(context.Background(), &publisher.Event{Domain: "example.com", ComplianceRate: 50}); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	_ = d.Close()
+
+	if matched.Load() != 3 {
+		t.Errorf("Expected 3 deliveries, got %d", matched.Load())
+	}
+	if skipped.Load() != 0 {
+		t.Errorf("Expected filtered channel to be skipped, got %d deliveries", skipped.Load())
+	}
+}
+
+// countingChannel is a NotificationChannel that counts deliveries
+type countingChannel struct {
+	sent atomic.Int32
+}
+
+func (c *countingChannel) Name() string { return "counting" }
+
+func (c *countingChannel) Send(context.Context, *publisher.Event) error {
+	c.sent.Add(1)
+	return nil
+}
+
+func TestDispatcherRegister(t *testing.T) {
+	log := zerolog.Nop()
+	d := NewDispatcher(2, &log)
+
+	ch := &countingChannel{}
+	d.Register(ch)
+	if d.Len() != 1 {
+		t.Fatalf("Expected 1 channel, got %d", d.Len())
+	}
+
+	for _, rate := range []float64{50, 100} {
+		if err := d.Publish(context.Background(), &publisher.Event{Domain: "example.com", ComplianceRate: rate}); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+	_ = d.Close()
+
+	if ch.sent.Load() != 2 {
+		t.Errorf("Expected 2 deliveries, got %d", ch.sent.Load())
+	}
+}
+
+func TestDispatcherSendTo(t *testing.T) {
+	log := zerolog.Nop()
+	d := NewDispatcher(2, &log)
+
+	var slack, webhooks atomic.Int32
+	d.Add("slack", SenderFunc(func(context.Context, *publisher.Event) error {
+		slack.Add(1)
+		return nil
+	}), Filter{Domains: []string{"example.org"}})
+	d.Add("webhook[0]", SenderFunc(func(context.Context, *publisher.Event) error {
+		webhooks.Add(1)
+		return nil
+	}), Filter{})
+	d.Add("webhook[1]", SenderFunc(func(context.Context, *publisher.Event) error {
+		webhooks.Add(1)
+		return errors.New("boom")
+	}), Filter{})
+
+	event := publisher.NewTestEvent()
+	if n, err := d.SendTo(context.Background(), "slack", event); n != 1 || err != nil {
+		t.Errorf("Expected 1 channel and no error, got %d, %v", n, err)
+	}
+	if slack.Load() != 1 {
+		t.Errorf("Expected filters to be bypassed, got %d deliveries", slack.Load())
+	}
+
+	n, err := d.SendTo(context.Background(), "webhook", event)
+	if n != 2 {
+		t.Errorf("Expected 2 channels, got %d", n)
+	}
+	if err == nil || !strings.Contains(err.Error(), "webhook[1]: boom") {
+		t.Errorf("Expected webhook[1] error, got %v", err)
+	}
+
+	if n, _ := d.SendTo(context.Background(), "discord", event); n != 0 {
+		t.Errorf("Expected no channels, got %d", n)
+	}
+}
@@ -0,0 +1,101 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/meysam81/parse-dmarc/internal/publisher"
+)
+
+// SMTPOptions configures an SMTP notification channel
+type SMTPOptions struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// SMTP emails report events
+type SMTP struct {
+	opts SMTPOptions
+}
+
+// NewSMTP creates a new SMTP notifier
+func NewSMTP(opts SMTPOptions) *SMTP {
+	if opts.Port == 0 {
+		opts.Port = 587
+	}
+	return &SMTP{opts: opts}
+}
+
+// Name identifies the channel in logs
+func (s *SMTP) Name() string {
+	return "smtp"
+}
+
+// Send emails a plain-text summary of the event. STARTTLS is used when the
+// server supports it.
+func (s *SMTP) Send(ctx context.Context, event *publisher.Event) error {
+	subject := event.Title(fmt.Sprintf("DMARC report for %s: %.1f%% compliant", event.Domain, event.ComplianceRate))
+	return s.send(ctx, s.message(subject, "text/plain", eventBody(event)))
+}
+
+// SendHTML emails an HTML document with the given subject
+func (s *SMTP) SendHTML(ctx context.Context, subject, html string) error {
+	return s.send(ctx, s.message(subject, "text/html", html))
+}
+
+// send delivers msg to the configured recipients
+func (s *SMTP) send(ctx context.Context, msg []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.opts.Username != "" {
+		auth = smtp.PlainAuth("", s.opts.Username, s.opts.Password, s.opts.Host)
+	}
+
+	addr := net.JoinHostPort(s.opts.Host, strconv.Itoa(s.opts.Port))
+	if err := smtp.SendMail(addr, auth, s.opts.From, s.opts.To, msg); err != nil {
+		return fmt.Errorf("send email: %w", err)
+	}
+	return nil
+}
+
+// message renders the email headers followed by body
+func (s *SMTP) message(subject, contentType, body string) []byte {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "From: %s\r\n", s.opts.From)
+	fmt.Fprintf(&sb, "To: %s\r\n", strings.Join(s.opts.To, ", "))
+	fmt.Fprintf(&sb, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&sb, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&sb, "Content-Type: %s; charset=UTF-8\r\n\r\n", contentType)
+	sb.WriteString(body)
+	return []byte(sb.String())
+}
+
+// eventBody renders the plain-text summary of event
+func eventBody(event *publisher.Event) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Domain:          %s\r\n", event.Domain)
+	fmt.Fprintf(&sb, "Reporter:        %s\r\n", event.Org)
+	fmt.Fprintf(&sb, "Report ID:       %s\r\n", event.ReportID)
+	fmt.Fprintf(&sb, "Period:          %s - %s\r\n", formatUnix(event.DateBegin), formatUnix(event.DateEnd))
+	fmt.Fprintf(&sb, "Messages:        %d\r\n", event.TotalMessages)
+	fmt.Fprintf(&sb, "Compliance rate: %.2f%%\r\n", event.ComplianceRate)
+	return sb.String()
+}
+
+// formatUnix formats a Unix timestamp as a UTC date and time
+func formatUnix(ts int64) string {
+	return time.Unix(ts, 0).UTC().Format("2006-01-02 15:04 MST")
+}
@@ -4,9 +4,13 @@ import (
 	"archive/zip"
 	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"net/netip"
+	"strings"
 	"time"
 )
 
@@ -17,6 +21,9 @@ type Feedback struct {
 	ReportMetadata  ReportMetadata  `xml:"report_metadata"`
 	PolicyPublished PolicyPublished `xml:"policy_published"`
 	Records         []Record        `xml:"record"`
+	// TruncatedAt is the number of records kept when parsing stopped at
+	// ParseOptions.MaxRecords, and 0 for a complete report
+	TruncatedAt int `xml:"-" json:"TruncatedAt,omitempty"`
 }
 
 // ReportMetadata contains information about the report
@@ -42,7 +49,7 @@ type PolicyPublished struct {
 	ASPF   string `xml:"aspf,omitempty"`  // SPF alignment mode (r=relaxed, s=strict)
 	P      string `xml:"p"`               // Policy (none, quarantine, reject)
 	SP     string `xml:"sp,omitempty"`    // Subdomain policy
-	PCT    int    `xml:"pct,omitempty"`   // Percentage of messages to filter
+	PCT    int    `xml:"pct"`             // Percentage of messages to filter
 	FO     string `xml:"fo,omitempty"`    // Failure reporting options
 }
 
@@ -104,34 +111,233 @@ type SPFResult struct {
 
 // ParseReport parses a DMARC aggregate report from raw data
 func ParseReport(data []byte) (*Feedback, error) {
+	return ParseReportWithContext(context.Background(), data)
+}
+
+// DefaultMaxRecords is the ParseOptions.MaxRecords used when none is set
+const DefaultMaxRecords = 100_000
+
+// ParseOptions bounds the work done parsing a single report
+type ParseOptions struct {
+	// MaxRecords is the most <record> elements decoded before parsing stops
+	// with ErrTruncated. Zero means DefaultMaxRecords, negative no limit.
+	MaxRecords int
+}
+
+// ErrTruncated is returned with the partial report when it holds more than
+// ParseOptions.MaxRecords records. Feedback.TruncatedAt is set, and the
+// caller decides whether to keep the partial data.
+var ErrTruncated = errors.New("report truncated: too many records")
+
+// ParseReportWithContext parses a DMARC aggregate report from raw data,
+// aborting with the context error if ctx is cancelled between the
+// decompression and XML parsing stages or while records are decoded.
+func ParseReportWithContext(ctx context.Context, data []byte) (*Feedback, error) {
+	return ParseReportWithOptions(ctx, data, ParseOptions{})
+}
+
+// ParseReportWithOptions is ParseReportWithContext with explicit limits.
+// When the record limit is hit, it returns the records decoded so far
+// together with an error wrapping ErrTruncated.
+func ParseReportWithOptions(ctx context.Context, data []byte, opts ParseOptions) (*Feedback, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// Try to decompress if needed
-	decompressed, err := tryDecompress(data)
+	decompressed, format, err := tryDecompress(data)
 	if err != nil {
 		return nil, fmt.Errorf("decompression failed: %w", err)
 	}
 
-	var feedback Feedback
-	if err := xml.Unmarshal(decompressed, &feedback); err != nil {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	maxRecords := opts.MaxRecords
+	if maxRecords == 0 {
+		maxRecords = DefaultMaxRecords
+	}
+
+	feedback, err := decodeFeedback(ctx, decompressed, maxRecords)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		// A bare .gz file may hold anything, so a gzip stream without a
+		// <feedback> root is not treated as a broken report. A malformed
+		// report inside one still is.
+		var rootErr *rootError
+		if format == FormatGzip && errors.As(err, &rootErr) {
+			return nil, fmt.Errorf("%w: %v", ErrNotDMARCReport, err)
+		}
 		return nil, fmt.Errorf("XML parsing failed: %w", err)
 	}
 
-	return &feedback, nil
+	feedback.Normalize()
+	if feedback.TruncatedAt > 0 {
+		return feedback, fmt.Errorf("%w: stopped after %d", ErrTruncated, feedback.TruncatedAt)
+	}
+	return feedback, nil
 }
 
-// tryDecompress attempts to decompress data (gzip or zip)
-func tryDecompress(data []byte) ([]byte, error) {
-	// Try gzip first
-	if gzipData, err := decompressGzip(data); err == nil {
-		return gzipData, nil
+// rootError is returned by decodeFeedback when the data does not start with
+// a <feedback> element
+type rootError struct{ err error }
+
+func (e *rootError) Error() string { return e.err.Error() }
+func (e *rootError) Unwrap() error { return e.err }
+
+// ctxCheckRecords is how many records are decoded between checks for a
+// cancelled context
+const ctxCheckRecords = 1000
+
+// decodeFeedback decodes a <feedback> document like xml.Unmarshal, but
+// element by element, so it can stop after maxRecords records without
+// holding the rest in memory. A negative maxRecords disables the limit.
+// It returns ctx.Err() if ctx is cancelled while records are decoded.
+func decodeFeedback(ctx context.Context, data []byte, maxRecords int) (*Feedback, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+
+	var start xml.StartElement
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, &rootError{err}
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			start = se
+			break
+		}
+	}
+	if start.Name.Local != "feedback" {
+		return nil, &rootError{fmt.Errorf("expected element type <feedback> but have <%s>", start.Name.Local)}
 	}
 
-	// Try zip
-	if zipData, err := decompressZip(data); err == nil {
-		return zipData, nil
+	// pct defaults to 100 (RFC 7489) when the report omits it. Decoding only
+	// sets the elements that are present, so an explicit 0 is kept.
+	feedback := &Feedback{XMLName: start.Name, PolicyPublished: PolicyPublished{PCT: 100}}
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.EndElement:
+			return feedback, nil
+		case xml.StartElement:
+			var err error
+			switch t.Name.Local {
+			case "version":
+				err = d.DecodeElement(&feedback.Version, &t)
+			case "report_metadata":
+				err = d.DecodeElement(&feedback.ReportMetadata, &t)
+			case "policy_published":
+				err = d.DecodeElement(&feedback.PolicyPublished, &t)
+			case "record":
+				if maxRecords >= 0 && len(feedback.Records) >= maxRecords {
+					feedback.TruncatedAt = len(feedback.Records)
+					return feedback, nil
+				}
+				if len(feedback.Records)%ctxCheckRecords == 0 {
+					if err := ctx.Err(); err != nil {
+						return nil, err
+					}
+				}
+				var record Record
+				if err = d.DecodeElement(&record, &t); err == nil {
+					feedback.Records = append(feedback.Records, record)
+				}
+			default:
+				err = d.Skip()
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
+}
 
-	// Return original data if not compressed
-	return data, nil
+// ErrNotDMARCReport is returned for gzip data that decompresses to something
+// other than a DMARC aggregate report
+var ErrNotDMARCReport = errors.New("not a DMARC report")
+
+// Report container formats returned by DetectFormat
+const (
+	FormatXML  = "xml"
+	FormatGzip = "gzip"
+	FormatZip  = "zip"
+	// FormatGzipZip is a ZIP archive inside a gzip stream, as sent by some
+	// misconfigured reporters
+	FormatGzipZip = "gzip+zip"
+)
+
+// DetectFormat returns the container format of a report attachment
+func DetectFormat(data []byte) string {
+	switch {
+	case isGzip(data):
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return FormatGzip
+		}
+		defer func() { _ = reader.Close() }()
+		head := make([]byte, 4)
+		if n, _ := io.ReadFull(reader, head); isZip(head[:n]) {
+			return FormatGzipZip
+		}
+		return FormatGzip
+	case isZip(data):
+		return FormatZip
+	}
+	return FormatXML
+}
+
+// FormatForFilename returns the container format implied by the extension
+// of filename, or "" if the extension does not imply one
+func FormatForFilename(filename string) string {
+	lower := strings.ToLower(filename)
+	switch {
+	case strings.HasSuffix(lower, ".xml"):
+		return FormatXML
+	case strings.HasSuffix(lower, ".gz"):
+		return FormatGzip
+	case strings.HasSuffix(lower, ".zip"):
+		return FormatZip
+	}
+	return ""
+}
+
+func isGzip(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
+
+func isZip(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("PK\x03\x04"))
+}
+
+// tryDecompress decompresses gzip and zip data, including a ZIP archive
+// inside a gzip stream, and returns the detected format. Other data is
+// returned unchanged.
+func tryDecompress(data []byte) ([]byte, string, error) {
+	switch {
+	case isGzip(data):
+		out, err := decompressGzip(data)
+		if err != nil {
+			return nil, "", err
+		}
+		if isZip(out) {
+			out, err = decompressZip(out)
+			return out, FormatGzipZip, err
+		}
+		return out, FormatGzip, nil
+	case isZip(data):
+		out, err := decompressZip(data)
+		return out, FormatZip, err
+	}
+	return data, FormatXML, nil
 }
 
 // decompressGzip decompresses gzip data
@@ -142,7 +348,7 @@ func decompressGzip(data []byte) ([]byte, error) {
 	}
 	defer func() { _ = reader.Close() }()
 
-	return io.ReadAll(reader)
+	return readLimited(reader)
 }
 
 // decompressZip decompresses zip data (returns first file)
@@ -164,7 +370,28 @@ func decompressZip(data []byte) ([]byte, error) {
 	}
 	defer func() { _ = rc.Close() }()
 
-	return io.ReadAll(rc)
+	return readLimited(rc)
+}
+
+// maxDecompressedSize bounds the size of a decompressed report, so a small
+// compression bomb cannot exhaust memory. Real reports are a few MB at most.
+const maxDecompressedSize = 100 << 20
+
+// ErrReportTooLarge is returned when a report decompresses to more than
+// maxDecompressedSize bytes
+var ErrReportTooLarge = errors.New("decompressed report is too large")
+
+// readLimited reads r to EOF, failing once more than maxDecompressedSize
+// bytes have been read
+func readLimited(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxDecompressedSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxDecompressedSize {
+		return nil, ErrReportTooLarge
+	}
+	return data, nil
 }
 
 // GetDateRange returns the date range as time.Time objects
@@ -194,6 +421,82 @@ func (f *Feedback) GetDMARCCompliantCount() int {
 	return count
 }
 
+// Normalize canonicalizes values that reporters write inconsistently: policy,
+// disposition and result fields are lowercased, string fields are trimmed and
+// IPv4-mapped IPv6 source addresses are rewritten as IPv4. It returns f.
+func (f *Feedback) Normalize() *Feedback {
+	if f == nil {
+		return nil
+	}
+
+	trim := strings.TrimSpace
+	lower := func(s string) string { return strings.ToLower(strings.TrimSpace(s)) }
+
+	f.Version = trim(f.Version)
+
+	m := &f.ReportMetadata
+	m.OrgName = trim(m.OrgName)
+	m.Email = trim(m.Email)
+	m.ExtraContactInfo = trim(m.ExtraContactInfo)
+	m.ReportID = trim(m.ReportID)
+	for i := range m.Errors {
+		m.Errors[i] = trim(m.Errors[i])
+	}
+
+	p := &f.PolicyPublished
+	p.Domain = trim(p.Domain)
+	p.ADKIM = lower(p.ADKIM)
+	p.ASPF = lower(p.ASPF)
+	p.P = lower(p.P)
+	p.SP = lower(p.SP)
+	p.FO = lower(p.FO)
+
+	for i := range f.Records {
+		r := &f.Records[i]
+		r.Row.SourceIP = normalizeIP(r.Row.SourceIP)
+
+		pe := &r.Row.PolicyEvaluated
+		pe.Disposition = lower(pe.Disposition)
+		pe.DKIM = lower(pe.DKIM)
+		pe.SPF = lower(pe.SPF)
+		for j := range pe.Reason {
+			pe.Reason[j].Type = lower(pe.Reason[j].Type)
+			pe.Reason[j].Comment = trim(pe.Reason[j].Comment)
+		}
+
+		id := &r.Identifiers
+		id.EnvelopeTo = trim(id.EnvelopeTo)
+		id.EnvelopeFrom = trim(id.EnvelopeFrom)
+		id.HeaderFrom = trim(id.HeaderFrom)
+
+		for j := range r.AuthResults.DKIM {
+			d := &r.AuthResults.DKIM[j]
+			d.Domain = trim(d.Domain)
+			d.Selector = trim(d.Selector)
+			d.Result = lower(d.Result)
+			d.HumanResult = trim(d.HumanResult)
+		}
+		for j := range r.AuthResults.SPF {
+			s := &r.AuthResults.SPF[j]
+			s.Domain = trim(s.Domain)
+			s.Scope = lower(s.Scope)
+			s.Result = lower(s.Result)
+		}
+	}
+	return f
+}
+
+// normalizeIP returns ip in canonical form, with IPv4-mapped IPv6 addresses
+// unmapped. Values that are not IP addresses are only trimmed.
+func normalizeIP(ip string) string {
+	ip = strings.TrimSpace(ip)
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ip
+	}
+	return addr.Unmap().String()
+}
+
 // NormalizeForJSON ensures all slice fields are initialized (not nil) to produce
 // valid JSON that matches the MCP output schema. The MCP SDK infers JSON schemas
 // from Go types, and nil slices serialize as null which violates the array type
@@ -1,6 +1,13 @@
 package parser
 
 import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"os"
+	"strings"
 	"testing"
 )
 
@@ -109,14 +116,280 @@ func TestParseReport(t *testing.T) {
 	}
 }
 
-func TestParseGzipReport(t *testing.T) {
-	// This test would require creating a gzip-compressed XML
-	// For now, we just test the decompression logic exists
-	t.Skip("TODO: Test gzip decompression")
+const minimalReportXML = `<?xml version="1.0" encoding="UTF-8"?>
+<feedback>
+  <report_metadata>
+    <org_name>google.com</org_name>
+    <report_id>compressed</report_id>
+  </report_metadata>
+  <policy_published>
+    <domain>example.com</domain>
+    <p>none</p>
+  </policy_published>
+</feedback>`
+
+func gzipData(t testing.TB, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatalf("Failed to write gzip data: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to close gzip writer: %v", err)
+	}
+	return buf.Bytes()
 }
 
-func TestParseZipReport(t *testing.T) {
-	// This test would require creating a zip-compressed XML
-	// For now, we just test the decompression logic exists
-	t.Skip("TODO: Test zip decompression")
+func zipData(t testing.TB, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("report.xml")
+	if err != nil {
+		t.Fatalf("Failed to create zip entry: %v", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("Failed to write zip data: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to close zip writer: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseCompressedReport(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		format string
+	}{
+		{"xml", []byte(minimalReportXML), FormatXML},
+		{"gzip", gzipData(t, []byte(minimalReportXML)), FormatGzip},
+		{"zip", zipData(t, []byte(minimalReportXML)), FormatZip},
+		{"zip inside gzip", gzipData(t, zipData(t, []byte(minimalReportXML))), FormatGzipZip},
+	}
+
+	for _, tt := range tests {
+		if got := DetectFormat(tt.data); got != tt.format {
+			t.Errorf("%s: expected format %q, got %q", tt.name, tt.format, got)
+		}
+
+		feedback, err := ParseReport(tt.data)
+		if err != nil {
+			t.Errorf("%s: failed to parse report: %v", tt.name, err)
+			continue
+		}
+		if feedback.ReportMetadata.ReportID != "compressed" {
+			t.Errorf("%s: expected report ID 'compressed', got '%s'", tt.name, feedback.ReportMetadata.ReportID)
+		}
+	}
+}
+
+func TestParseReport_GzipNotDMARC(t *testing.T) {
+	_, err := ParseReport(gzipData(t, []byte("just some log lines")))
+	if !errors.Is(err, ErrNotDMARCReport) {
+		t.Errorf("Expected ErrNotDMARCReport, got %v", err)
+	}
+
+	_, err = ParseReport([]byte("<html></html>"))
+	if err == nil || errors.Is(err, ErrNotDMARCReport) {
+		t.Errorf("Expected XML parsing error for uncompressed data, got %v", err)
+	}
+}
+
+func TestParseReport_GzipMalformedReport(t *testing.T) {
+	truncated := minimalReportXML[:len(minimalReportXML)/2]
+	_, err := ParseReport(gzipData(t, []byte(truncated)))
+	if err == nil {
+		t.Fatal("Expected an error for a truncated report")
+	}
+	if errors.Is(err, ErrNotDMARCReport) {
+		t.Errorf("Expected a malformed report.xml.gz to be reported as broken, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "XML parsing failed") {
+		t.Errorf("Expected XML parsing error, got %v", err)
+	}
+
+	_, err = ParseReport(gzipData(t, []byte("<html></html>")))
+	if !errors.Is(err, ErrNotDMARCReport) {
+		t.Errorf("Expected ErrNotDMARCReport for a non-feedback root, got %v", err)
+	}
+}
+
+func TestFormatForFilename(t *testing.T) {
+	tests := map[string]string{
+		"report.xml":    FormatXML,
+		"report.xml.gz": FormatGzip,
+		"report.GZ":     FormatGzip,
+		"report.zip":    FormatZip,
+		"report":        "",
+	}
+	for filename, want := range tests {
+		if got := FormatForFilename(filename); got != want {
+			t.Errorf("FormatForFilename(%q): expected %q, got %q", filename, want, got)
+		}
+	}
+}
+
+func TestParseReportWithContext_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := ParseReportWithContext(ctx, []byte(`<feedback></feedback>`))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestParseReport_Normalize(t *testing.T) {
+	data, err := os.ReadFile("testdata/inconsistent_casing.xml")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	feedback, err := ParseReport(data)
+	if err != nil {
+		t.Fatalf("Failed to parse report: %v", err)
+	}
+
+	if feedback.ReportMetadata.OrgName != "Example Reporter Inc." {
+		t.Errorf("Expected trimmed org name, got %q", feedback.ReportMetadata.OrgName)
+	}
+	if feedback.ReportMetadata.ReportID != "casing-1" {
+		t.Errorf("Expected report ID 'casing-1', got %q", feedback.ReportMetadata.ReportID)
+	}
+
+	policy := feedback.PolicyPublished
+	if policy.P != "quarantine" || policy.SP != "none" || policy.ADKIM != "r" || policy.ASPF != "s" {
+		t.Errorf("Expected lowercased policy, got %+v", policy)
+	}
+
+	first := feedback.Records[0]
+	if first.Row.SourceIP != "192.0.2.1" {
+		t.Errorf("Expected IPv4-mapped address to be unmapped, got %q", first.Row.SourceIP)
+	}
+	evaluated := first.Row.PolicyEvaluated
+	if evaluated.Disposition != "none" || evaluated.DKIM != "pass" || evaluated.SPF != "fail" {
+		t.Errorf("Expected lowercased evaluation, got %+v", evaluated)
+	}
+	if evaluated.Reason[0].Type != "forwarded" {
+		t.Errorf("Expected reason type 'forwarded', got %q", evaluated.Reason[0].Type)
+	}
+	if first.Identifiers.HeaderFrom != "example.com" {
+		t.Errorf("Expected trimmed header_from, got %q", first.Identifiers.HeaderFrom)
+	}
+	if first.AuthResults.DKIM[0].Result != "pass" {
+		t.Errorf("Expected DKIM result 'pass', got %q", first.AuthResults.DKIM[0].Result)
+	}
+	if spf := first.AuthResults.SPF[0]; spf.Result != "softfail" || spf.Scope != "mfrom" {
+		t.Errorf("Expected lowercased SPF result and scope, got %+v", spf)
+	}
+
+	if ip := feedback.Records[1].Row.SourceIP; ip != "2001:db8::1" {
+		t.Errorf("Expected canonical IPv6 address, got %q", ip)
+	}
+
+	// Lowercased results count towards compliance
+	if compliant := feedback.GetDMARCCompliantCount(); compliant != 5 {
+		t.Errorf("Expected 5 compliant messages, got %d", compliant)
+	}
+}
+
+func TestParseReport_PCT(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		want   int
+	}{
+		{"omitted", `<domain>example.com</domain>`, 100},
+		{"explicit zero", `<domain>example.com</domain><pct>0</pct>`, 0},
+		{"explicit", `<domain>example.com</domain><pct>25</pct>`, 25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feedback, err := ParseReport([]byte(`<feedback><policy_published>` + tt.policy + `</policy_published></feedback>`))
+			if err != nil {
+				t.Fatalf("Failed to parse report: %v", err)
+			}
+			if feedback.PolicyPublished.PCT != tt.want {
+				t.Errorf("Expected pct %d, got %d", tt.want, feedback.PolicyPublished.PCT)
+			}
+		})
+	}
+}
+
+func TestNormalize_Nil(t *testing.T) {
+	var f *Feedback
+	if f.Normalize() != nil {
+		t.Error("Expected nil")
+	}
+}
+
+func TestParseReportWithOptions_MaxRecords(t *testing.T) {
+	record := `<record><row><source_ip>192.0.2.1</source_ip><count>1</count></row></record>`
+	data := []byte(`<feedback><policy_published><domain>example.com</domain></policy_published>` +
+		strings.Repeat(record, 5) + `</feedback>`)
+
+	feedback, err := ParseReportWithOptions(context.Background(), data, ParseOptions{MaxRecords: 3})
+	if !errors.Is(err, ErrTruncated) {
+		t.Fatalf("Expected ErrTruncated, got %v", err)
+	}
+	if feedback == nil {
+		t.Fatal("Expected the partial report with ErrTruncated")
+	}
+	if len(feedback.Records) != 3 || feedback.TruncatedAt != 3 {
+		t.Errorf("Expected 3 records and TruncatedAt 3, got %d records and TruncatedAt %d", len(feedback.Records), feedback.TruncatedAt)
+	}
+	if feedback.PolicyPublished.Domain != "example.com" {
+		t.Errorf("Expected elements before the limit to be decoded, got domain %q", feedback.PolicyPublished.Domain)
+	}
+
+	for _, max := range []int{5, -1} {
+		feedback, err = ParseReportWithOptions(context.Background(), data, ParseOptions{MaxRecords: max})
+		if err != nil {
+			t.Fatalf("MaxRecords %d: expected no error, got %v", max, err)
+		}
+		if len(feedback.Records) != 5 || feedback.TruncatedAt != 0 {
+			t.Errorf("MaxRecords %d: expected 5 records and no truncation, got %d records and TruncatedAt %d", max, len(feedback.Records), feedback.TruncatedAt)
+		}
+	}
+}
+
+// cancelAfterContext reports itself cancelled once Err has been called n times
+type cancelAfterContext struct {
+	context.Context
+	n int
+}
+
+func (c *cancelAfterContext) Err() error {
+	if c.n--; c.n < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestParseReportWithOptions_CancelledDuringDecode(t *testing.T) {
+	record := `<record><row><source_ip>192.0.2.1</source_ip><count>1</count></row></record>`
+	data := []byte(`<feedback>` + strings.Repeat(record, 3*ctxCheckRecords) + `</feedback>`)
+
+	// Two checks pass before decoding starts, then the first record check
+	// passes and the second sees the cancellation
+	ctx := &cancelAfterContext{Context: context.Background(), n: 3}
+	feedback, err := ParseReportWithOptions(ctx, data, ParseOptions{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if feedback != nil {
+		t.Errorf("Expected no report after cancellation, got %d records", len(feedback.Records))
+	}
+}
+
+func TestParseReport_NotFeedback(t *testing.T) {
+	if _, err := ParseReport([]byte(`<other><record/></other>`)); err == nil {
+		t.Error("Expected an error for a document that is not <feedback>")
+	}
+	if _, err := ParseReport([]byte(`<feedback><record>`)); err == nil {
+		t.Error("Expected an error for a truncated document")
+	}
 }
@@ -0,0 +1,185 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Issue types reported by ExplainReport
+const (
+	// IssueDMARCFailure is mail that failed both SPF and DKIM
+	IssueDMARCFailure = "dmarc_failure"
+	// IssueSPFFailure is mail that failed SPF but passed DMARC through DKIM
+	IssueSPFFailure = "spf_failure"
+	// IssueDKIMFailure is mail that failed DKIM but passed DMARC through SPF
+	IssueDKIMFailure = "dkim_failure"
+	// IssuePolicyOverride is mail the receiver exempted from the policy
+	IssuePolicyOverride = "policy_override"
+)
+
+// maxIssueSources is the number of source IPs or reasons named in an issue
+const maxIssueSources = 3
+
+// Issue is a problem found in a report, in plain language
+type Issue struct {
+	Type             string `json:"type"`
+	Description      string `json:"description"`
+	AffectedMessages int    `json:"affected_messages"`
+	Recommendation   string `json:"recommendation"`
+}
+
+// ReportExplanation describes a report's compliance for non-specialists
+type ReportExplanation struct {
+	Summary string `json:"summary"`
+	// TopIssues is ordered by affected messages, largest first
+	TopIssues []Issue `json:"top_issues"`
+}
+
+// ExplainReport summarizes the report's DMARC compliance and lists the
+// failing records by cause: SPF and DKIM both failing, only one of them
+// failing, and receiver policy overrides
+func ExplainReport(f *Feedback) *ReportExplanation {
+	total := f.GetTotalMessages()
+	compliant := f.GetDMARCCompliantCount()
+	policy := f.PolicyPublished.P
+	if policy == "" {
+		policy = "none"
+	}
+
+	dmarcFail := make(map[string]int)
+	spfFail := make(map[string]int)
+	dkimFail := make(map[string]int)
+	overrides := make(map[string]int)
+	for _, record := range f.Records {
+		row := record.Row
+		dkimPass := row.PolicyEvaluated.DKIM == "pass"
+		spfPass := row.PolicyEvaluated.SPF == "pass"
+		switch {
+		case !dkimPass && !spfPass:
+			dmarcFail[row.SourceIP] += row.Count
+		case !spfPass:
+			spfFail[row.SourceIP] += row.Count
+		case !dkimPass:
+			dkimFail[row.SourceIP] += row.Count
+		}
+		for _, reason := range row.PolicyEvaluated.Reason {
+			if reason.Type != "" {
+				overrides[reason.Type] += row.Count
+			}
+		}
+	}
+
+	explanation := &ReportExplanation{TopIssues: []Issue{}}
+
+	if n := sumCounts(dmarcFail); n > 0 {
+		recommendation := "If these are your own servers or services that send on your behalf, add them to your SPF record " +
+			"or have them sign mail with DKIM for your domain. Sources you don't recognize are likely spoofing your domain."
+		if policy == "none" {
+			recommendation += " Your policy is p=none, so this mail was still delivered; once every legitimate source passes, " +
+				"move to p=quarantine and then p=reject."
+		}
+		explanation.TopIssues = append(explanation.TopIssues, Issue{
+			Type: IssueDMARCFailure,
+			Description: fmt.Sprintf("%d messages failed both SPF and DKIM, so they failed DMARC. Largest sources: %s.",
+				n, topCounts(dmarcFail)),
+			AffectedMessages: n,
+			Recommendation:   recommendation,
+		})
+	}
+
+	if n := sumCounts(spfFail); n > 0 {
+		explanation.TopIssues = append(explanation.TopIssues, Issue{
+			Type: IssueSPFFailure,
+			Description: fmt.Sprintf("%d messages failed SPF but passed DMARC because DKIM passed. Largest sources: %s.",
+				n, topCounts(spfFail)),
+			AffectedMessages: n,
+			Recommendation: "This is common for forwarded mail, which no SPF record can cover. If these sources send for you " +
+				"directly, include them in your SPF record so mail still passes if their DKIM signature breaks.",
+		})
+	}
+
+	if n := sumCounts(dkimFail); n > 0 {
+		explanation.TopIssues = append(explanation.TopIssues, Issue{
+			Type: IssueDKIMFailure,
+			Description: fmt.Sprintf("%d messages failed DKIM but passed DMARC because SPF passed. Largest sources: %s.",
+				n, topCounts(dkimFail)),
+			AffectedMessages: n,
+			Recommendation: "Enable DKIM signing for your domain at these senders. SPF breaks when mail is forwarded, " +
+				"so DKIM is what keeps it passing DMARC.",
+		})
+	}
+
+	if n := sumCounts(overrides); n > 0 {
+		explanation.TopIssues = append(explanation.TopIssues, Issue{
+			Type: IssuePolicyOverride,
+			Description: fmt.Sprintf("The receiver did not apply your policy to %d messages, giving these reasons: %s.",
+				n, topCounts(overrides)),
+			AffectedMessages: n,
+			Recommendation: "Overrides such as forwarded or mailing_list are decided by the receiver and usually need no action. " +
+				"Make sure mail you send through lists or forwarders is DKIM-signed, since SPF does not survive forwarding.",
+		})
+	}
+
+	sort.SliceStable(explanation.TopIssues, func(i, j int) bool {
+		return explanation.TopIssues[i].AffectedMessages > explanation.TopIssues[j].AffectedMessages
+	})
+
+	explanation.Summary = explainSummary(f, total, compliant, policy, sumCounts(dmarcFail))
+	return explanation
+}
+
+// explainSummary describes the report's overall result in a few sentences
+func explainSummary(f *Feedback, total, compliant int, policy string, failed int) string {
+	if total == 0 {
+		return fmt.Sprintf("%s reported no messages for %s.", f.ReportMetadata.OrgName, f.PolicyPublished.Domain)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%.1f%% of the %d messages %s received for %s between %s and %s passed DMARC.",
+		float64(compliant)/float64(total)*100, total, f.ReportMetadata.OrgName, f.PolicyPublished.Domain,
+		time.Unix(f.ReportMetadata.DateRange.Begin, 0).UTC().Format(time.DateOnly),
+		time.Unix(f.ReportMetadata.DateRange.End, 0).UTC().Format(time.DateOnly))
+	switch {
+	case failed == 0:
+		sb.WriteString(" Every message passed SPF or DKIM.")
+	case policy == "none":
+		fmt.Fprintf(&sb, " %d failed, but your policy is p=none, so they were delivered anyway.", failed)
+	default:
+		fmt.Fprintf(&sb, " %d failed and were subject to your p=%s policy.", failed, policy)
+	}
+	return sb.String()
+}
+
+// sumCounts adds up the message counts in counts
+func sumCounts(counts map[string]int) int {
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	return total
+}
+
+// topCounts formats the largest entries of counts as "key (n messages)"
+func topCounts(counts map[string]int) string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > maxIssueSources {
+		keys = keys[:maxIssueSources]
+	}
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s (%d messages)", k, counts[k]))
+	}
+	return strings.Join(parts, ", ")
+}
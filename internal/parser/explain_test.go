@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func explainRecord(ip string, count int, dkim, spf string, reasons ...string) Record {
+	var r Record
+	r.Row.SourceIP = ip
+	r.Row.Count = count
+	r.Row.PolicyEvaluated.DKIM = dkim
+	r.Row.PolicyEvaluated.SPF = spf
+	for _, reason := range reasons {
+		r.Row.PolicyEvaluated.Reason = append(r.Row.PolicyEvaluated.Reason, Reason{Type: reason})
+	}
+	return r
+}
+
+func TestExplainReport(t *testing.T) {
+	f := &Feedback{}
+	f.ReportMetadata.OrgName = "google.com"
+	f.ReportMetadata.DateRange.Begin = 1609459200
+	f.ReportMetadata.DateRange.End = 1609545600
+	f.PolicyPublished.Domain = "example.com"
+	f.PolicyPublished.P = "none"
+	f.Records = []Record{
+		explainRecord("192.0.2.1", 60, "pass", "pass"),
+		explainRecord("192.0.2.2", 20, "fail", "fail"),
+		explainRecord("192.0.2.3", 5, "fail", "fail"),
+		explainRecord("192.0.2.4", 10, "pass", "fail", "forwarded"),
+		explainRecord("192.0.2.5", 5, "fail", "pass"),
+	}
+
+	e := ExplainReport(f)
+
+	wantTypes := []string{IssueDMARCFailure, IssueSPFFailure, IssuePolicyOverride, IssueDKIMFailure}
+	if len(e.TopIssues) != len(wantTypes) {
+		t.Fatalf("Expected %d issues, got %d: %+v", len(wantTypes), len(e.TopIssues), e.TopIssues)
+	}
+	for i, want := range wantTypes {
+		if e.TopIssues[i].Type != want {
+			t.Errorf("Expected issue %d to be %s, got %s", i, want, e.TopIssues[i].Type)
+		}
+	}
+
+	dmarc := e.TopIssues[0]
+	if dmarc.AffectedMessages != 25 {
+		t.Errorf("Expected 25 messages failing DMARC, got %d", dmarc.AffectedMessages)
+	}
+	if !strings.Contains(dmarc.Description, "192.0.2.2 (20 messages), 192.0.2.3 (5 messages)") {
+		t.Errorf("Expected sources largest first in description, got %q", dmarc.Description)
+	}
+	if !strings.Contains(dmarc.Recommendation, "p=quarantine") {
+		t.Errorf("Expected p=none recommendation to suggest quarantine, got %q", dmarc.Recommendation)
+	}
+	if !strings.Contains(e.TopIssues[2].Description, "forwarded (10 messages)") {
+		t.Errorf("Expected override reason in description, got %q", e.TopIssues[2].Description)
+	}
+
+	if !strings.HasPrefix(e.Summary, "75.0% of the 100 messages google.com received for example.com between 2021-01-01 and 2021-01-02") {
+		t.Errorf("Unexpected summary: %q", e.Summary)
+	}
+	if !strings.Contains(e.Summary, "p=none") {
+		t.Errorf("Expected summary to mention the p=none policy, got %q", e.Summary)
+	}
+}
+
+func TestExplainReport_NoIssues(t *testing.T) {
+	f := &Feedback{}
+	f.PolicyPublished.P = "reject"
+	f.Records = []Record{explainRecord("192.0.2.1", 10, "pass", "pass")}
+
+	e := ExplainReport(f)
+	if e.TopIssues == nil || len(e.TopIssues) != 0 {
+		t.Errorf("Expected empty issue list, got %+v", e.TopIssues)
+	}
+	if !strings.Contains(e.Summary, "Every message passed") {
+		t.Errorf("Unexpected summary: %q", e.Summary)
+	}
+}
@@ -0,0 +1,53 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"testing"
+)
+
+// fuzzMaxAlloc is the most a single ParseReport call may allocate
+const fuzzMaxAlloc = 500 << 20
+
+func FuzzParseReport(f *testing.F) {
+	f.Add([]byte(minimalReportXML))
+	f.Add(gzipData(f, []byte(minimalReportXML)))
+	f.Add(zipData(f, []byte(minimalReportXML)))
+	f.Add(gzipData(f, zipData(f, []byte(minimalReportXML))))
+	f.Add([]byte(`<feedback><record><row><count>-1</count></row></record></feedback>`))
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+
+		feedback, err := ParseReport(data)
+
+		runtime.ReadMemStats(&after)
+		if alloc := after.TotalAlloc - before.TotalAlloc; alloc > fuzzMaxAlloc {
+			t.Fatalf("ParseReport allocated %d bytes for a %d byte input", alloc, len(data))
+		}
+
+		if err != nil {
+			if feedback != nil && !errors.Is(err, ErrTruncated) {
+				t.Fatalf("Expected nil feedback with error %v", err)
+			}
+			return
+		}
+		if feedback == nil {
+			t.Fatal("Expected feedback or an error, got neither")
+		}
+		// Exercise the accessors used when saving a report
+		_ = feedback.GetTotalMessages()
+		_ = feedback.GetDMARCCompliantCount()
+		_, _ = feedback.GetDateRange()
+	})
+}
+
+func TestParseReport_DecompressionLimit(t *testing.T) {
+	bomb := gzipData(t, bytes.Repeat([]byte{' '}, maxDecompressedSize+1))
+	if _, err := ParseReport(bomb); !errors.Is(err, ErrReportTooLarge) {
+		t.Errorf("Expected ErrReportTooLarge, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package publisher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/goccy/go-json"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// AMQPRoutingKey is the routing key used for report events
+const AMQPRoutingKey = "dmarc.report.saved"
+
+// AMQPPublisher publishes events to a RabbitMQ exchange.
+// The connection is re-established on the next publish after it is lost.
+type AMQPPublisher struct {
+	url      string
+	exchange string
+
+	mu   sync.Mutex
+	conn *amqp.Connection
+	ch   *amqp.Channel
+}
+
+// NewAMQPPublisher connects to the broker at url
+func NewAMQPPublisher(url, exchange string) (*AMQPPublisher, error) {
+	p := &AMQPPublisher{url: url, exchange: exchange}
+	if err := p.connect(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// connect dials the broker and opens a channel. The caller must hold mu
+// or have exclusive access to p.
+func (p *AMQPPublisher) connect() error {
+	conn, err := amqp.Dial(p.url)
+	if err != nil {
+		return fmt.Errorf("dial AMQP broker: %w", err)
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("open AMQP channel: %w", err)
+	}
+
+	p.conn = conn
+	p.ch = ch
+	return nil
+}
+
+// reset drops the current connection so the next publish reconnects
+func (p *AMQPPublisher) reset() {
+	if p.conn != nil {
+		_ = p.conn.Close()
+	}
+	p.conn = nil
+	p.ch = nil
+}
+
+// Publish sends the event as JSON, reconnecting once if the connection was lost
+func (p *AMQPPublisher) Publish(ctx context.Context, event *Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+
+	msg := amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    event.ReportID,
+		Timestamp:    event.Timestamp,
+		Body:         body,
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for attempt := 0; attempt < 2; attempt++ {
+		if p.conn == nil || p.conn.IsClosed() || p.ch == nil || p.ch.IsClosed() {
+			p.reset()
+			if err = p.connect(); err != nil {
+				continue
+			}
+		}
+
+		err = p.ch.PublishWithContext(ctx, p.exchange, AMQPRoutingKey, false, false, msg)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, amqp.ErrClosed) {
+			break
+		}
+		p.reset()
+	}
+
+	return fmt.Errorf("publish AMQP event: %w", err)
+}
+
+// Close closes the broker connection
+func (p *AMQPPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn == nil {
+		return nil
+	}
+	err := p.conn.Close()
+	p.conn = nil
+	p.ch = nil
+	return err
+}
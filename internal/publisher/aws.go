@@ -0,0 +1,125 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/goccy/go-json"
+)
+
+// AWSOptions holds the region and optional static credentials for AWS
+// publishers. When AccessKey is empty, the default credential chain is used
+// (environment variables, shared config, instance role).
+type AWSOptions struct {
+	Region    string
+	AccessKey string
+	SecretKey string
+}
+
+// loadAWSConfig resolves the AWS SDK configuration for opts
+func loadAWSConfig(ctx context.Context, opts AWSOptions) (aws.Config, error) {
+	var loadOpts []func(*awsconfig.LoadOptions) error
+	if opts.Region != "" {
+		loadOpts = append(loadOpts, awsconfig.WithRegion(opts.Region))
+	}
+	if opts.AccessKey != "" {
+		loadOpts = append(loadOpts, awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(opts.AccessKey, opts.SecretKey, "")))
+	}
+
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("load AWS config: %w", err)
+	}
+	return cfg, nil
+}
+
+// SNSPublisher publishes events to an SNS topic
+type SNSPublisher struct {
+	client   *sns.Client
+	topicARN string
+}
+
+// NewSNSPublisher creates a publisher for the given topic ARN
+func NewSNSPublisher(ctx context.Context, topicARN string, opts AWSOptions) (*SNSPublisher, error) {
+	cfg, err := loadAWSConfig(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &SNSPublisher{client: sns.NewFromConfig(cfg), topicARN: topicARN}, nil
+}
+
+// Publish sends the event as a JSON message. The domain is attached as a
+// message attribute so subscriptions can filter on it.
+func (p *SNSPublisher) Publish(ctx context.Context, event *Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+
+	_, err = p.client.Publish(ctx, &sns.PublishInput{
+		TopicArn: aws.String(p.topicARN),
+		Message:  aws.String(string(body)),
+		Subject:  aws.String("DMARC report for " + event.Domain),
+		MessageAttributes: map[string]snstypes.MessageAttributeValue{
+			"domain": {DataType: aws.String("String"), StringValue: aws.String(event.Domain)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("publish SNS event: %w", err)
+	}
+	return nil
+}
+
+// Close is a no-op; the SNS client holds no persistent connection
+func (p *SNSPublisher) Close() error {
+	return nil
+}
+
+// SQSPublisher sends events to an SQS queue
+type SQSPublisher struct {
+	client   *sqs.Client
+	queueURL string
+}
+
+// NewSQSPublisher creates a publisher for the given queue URL
+func NewSQSPublisher(ctx context.Context, queueURL string, opts AWSOptions) (*SQSPublisher, error) {
+	cfg, err := loadAWSConfig(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &SQSPublisher{client: sqs.NewFromConfig(cfg), queueURL: queueURL}, nil
+}
+
+// Publish sends the event as a JSON message with the domain as a message
+// attribute
+func (p *SQSPublisher) Publish(ctx context.Context, event *Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+
+	_, err = p.client.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:    aws.String(p.queueURL),
+		MessageBody: aws.String(string(body)),
+		MessageAttributes: map[string]sqstypes.MessageAttributeValue{
+			"domain": {DataType: aws.String("String"), StringValue: aws.String(event.Domain)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("send SQS event: %w", err)
+	}
+	return nil
+}
+
+// Close is a no-op; the SQS client holds no persistent connection
+func (p *SQSPublisher) Close() error {
+	return nil
+}
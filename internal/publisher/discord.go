@@ -0,0 +1,76 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// Discord embed colors
+const (
+	discordGreen = 0x2eb67d
+	discordRed   = 0xe01e5a
+)
+
+// DiscordPublisher posts embeds to a Discord webhook
+type DiscordPublisher struct {
+	webhookURL   string
+	dashboardURL string
+	client       *http.Client
+}
+
+// NewDiscordPublisher creates a publisher for the given webhook URL.
+// When dashboardURL is set, the embed title links to the report.
+func NewDiscordPublisher(webhookURL, dashboardURL string) *DiscordPublisher {
+	return &DiscordPublisher{
+		webhookURL:   webhookURL,
+		dashboardURL: strings.TrimSuffix(dashboardURL, "/"),
+		client:       &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// discordMessage builds the webhook payload for an event
+func (p *DiscordPublisher) discordMessage(event *Event) map[string]interface{} {
+	color := discordRed
+	if event.ComplianceRate >= complianceGood {
+		color = discordGreen
+	}
+
+	embed := map[string]interface{}{
+		"title": event.Title(fmt.Sprintf("DMARC report for %s", event.Domain)),
+		"color": color,
+		"fields": []map[string]interface{}{
+			{"name": "Domain", "value": event.Domain, "inline": true},
+			{"name": "Reporter", "value": event.Org, "inline": true},
+			{"name": "Compliance", "value": fmt.Sprintf("%.2f%%", event.ComplianceRate), "inline": true},
+			{"name": "Messages", "value": fmt.Sprintf("%d", event.TotalMessages), "inline": true},
+		},
+		"footer":    map[string]string{"text": "Report period: " + event.Period()},
+		"timestamp": event.Timestamp.Format(time.RFC3339),
+	}
+	if link := ReportURL(p.dashboardURL, event); link != "" {
+		embed["url"] = link
+	}
+
+	return map[string]interface{}{
+		"embeds": []map[string]interface{}{embed},
+	}
+}
+
+// Name identifies the channel in logs
+func (p *DiscordPublisher) Name() string {
+	return "discord"
+}
+
+// Send posts the event to Discord
+func (p *DiscordPublisher) Send(ctx context.Context, event *Event) error {
+	body, err := json.Marshal(p.discordMessage(event))
+	if err != nil {
+		return fmt.Errorf("marshal Discord message: %w", err)
+	}
+	return postJSON(ctx, p.client, p.webhookURL, body)
+}
@@ -0,0 +1,36 @@
+package publisher
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiscordMessage(t *testing.T) {
+	p := NewDiscordPublisher("https://discord.example.com/webhook", "https://dmarc.example.com")
+	event := &Event{
+		ReportID:       "r1",
+		Domain:         "example.com",
+		ComplianceRate: 50,
+		DateBegin:      1609459200,
+		DateEnd:        1609545600,
+		Timestamp:      time.Unix(1609545600, 0).UTC(),
+	}
+
+	embed := p.discordMessage(event)["embeds"].([]map[string]interface{})[0]
+	if embed["color"] != discordRed {
+		t.Errorf("Expected red embed for low compliance, got %v", embed["color"])
+	}
+	if embed["url"] != "https://dmarc.example.com/?report_id=r1" {
+		t.Errorf("Unexpected embed URL %v", embed["url"])
+	}
+	footer := embed["footer"].(map[string]string)["text"]
+	if footer != "Report period: 2021-01-01 00:00 – 2021-01-02 00:00 UTC" {
+		t.Errorf("Unexpected footer %q", footer)
+	}
+
+	event.ComplianceRate = 99
+	embed = p.discordMessage(event)["embeds"].([]map[string]interface{})[0]
+	if embed["color"] != discordGreen {
+		t.Errorf("Expected green embed for high compliance, got %v", embed["color"])
+	}
+}
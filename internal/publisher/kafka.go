@@ -0,0 +1,126 @@
+package publisher
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// KafkaOptions configures a KafkaProducer
+type KafkaOptions struct {
+	Brokers []string
+	Topic   string
+	// DeadLetterTopic receives events that could not be delivered to Topic.
+	// Defaults to Topic with a ".dlq" suffix.
+	DeadLetterTopic string
+	TLS             bool
+	// SASLMechanism is one of PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512.
+	// SASL is disabled when empty.
+	SASLMechanism string
+	SASLUser      string
+	SASLPassword  string
+}
+
+// KafkaProducer publishes events to a Kafka topic keyed by report ID
+type KafkaProducer struct {
+	writer          *kafka.Writer
+	topic           string
+	deadLetterTopic string
+}
+
+// NewKafkaProducer creates a producer for the given options
+func NewKafkaProducer(opts KafkaOptions) (*KafkaProducer, error) {
+	if len(opts.Brokers) == 0 {
+		return nil, errors.New("kafka: at least one broker is required")
+	}
+	if opts.Topic == "" {
+		return nil, errors.New("kafka: topic is required")
+	}
+
+	mechanism, err := saslMechanism(opts.SASLMechanism, opts.SASLUser, opts.SASLPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := &kafka.Transport{SASL: mechanism}
+	if opts.TLS {
+		transport.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	deadLetterTopic := opts.DeadLetterTopic
+	if deadLetterTopic == "" {
+		deadLetterTopic = opts.Topic + ".dlq"
+	}
+
+	return &KafkaProducer{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(opts.Brokers...),
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			Transport:    transport,
+		},
+		topic:           opts.Topic,
+		deadLetterTopic: deadLetterTopic,
+	}, nil
+}
+
+// saslMechanism builds the SASL mechanism named by name
+func saslMechanism(name, user, password string) (sasl.Mechanism, error) {
+	switch strings.ToUpper(name) {
+	case "":
+		return nil, nil
+	case "PLAIN":
+		return plain.Mechanism{Username: user, Password: password}, nil
+	case "SCRAM-SHA-256":
+		return scram.Mechanism(scram.SHA256, user, password)
+	case "SCRAM-SHA-512":
+		return scram.Mechanism(scram.SHA512, user, password)
+	default:
+		return nil, fmt.Errorf("kafka: unsupported SASL mechanism %q", name)
+	}
+}
+
+// Publish sends the event to the topic. If delivery fails, the event is
+// written to the dead-letter topic along with the delivery error.
+func (p *KafkaProducer) Publish(ctx context.Context, event *Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+
+	err = p.writer.WriteMessages(ctx, kafka.Message{
+		Topic: p.topic,
+		Key:   []byte(event.ReportID),
+		Value: body,
+	})
+	if err == nil {
+		return nil
+	}
+
+	dlqErr := p.writer.WriteMessages(ctx, kafka.Message{
+		Topic: p.deadLetterTopic,
+		Key:   []byte(event.ReportID),
+		Value: body,
+		Headers: []kafka.Header{
+			{Key: "error", Value: []byte(err.Error())},
+			{Key: "original_topic", Value: []byte(p.topic)},
+		},
+	})
+	if dlqErr != nil {
+		return fmt.Errorf("publish Kafka event: %w (dead-letter: %v)", err, dlqErr)
+	}
+	return fmt.Errorf("publish Kafka event, sent to %s: %w", p.deadLetterTopic, err)
+}
+
+// Close flushes pending messages and closes the producer
+func (p *KafkaProducer) Close() error {
+	return p.writer.Close()
+}
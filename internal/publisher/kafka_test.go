@@ -0,0 +1,25 @@
+package publisher
+
+import "testing"
+
+func TestNewKafkaProducer(t *testing.T) {
+	if _, err := NewKafkaProducer(KafkaOptions{Topic: "reports"}); err == nil {
+		t.Errorf("Expected error without brokers")
+	}
+	if _, err := NewKafkaProducer(KafkaOptions{Brokers: []string{"localhost:9092"}}); err == nil {
+		t.Errorf("Expected error without topic")
+	}
+	if _, err := NewKafkaProducer(KafkaOptions{Brokers: []string{"localhost:9092"}, Topic: "reports", SASLMechanism: "GSSAPI"}); err == nil {
+		t.Errorf("Expected error for unsupported SASL mechanism")
+	}
+
+	p, err := NewKafkaProducer(KafkaOptions{Brokers: []string{"localhost:9092"}, Topic: "reports", SASLMechanism: "scram-sha-512"})
+	if err != nil {
+		t.Fatalf("Failed to create producer: %v", err)
+	}
+	defer func() { _ = p.Close() }()
+
+	if p.deadLetterTopic != "reports.dlq" {
+		t.Errorf("Expected dead-letter topic reports.dlq, got %s", p.deadLetterTopic)
+	}
+}
@@ -0,0 +1,158 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// PagerDutyEventsURL is the PagerDuty Events API v2 endpoint
+const PagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDuty severities
+const (
+	SeverityCritical = "critical"
+	SeverityError    = "error"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+// PagerDutyPublisher triggers an incident when a domain's compliance drops
+// below a threshold and resolves it when a later report is back above it.
+// Open incidents are tracked in memory per domain and routing key.
+type PagerDutyPublisher struct {
+	routingKey   string
+	threshold    float64
+	dashboardURL string
+	endpoint     string
+	client       *http.Client
+
+	mu   sync.Mutex
+	open map[string]bool
+}
+
+// NewPagerDutyPublisher creates a publisher for the given integration
+// routing key that triggers below threshold percent compliance
+func NewPagerDutyPublisher(routingKey string, threshold float64, dashboardURL string) *PagerDutyPublisher {
+	return &PagerDutyPublisher{
+		routingKey:   routingKey,
+		threshold:    threshold,
+		dashboardURL: strings.TrimSuffix(dashboardURL, "/"),
+		endpoint:     PagerDutyEventsURL,
+		client:       &http.Client{Timeout: 10 * time.Second},
+		open:         make(map[string]bool),
+	}
+}
+
+// PagerDutySeverity maps a compliance rate to a PagerDuty severity
+func PagerDutySeverity(rate float64) string {
+	switch {
+	case rate < 50:
+		return SeverityCritical
+	case rate < complianceWarning:
+		return SeverityError
+	case rate < complianceGood:
+		return SeverityWarning
+	default:
+		return SeverityInfo
+	}
+}
+
+// dedupKey identifies the incident for a domain
+func (p *PagerDutyPublisher) dedupKey(domain string) string {
+	return "parse-dmarc/" + strings.ToLower(domain)
+}
+
+// Name identifies the channel in logs
+func (p *PagerDutyPublisher) Name() string {
+	return "pagerduty"
+}
+
+// Send triggers or resolves the domain's incident based on the event's
+// compliance rate. Events that don't change the incident state are ignored.
+// A test event triggers an incident of its own and resolves it right away,
+// leaving the domain's incident alone.
+func (p *PagerDutyPublisher) Send(ctx context.Context, event *Event) error {
+	if event.Test {
+		dedupKey := "parse-dmarc/test/" + event.ReportID
+		if err := p.post(ctx, p.triggerMessage(event, dedupKey)); err != nil {
+			return err
+		}
+		return p.post(ctx, p.resolveMessage(dedupKey))
+	}
+
+	key := p.dedupKey(event.Domain) + "/" + p.routingKey
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	below := event.ComplianceRate < p.threshold
+	if below == p.open[key] {
+		return nil
+	}
+
+	msg := p.resolveMessage(p.dedupKey(event.Domain))
+	if below {
+		msg = p.triggerMessage(event, p.dedupKey(event.Domain))
+	}
+	if err := p.post(ctx, msg); err != nil {
+		return err
+	}
+
+	if below {
+		p.open[key] = true
+	} else {
+		delete(p.open, key)
+	}
+	return nil
+}
+
+// triggerMessage builds the Events API message opening the incident
+// dedupKey for event
+func (p *PagerDutyPublisher) triggerMessage(event *Event, dedupKey string) map[string]interface{} {
+	msg := map[string]interface{}{
+		"routing_key":  p.routingKey,
+		"dedup_key":    dedupKey,
+		"event_action": "trigger",
+		"payload": map[string]interface{}{
+			"summary": event.Title(fmt.Sprintf("DMARC compliance for %s dropped to %.1f%% (threshold %.1f%%)",
+				event.Domain, event.ComplianceRate, p.threshold)),
+			"source":         "parse-dmarc",
+			"severity":       PagerDutySeverity(event.ComplianceRate),
+			"component":      event.Domain,
+			"group":          "dmarc",
+			"class":          "compliance",
+			"custom_details": event,
+		},
+	}
+	if link := ReportURL(p.dashboardURL, event); link != "" {
+		msg["links"] = []map[string]string{{"href": link, "text": "View report"}}
+	}
+	return msg
+}
+
+// resolveMessage builds the Events API message closing the incident dedupKey
+func (p *PagerDutyPublisher) resolveMessage(dedupKey string) map[string]interface{} {
+	return map[string]interface{}{
+		"routing_key":  p.routingKey,
+		"dedup_key":    dedupKey,
+		"event_action": "resolve",
+	}
+}
+
+// post sends an Events API message
+func (p *PagerDutyPublisher) post(ctx context.Context, msg map[string]interface{}) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal PagerDuty event: %w", err)
+	}
+	if err := postJSON(ctx, p.client, p.endpoint, body); err != nil {
+		return fmt.Errorf("send PagerDuty event: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package publisher
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goccy/go-json"
+)
+
+func TestPagerDutySeverity(t *testing.T) {
+	tests := []struct {
+		rate float64
+		want string
+	}{
+		{20, SeverityCritical},
+		{70, SeverityError},
+		{90, SeverityWarning},
+		{99, SeverityInfo},
+	}
+	for _, tt := range tests {
+		if got := PagerDutySeverity(tt.rate); got != tt.want {
+			t.Errorf("Expected severity %s for %.0f%%, got %s", tt.want, tt.rate, got)
+		}
+	}
+}
+
+func TestPagerDutyTriggerAndResolve(t *testing.T) {
+	var actions []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var msg struct {
+			EventAction string `json:"event_action"`
+			DedupKey    string `json:"dedup_key"`
+		}
+		_ = json.Unmarshal(body, &msg)
+		if msg.DedupKey != "parse-dmarc/example.com" {
+			t.Errorf("Unexpected dedup key %s", msg.DedupKey)
+		}
+		actions = append(actions, msg.EventAction)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	p := NewPagerDutyPublisher("key", 90, "")
+	p.endpoint = srv.URL
+
+	for _, rate := range []float64{95, 50, 60, 95, 99} {
+		if err := p.Send(context.Background(), &Event{Domain: "example.com", ComplianceRate: rate}); err != nil {
+			t.Fatalf("Failed to send: %v", err)
+		}
+	}
+
+	if len(actions) != 2 || actions[0] != "trigger" || actions[1] != "resolve" {
+		t.Errorf("Expected [trigger resolve], got %v", actions)
+	}
+}
+
+func TestPagerDutyTestEvent(t *testing.T) {
+	type message struct {
+		EventAction string `json:"event_action"`
+		DedupKey    string `json:"dedup_key"`
+	}
+	var msgs []message
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var msg message
+		_ = json.Unmarshal(body, &msg)
+		msgs = append(msgs, msg)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	p := NewPagerDutyPublisher("key", 90, "")
+	p.endpoint = srv.URL
+
+	event := NewTestEvent()
+	if err := p.Send(context.Background(), event); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+
+	if len(msgs) != 2 || msgs[0].EventAction != "trigger" || msgs[1].EventAction != "resolve" {
+		t.Fatalf("Expected trigger then resolve, got %+v", msgs)
+	}
+	for _, msg := range msgs {
+		if msg.DedupKey != "parse-dmarc/test/"+event.ReportID {
+			t.Errorf("Expected a dedup key of its own, got %s", msg.DedupKey)
+		}
+	}
+
+	// The domain's real incident is still triggered by the next low report
+	if err := p.Send(context.Background(), &Event{Domain: "example.com", ComplianceRate: 50}); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+	if len(msgs) != 3 || msgs[2].EventAction != "trigger" || msgs[2].DedupKey != "parse-dmarc/example.com" {
+		t.Errorf("Expected the domain incident to be triggered, got %+v", msgs)
+	}
+}
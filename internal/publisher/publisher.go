@@ -0,0 +1,109 @@
+// Package publisher emits report ingestion events to external message buses.
+package publisher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/meysam81/parse-dmarc/internal/parser"
+)
+
+// Event is published whenever a report is saved
+type Event struct {
+	ReportID       string    `json:"report_id"`
+	Domain         string    `json:"domain"`
+	Org            string    `json:"org"`
+	ComplianceRate float64   `json:"compliance_rate"`
+	TotalMessages  int       `json:"total_messages"`
+	DateBegin      int64     `json:"date_begin"`
+	DateEnd        int64     `json:"date_end"`
+	Timestamp      time.Time `json:"timestamp"`
+	// Test marks a synthetic event sent to check a channel's configuration
+	Test bool `json:"test,omitempty"`
+}
+
+// NewReportEvent builds an Event from a parsed report
+func NewReportEvent(feedback *parser.Feedback) *Event {
+	total := feedback.GetTotalMessages()
+	var rate float64
+	if total > 0 {
+		rate = float64(feedback.GetDMARCCompliantCount()) / float64(total) * 100
+	}
+
+	return &Event{
+		ReportID:       feedback.ReportMetadata.ReportID,
+		Domain:         feedback.PolicyPublished.Domain,
+		Org:            feedback.ReportMetadata.OrgName,
+		ComplianceRate: rate,
+		TotalMessages:  total,
+		DateBegin:      feedback.ReportMetadata.DateRange.Begin,
+		DateEnd:        feedback.ReportMetadata.DateRange.End,
+		Timestamp:      time.Now().UTC(),
+	}
+}
+
+// NewTestEvent builds a synthetic event for checking that a channel is
+// configured correctly. Its compliance rate is low enough to pass every
+// threshold filter.
+func NewTestEvent() *Event {
+	now := time.Now().UTC()
+	return &Event{
+		ReportID:       fmt.Sprintf("test-%d", now.Unix()),
+		Domain:         "example.com",
+		Org:            "parse-dmarc",
+		ComplianceRate: 0,
+		TotalMessages:  1,
+		DateBegin:      now.Add(-24 * time.Hour).Unix(),
+		DateEnd:        now.Unix(),
+		Timestamp:      now,
+		Test:           true,
+	}
+}
+
+// Title prefixes s with [TEST] for test events
+func (e *Event) Title(s string) string {
+	if e.Test {
+		return "[TEST] " + s
+	}
+	return s
+}
+
+// Period formats the report's date range in UTC
+func (e *Event) Period() string {
+	return fmt.Sprintf("%s – %s",
+		time.Unix(e.DateBegin, 0).UTC().Format("2006-01-02 15:04"),
+		time.Unix(e.DateEnd, 0).UTC().Format("2006-01-02 15:04 MST"))
+}
+
+// Publisher delivers events to a message bus
+type Publisher interface {
+	Publish(ctx context.Context, event *Event) error
+	Close() error
+}
+
+// Multi fans an event out to several publishers
+type Multi []Publisher
+
+// Publish sends the event to every publisher and joins their errors
+func (m Multi) Publish(ctx context.Context, event *Event) error {
+	var errs []error
+	for _, p := range m {
+		if err := p.Publish(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes every publisher and joins their errors
+func (m Multi) Close() error {
+	var errs []error
+	for _, p := range m {
+		if err := p.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
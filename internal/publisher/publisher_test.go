@@ -0,0 +1,63 @@
+package publisher
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/meysam81/parse-dmarc/internal/parser"
+)
+
+type fakePublisher struct {
+	events []*Event
+	err    error
+}
+
+func (f *fakePublisher) Publish(_ context.Context, event *Event) error {
+	f.events = append(f.events, event)
+	return f.err
+}
+
+func (f *fakePublisher) Close() error { return nil }
+
+func TestNewReportEvent(t *testing.T) {
+	feedback := &parser.Feedback{
+		ReportMetadata:  parser.ReportMetadata{OrgName: "google.com", ReportID: "r1"},
+		PolicyPublished: parser.PolicyPublished{Domain: "example.com"},
+		Records: []parser.Record{
+			{Row: parser.Row{Count: 3, PolicyEvaluated: parser.PolicyEvaluated{DKIM: "pass", SPF: "fail"}}},
+			{Row: parser.Row{Count: 1, PolicyEvaluated: parser.PolicyEvaluated{DKIM: "fail", SPF: "fail"}}},
+		},
+	}
+
+	event := NewReportEvent(feedback)
+	if event.ReportID != "r1" || event.Domain != "example.com" || event.Org != "google.com" {
+		t.Errorf("Unexpected event identity: %+v", event)
+	}
+	if event.TotalMessages != 4 {
+		t.Errorf("Expected 4 total messages, got %d", event.TotalMessages)
+	}
+	if event.ComplianceRate != 75 {
+		t.Errorf("Expected compliance rate 75, got %f", event.ComplianceRate)
+	}
+	if event.Timestamp.IsZero() {
+		t.Errorf("Expected timestamp to be set")
+	}
+}
+
+func TestMultiPublish(t *testing.T) {
+	ok := &fakePublisher{}
+	failing := &fakePublisher{err: errors.New("boom")}
+
+	err := Multi{failing, ok}.Publish(context.Background(), &Event{ReportID: "r1"})
+	if err == nil {
+		t.Errorf("Expected error from failing publisher")
+	}
+	if len(ok.events) != 1 {
+		t.Errorf("Expected remaining publishers to receive the event, got %d", len(ok.events))
+	}
+
+	if err := (Multi{}).Publish(context.Background(), &Event{}); err != nil {
+		t.Errorf("Expected no error from empty Multi, got %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/goccy/go-json"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisPublisher publishes events to a Redis pub/sub channel
+type RedisPublisher struct {
+	client  *redis.Client
+	channel string
+}
+
+// NewRedisPublisher connects to the Redis server at addr
+func NewRedisPublisher(ctx context.Context, addr, channel string) (*RedisPublisher, error) {
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("connect to Redis at %s: %w", addr, err)
+	}
+	return &RedisPublisher{client: client, channel: channel}, nil
+}
+
+// Publish sends the event as JSON to the configured channel
+func (p *RedisPublisher) Publish(ctx context.Context, event *Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+	if err := p.client.Publish(ctx, p.channel, body).Err(); err != nil {
+		return fmt.Errorf("publish Redis event: %w", err)
+	}
+	return nil
+}
+
+// Subscribe returns the raw JSON events published to the channel.
+// The returned channel is closed once ctx is done.
+func (p *RedisPublisher) Subscribe(ctx context.Context) (<-chan []byte, error) {
+	ps := p.client.Subscribe(ctx, p.channel)
+	if _, err := ps.Receive(ctx); err != nil {
+		_ = ps.Close()
+		return nil, fmt.Errorf("subscribe to Redis channel %s: %w", p.channel, err)
+	}
+
+	out := make(chan []byte)
+	go func() {
+		defer close(out)
+		defer func() { _ = ps.Close() }()
+
+		msgs := ps.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+				select {
+				case out <- []byte(msg.Payload):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// Close closes the Redis client
+func (p *RedisPublisher) Close() error {
+	return p.client.Close()
+}
@@ -0,0 +1,140 @@
+package publisher
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// Compliance levels used to color chat notifications
+const (
+	complianceGood    = 95.0
+	complianceWarning = 80.0
+)
+
+// slackTimeout bounds a single Slack webhook delivery
+const slackTimeout = 10 * time.Second
+
+// SlackPublisher posts Block Kit messages to a Slack incoming webhook
+type SlackPublisher struct {
+	webhookURL   string
+	dashboardURL string
+	client       *http.Client
+}
+
+// NewSlackPublisher creates a publisher for the given incoming webhook URL.
+// When dashboardURL is set, messages link to the report in the dashboard.
+func NewSlackPublisher(webhookURL, dashboardURL string) *SlackPublisher {
+	return &SlackPublisher{
+		webhookURL:   webhookURL,
+		dashboardURL: strings.TrimSuffix(dashboardURL, "/"),
+		client:       &http.Client{Timeout: slackTimeout},
+	}
+}
+
+// slackColor returns the attachment color for a compliance rate
+func slackColor(rate float64) string {
+	switch {
+	case rate >= complianceGood:
+		return "#2eb67d"
+	case rate >= complianceWarning:
+		return "#ecb22e"
+	default:
+		return "#e01e5a"
+	}
+}
+
+// ReportURL returns the dashboard link for the event's report, or "" if no
+// dashboard URL is configured
+func ReportURL(dashboardURL string, event *Event) string {
+	if dashboardURL == "" {
+		return ""
+	}
+	return dashboardURL + "/?report_id=" + url.QueryEscape(event.ReportID)
+}
+
+// slackMessage builds the Block Kit payload for an event
+func (p *SlackPublisher) slackMessage(event *Event) map[string]interface{} {
+	summary := event.Title(fmt.Sprintf("DMARC compliance for %s is %.1f%%", event.Domain, event.ComplianceRate))
+
+	blocks := []map[string]interface{}{
+		{
+			"type": "header",
+			"text": map[string]interface{}{"type": "plain_text", "text": summary},
+		},
+		{
+			"type": "section",
+			"fields": []map[string]string{
+				{"type": "mrkdwn", "text": "*Domain*\n" + event.Domain},
+				{"type": "mrkdwn", "text": "*Reporter*\n" + event.Org},
+				{"type": "mrkdwn", "text": fmt.Sprintf("*Compliance rate*\n%.2f%%", event.ComplianceRate)},
+				{"type": "mrkdwn", "text": fmt.Sprintf("*Total messages*\n%d", event.TotalMessages)},
+				{"type": "mrkdwn", "text": "*Report period*\n" + event.Period()},
+				{"type": "mrkdwn", "text": "*Report ID*\n" + event.ReportID},
+			},
+		},
+	}
+
+	if link := ReportURL(p.dashboardURL, event); link != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "actions",
+			"elements": []map[string]interface{}{
+				{
+					"type": "button",
+					"text": map[string]string{"type": "plain_text", "text": "View report"},
+					"url":  link,
+				},
+			},
+		})
+	}
+
+	return map[string]interface{}{
+		"text": summary,
+		"attachments": []map[string]interface{}{
+			{"color": slackColor(event.ComplianceRate), "blocks": blocks},
+		},
+	}
+}
+
+// Name identifies the channel in logs
+func (p *SlackPublisher) Name() string {
+	return "slack"
+}
+
+// Send posts the event to Slack
+func (p *SlackPublisher) Send(ctx context.Context, event *Event) error {
+	body, err := json.Marshal(p.slackMessage(event))
+	if err != nil {
+		return fmt.Errorf("marshal Slack message: %w", err)
+	}
+	return postJSON(ctx, p.client, p.webhookURL, body)
+}
+
+// postJSON posts body to url and fails on non-2xx responses, including the
+// response body in the error
+func postJSON(ctx context.Context, client *http.Client, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("%s returned status %d: %s", req.URL.Host, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
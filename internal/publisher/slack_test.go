@@ -0,0 +1,72 @@
+package publisher
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+)
+
+func TestSlackColor(t *testing.T) {
+	tests := []struct {
+		rate float64
+		want string
+	}{
+		{100, "#2eb67d"},
+		{95, "#2eb67d"},
+		{90, "#ecb22e"},
+		{50, "#e01e5a"},
+	}
+	for _, tt := range tests {
+		if got := slackColor(tt.rate); got != tt.want {
+			t.Errorf("Expected color %s for %.0f%%, got %s", tt.want, tt.rate, got)
+		}
+	}
+}
+
+func TestSlackPublisherSend(t *testing.T) {
+	var payload map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &payload)
+	}))
+	defer srv.Close()
+
+	p := NewSlackPublisher(srv.URL, "https://dmarc.example.com/")
+	event := &Event{ReportID: "r 1", Domain: "example.com", ComplianceRate: 50}
+	if err := p.Send(context.Background(), event); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+
+	if !strings.Contains(payload["text"].(string), "example.com") {
+		t.Errorf("Expected summary to mention domain, got %v", payload["text"])
+	}
+	raw, _ := json.Marshal(payload)
+	if !strings.Contains(string(raw), "https://dmarc.example.com/?report_id=r+1") {
+		t.Errorf("Expected report link in payload, got %s", raw)
+	}
+}
+
+func TestSlackMessageTestEvent(t *testing.T) {
+	p := NewSlackPublisher("", "")
+	msg := p.slackMessage(NewTestEvent())
+	if !strings.HasPrefix(msg["text"].(string), "[TEST] ") {
+		t.Errorf("Expected [TEST] prefix, got %v", msg["text"])
+	}
+}
+
+func TestSlackPublisherSendError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_payload", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	err := NewSlackPublisher(srv.URL, "").Send(context.Background(), &Event{})
+	if err == nil || !strings.Contains(err.Error(), "invalid_payload") {
+		t.Errorf("Expected error with response body, got %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+// Package scaffold renders deployment files from templates embedded in the binary.
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"text/template"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+//go:embed templates
+var templatesFS embed.FS
+
+// DefaultImage is the container image used when none is specified
+const DefaultImage = "ghcr.io/meysam81/parse-dmarc:latest"
+
+var templates = template.Must(
+	template.New("").
+		Funcs(template.FuncMap{
+			"quote":        strconv.Quote,
+			"promDuration": func(d time.Duration) string { return model.Duration(d).String() },
+			"seconds":      func(d time.Duration) int64 { return int64(d.Seconds()) },
+			"humanBytes":   humanBytes,
+		}).
+		ParseFS(templatesFS, "templates/*.tmpl", "templates/kubernetes/*.tmpl"),
+)
+
+// ComposeOptions holds the values rendered into docker-compose.yml
+type ComposeOptions struct {
+	Image          string
+	IMAPHost       string
+	IMAPPort       int
+	IMAPUsername   string
+	IMAPMailbox    string
+	IMAPUseTLS     bool
+	ServerPort     int
+	ConfigFile     string // relative config path to mount, if any
+	WithMonitoring bool   // include Prometheus and Grafana
+}
+
+// ConfigTarget is the path the config file is mounted at in the container.
+// The extension is kept so the format is still detected correctly.
+func (o ComposeOptions) ConfigTarget() string {
+	return "/app/config" + path.Ext(o.ConfigFile)
+}
+
+// DockerCompose writes a docker-compose.yml for the given options
+func DockerCompose(w io.Writer, opts ComposeOptions) error {
+	if opts.Image == "" {
+		opts.Image = DefaultImage
+	}
+	if err := templates.ExecuteTemplate(w, "docker-compose.yml.tmpl", opts); err != nil {
+		return fmt.Errorf("render docker-compose.yml: %w", err)
+	}
+	return nil
+}
+
+// AlertingRulesOptions holds the thresholds rendered into the alerting rules
+type AlertingRulesOptions struct {
+	MinCompliance           float64       // compliance rate in percent
+	ComplianceFor           time.Duration // how long compliance must stay low
+	NoReportsFor            time.Duration // window without new reports
+	MaxFetchErrorsPerMinute float64
+	FetchErrorsFor          time.Duration // how long fetch errors must persist
+	MaxDatabaseBytes        int64
+}
+
+// DefaultAlertingRulesOptions returns the thresholds used when none are given
+func DefaultAlertingRulesOptions() AlertingRulesOptions {
+	return AlertingRulesOptions{
+		MinCompliance:           90,
+		ComplianceFor:           15 * time.Minute,
+		NoReportsFor:            48 * time.Hour,
+		MaxFetchErrorsPerMinute: 0.1,
+		FetchErrorsFor:          5 * time.Minute,
+		MaxDatabaseBytes:        5 << 30,
+	}
+}
+
+// AlertingRules writes Prometheus alerting rules for the given options
+func AlertingRules(w io.Writer, opts AlertingRulesOptions) error {
+	if err := templates.ExecuteTemplate(w, "alerting-rules.yml.tmpl", opts); err != nil {
+		return fmt.Errorf("render alerting rules: %w", err)
+	}
+	return nil
+}
+
+// KubernetesOptions holds the values rendered into the Kubernetes manifests
+type KubernetesOptions struct {
+	Namespace    string
+	Image        string
+	Replicas     int
+	MaxReplicas  int    // upper bound for the HorizontalPodAutoscaler
+	StorageSize  string // size of the database PersistentVolumeClaim, e.g. 1Gi
+	IMAPHost     string
+	IMAPPort     int
+	IMAPUsername string
+	IMAPMailbox  string
+	IMAPUseTLS   bool
+	ServerPort   int
+}
+
+// Manifest is a single rendered Kubernetes manifest
+type Manifest struct {
+	Name    string // file name, e.g. deployment.yaml
+	Content []byte
+}
+
+// kubernetesManifests are the manifests rendered by KubernetesManifests, in
+// the order they should be applied
+var kubernetesManifests = []string{
+	"configmap.yaml",
+	"secret.yaml",
+	"pvc.yaml",
+	"deployment.yaml",
+	"service.yaml",
+	"servicemonitor.yaml",
+	"hpa.yaml",
+}
+
+// KubernetesManifests renders the manifests for a parse-dmarc deployment
+func KubernetesManifests(opts KubernetesOptions) ([]Manifest, error) {
+	if opts.Image == "" {
+		opts.Image = DefaultImage
+	}
+	if opts.Namespace == "" {
+		opts.Namespace = "default"
+	}
+	if opts.MaxReplicas < opts.Replicas {
+		opts.MaxReplicas = opts.Replicas
+	}
+
+	manifests := make([]Manifest, 0, len(kubernetesManifests))
+	for _, name := range kubernetesManifests {
+		var buf bytes.Buffer
+		if err := templates.ExecuteTemplate(&buf, name+".tmpl", opts); err != nil {
+			return nil, fmt.Errorf("render %s: %w", name, err)
+		}
+		manifests = append(manifests, Manifest{Name: name, Content: buf.Bytes()})
+	}
+	return manifests, nil
+}
+
+// humanBytes formats n with a binary unit, e.g. 5 GiB
+func humanBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return strconv.FormatFloat(float64(n)/float64(div), 'f', -1, 64) + " " + string("KMGTPE"[exp]) + "iB"
+}
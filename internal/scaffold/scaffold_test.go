@@ -0,0 +1,159 @@
+package scaffold
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestDockerCompose(t *testing.T) {
+	opts := ComposeOptions{
+		IMAPHost:   "imap.example.com",
+		IMAPPort:   993,
+		IMAPUseTLS: true,
+		ServerPort: 8080,
+		ConfigFile: "config.toml",
+	}
+
+	var buf bytes.Buffer
+	if err := DockerCompose(&buf, opts); err != nil {
+		t.Fatalf("Failed to render compose file: %v", err)
+	}
+	got := buf.String()
+
+	for _, want := range []string{
+		"image: " + DefaultImage,
+		`IMAP_HOST: "imap.example.com"`,
+		"./config.toml:/app/config.toml:ro",
+		`command: ["--config=/app/config.toml"]`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected output to contain %q", want)
+		}
+	}
+	if strings.Contains(got, "prometheus") {
+		t.Errorf("Expected no monitoring stack without WithMonitoring")
+	}
+
+	buf.Reset()
+	opts.WithMonitoring = true
+	if err := DockerCompose(&buf, opts); err != nil {
+		t.Fatalf("Failed to render compose file: %v", err)
+	}
+	if !strings.Contains(buf.String(), "grafana/grafana") {
+		t.Errorf("Expected monitoring stack with WithMonitoring")
+	}
+}
+
+// ruleGroups is the Prometheus rule file format, limited to the fields
+// AlertingRules writes
+type ruleGroups struct {
+	Groups []struct {
+		Name  string         `yaml:"name"`
+		Rules []alertingRule `yaml:"rules"`
+	} `yaml:"groups"`
+}
+
+type alertingRule struct {
+	Alert       string            `yaml:"alert"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for"`
+	Labels      map[string]string `yaml:"labels"`
+	Annotations map[string]string `yaml:"annotations"`
+}
+
+func TestAlertingRules(t *testing.T) {
+	opts := DefaultAlertingRulesOptions()
+	opts.MinCompliance = 85
+	opts.NoReportsFor = 72 * time.Hour
+
+	var buf bytes.Buffer
+	if err := AlertingRules(&buf, opts); err != nil {
+		t.Fatalf("Failed to render alerting rules: %v", err)
+	}
+
+	var groups ruleGroups
+	dec := yaml.NewDecoder(&buf)
+	dec.KnownFields(true)
+	if err := dec.Decode(&groups); err != nil {
+		t.Fatalf("Expected valid rules, got %v", err)
+	}
+	if len(groups.Groups) != 1 {
+		t.Fatalf("Expected 1 rule group, got %d", len(groups.Groups))
+	}
+
+	rules := map[string]alertingRule{}
+	for _, rule := range groups.Groups[0].Rules {
+		if rule.Alert == "" || rule.Expr == "" {
+			t.Errorf("Expected alert and expr in every rule, got %+v", rule)
+		}
+		rules[rule.Alert] = rule
+	}
+	for _, name := range []string{"DMARCComplianceLow", "DMARCNoNewReports", "DMARCFetchErrors", "DMARCDatabaseLarge"} {
+		if _, ok := rules[name]; !ok {
+			t.Errorf("Expected alert %s", name)
+		}
+	}
+	if expr := rules["DMARCComplianceLow"].Expr; !strings.HasSuffix(expr, "< 85") {
+		t.Errorf("Expected compliance threshold 85, got %q", expr)
+	}
+	if expr := rules["DMARCNoNewReports"].Expr; !strings.Contains(expr, "[3d]") {
+		t.Errorf("Expected 3d window, got %q", expr)
+	}
+	if expr := rules["DMARCDatabaseLarge"].Expr; !strings.HasSuffix(expr, "> 5368709120") {
+		t.Errorf("Expected 5 GiB threshold, got %q", expr)
+	}
+	if got := rules["DMARCComplianceLow"].Annotations["description"]; got != "Current compliance rate: {{ $value }}%" {
+		t.Errorf("Expected Prometheus template to be kept, got %q", got)
+	}
+}
+
+func TestKubernetesManifests(t *testing.T) {
+	manifests, err := KubernetesManifests(KubernetesOptions{
+		Namespace:   "monitoring",
+		Replicas:    2,
+		StorageSize: "5Gi",
+		IMAPHost:    "imap.example.com",
+		IMAPPort:    993,
+		ServerPort:  8080,
+	})
+	if err != nil {
+		t.Fatalf("Failed to render manifests: %v", err)
+	}
+
+	kinds := map[string]map[string]interface{}{}
+	for _, m := range manifests {
+		var doc map[string]interface{}
+		if err := yaml.Unmarshal(m.Content, &doc); err != nil {
+			t.Fatalf("Expected %s to be valid YAML, got %v\n%s", m.Name, err, m.Content)
+		}
+		kind, _ := doc["kind"].(string)
+		kinds[kind] = doc
+
+		metadata, _ := doc["metadata"].(map[string]interface{})
+		if metadata["namespace"] != "monitoring" {
+			t.Errorf("Expected %s in namespace monitoring, got %v", m.Name, metadata["namespace"])
+		}
+	}
+	for _, kind := range []string{"ConfigMap", "Secret", "PersistentVolumeClaim", "Deployment", "Service", "ServiceMonitor", "HorizontalPodAutoscaler"} {
+		if _, ok := kinds[kind]; !ok {
+			t.Errorf("Expected a %s manifest", kind)
+		}
+	}
+
+	spec, _ := kinds["Deployment"]["spec"].(map[string]interface{})
+	if spec["replicas"] != 2 {
+		t.Errorf("Expected 2 replicas, got %v", spec["replicas"])
+	}
+	hpa, _ := kinds["HorizontalPodAutoscaler"]["spec"].(map[string]interface{})
+	if hpa["maxReplicas"] != 2 {
+		t.Errorf("Expected maxReplicas to default to replicas, got %v", hpa["maxReplicas"])
+	}
+	data, _ := kinds["ConfigMap"]["data"].(map[string]interface{})
+	if data["IMAP_HOST"] != "imap.example.com" {
+		t.Errorf("Expected IMAP_HOST in the ConfigMap, got %v", data["IMAP_HOST"])
+	}
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// ThresholdMinComplianceRate is the threshold type for compliance rate alerts
+const ThresholdMinComplianceRate = "min_compliance_rate"
+
+// Alert represents a triggered threshold alert
+type Alert struct {
+	ID             int64   `json:"id"`
+	Domain         string  `json:"domain"`
+	ThresholdType  string  `json:"threshold_type"`
+	ThresholdValue float64 `json:"threshold_value"`
+	Value          float64 `json:"value"`
+	TriggeredAt    int64   `json:"triggered_at"`
+	ReportID       string  `json:"report_id"`
+	Acknowledged   bool    `json:"acknowledged"`
+}
+
+// AlertFilter narrows the alerts returned by GetAlerts.
+// Zero values disable the corresponding filter.
+type AlertFilter struct {
+	Since        int64
+	Domain       string
+	Acknowledged *bool
+}
+
+// SaveAlert stores a triggered alert. It returns false if an alert for the
+// same domain, threshold and report was already recorded.
+func (s *Storage) SaveAlert(alert *Alert) (bool, error) {
+	result, err := s.db.Exec(`
+		INSERT OR IGNORE INTO alerts (
+			domain, threshold_type, threshold_value,
+			value, triggered_at, report_id
+		) VALUES (?, ?, ?, ?, ?, ?)
+	`,
+		alert.Domain,
+		alert.ThresholdType,
+		alert.ThresholdValue,
+		alert.Value,
+		alert.TriggeredAt,
+		alert.ReportID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("insert alert: %w", wrapError(err))
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	alert.ID, err = result.LastInsertId()
+	if err != nil {
+		return false, fmt.Errorf("get last insert ID: %w", err)
+	}
+
+	return true, nil
+}
+
+// GetAlerts returns alerts matching the filter, newest first
+func (s *Storage) GetAlerts(filter AlertFilter) ([]Alert, error) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.Since > 0 {
+		conditions = append(conditions, "triggered_at >= ?")
+		args = append(args, filter.Since)
+	}
+	if filter.Domain != "" {
+		conditions = append(conditions, "domain = ?")
+		args = append(args, filter.Domain)
+	}
+	if filter.Acknowledged != nil {
+		conditions = append(conditions, "acknowledged = ?")
+		args = append(args, *filter.Acknowledged)
+	}
+
+	query := `
+		SELECT id, domain, threshold_type, threshold_value,
+		       value, triggered_at, report_id, acknowledged
+		FROM alerts`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY triggered_at DESC, id DESC"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query alerts: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	alerts := []Alert{}
+	for rows.Next() {
+		var a Alert
+		err := rows.Scan(
+			&a.ID, &a.Domain, &a.ThresholdType, &a.ThresholdValue,
+			&a.Value, &a.TriggeredAt, &a.ReportID, &a.Acknowledged,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan alert row: %w", err)
+		}
+		alerts = append(alerts, a)
+	}
+
+	return alerts, nil
+}
+
+// AcknowledgeAlert marks an alert as acknowledged.
+// It returns ErrNotFound if no alert with the given ID exists.
+func (s *Storage) AcknowledgeAlert(id int64) error {
+	result, err := s.db.Exec("UPDATE alerts SET acknowledged = 1 WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("acknowledge alert %d: %w", id, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("acknowledge alert %d: %w", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("acknowledge alert %d: %w", id, wrapError(sql.ErrNoRows))
+	}
+
+	return nil
+}
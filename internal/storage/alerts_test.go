@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestAlerts(t *testing.T) {
+	storage, err := NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	alert := &Alert{
+		Domain:         "example.com",
+		ThresholdType:  ThresholdMinComplianceRate,
+		ThresholdValue: 90,
+		Value:          50,
+		TriggeredAt:    1609459200,
+		ReportID:       "r1",
+	}
+
+	created, err := storage.SaveAlert(alert)
+	if err != nil {
+		t.Fatalf("Failed to save alert: %v", err)
+	}
+	if !created || alert.ID == 0 {
+		t.Fatalf("Expected alert to be created with an ID, got created=%v id=%d", created, alert.ID)
+	}
+
+	duplicate := *alert
+	created, err = storage.SaveAlert(&duplicate)
+	if err != nil {
+		t.Fatalf("Failed to save duplicate alert: %v", err)
+	}
+	if created {
+		t.Errorf("Expected duplicate alert to be ignored")
+	}
+
+	unacknowledged := false
+	alerts, err := storage.GetAlerts(AlertFilter{Domain: "example.com", Acknowledged: &unacknowledged})
+	if err != nil {
+		t.Fatalf("Failed to get alerts: %v", err)
+	}
+	if len(alerts) != 1 {
+		t.Fatalf("Expected 1 unacknowledged alert, got %d", len(alerts))
+	}
+
+	if err := storage.AcknowledgeAlert(alert.ID); err != nil {
+		t.Fatalf("Failed to acknowledge alert: %v", err)
+	}
+
+	alerts, err = storage.GetAlerts(AlertFilter{Acknowledged: &unacknowledged})
+	if err != nil {
+		t.Fatalf("Failed to get alerts: %v", err)
+	}
+	if len(alerts) != 0 {
+		t.Errorf("Expected 0 unacknowledged alerts, got %d", len(alerts))
+	}
+
+	if err := storage.AcknowledgeAlert(999); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected sql.ErrNoRows for unknown alert, got %v", err)
+	}
+}
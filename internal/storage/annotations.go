@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Annotation is a note left on a report, e.g. during incident response
+type Annotation struct {
+	ID        int64  `json:"id"`
+	ReportID  int64  `json:"report_id"`
+	Author    string `json:"author"`
+	Body      string `json:"body"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// AddAnnotation stores annotation on the report with row ID
+// annotation.ReportID and sets its ID and CreatedAt. It returns ErrNotFound
+// if the report does not exist.
+func (s *Storage) AddAnnotation(annotation *Annotation) error {
+	createdAt := time.Now().Unix()
+	result, err := s.db.Exec(`
+		INSERT INTO annotations (report_id, author, body, created_at)
+		SELECT id, ?, ?, ? FROM reports WHERE id = ?
+	`, annotation.Author, annotation.Body, createdAt, annotation.ReportID)
+	if err != nil {
+		return fmt.Errorf("insert annotation: %w", wrapError(err))
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("report %d: %w", annotation.ReportID, wrapError(sql.ErrNoRows))
+	}
+
+	annotation.ID, err = result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("get last insert ID: %w", err)
+	}
+	annotation.CreatedAt = createdAt
+	return nil
+}
+
+// GetAnnotations returns the notes on the report with row ID reportID,
+// oldest first. It returns ErrNotFound if the report does not exist.
+func (s *Storage) GetAnnotations(reportID int64) ([]Annotation, error) {
+	var exists bool
+	err := s.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM reports WHERE id = ?)`, reportID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("query report %d: %w", reportID, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("report %d: %w", reportID, wrapError(sql.ErrNoRows))
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, report_id, author, body, created_at
+		FROM annotations
+		WHERE report_id = ?
+		ORDER BY created_at, id
+	`, reportID)
+	if err != nil {
+		return nil, fmt.Errorf("query annotations: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	annotations := []Annotation{}
+	for rows.Next() {
+		var a Annotation
+		if err := rows.Scan(&a.ID, &a.ReportID, &a.Author, &a.Body, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan annotation: %w", err)
+		}
+		annotations = append(annotations, a)
+	}
+	return annotations, rows.Err()
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAnnotations(t *testing.T) {
+	storage, err := NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	saveTestReport(t, storage, testReportXML("report-1", "example.com", 1609459200, 1609545600, "none"))
+
+	annotations, err := storage.GetAnnotations(1)
+	if err != nil {
+		t.Fatalf("Failed to get annotations: %v", err)
+	}
+	if len(annotations) != 0 {
+		t.Errorf("Expected no annotations, got %d", len(annotations))
+	}
+
+	for _, body := range []string{"Confirmed this is our AWS SES sending IP", "SPF include added"} {
+		annotation := &Annotation{ReportID: 1, Author: "alice", Body: body}
+		if err := storage.AddAnnotation(annotation); err != nil {
+			t.Fatalf("Failed to add annotation: %v", err)
+		}
+		if annotation.ID == 0 || annotation.CreatedAt == 0 {
+			t.Errorf("Expected ID and CreatedAt to be set, got %+v", annotation)
+		}
+	}
+
+	annotations, err = storage.GetAnnotations(1)
+	if err != nil {
+		t.Fatalf("Failed to get annotations: %v", err)
+	}
+	if len(annotations) != 2 {
+		t.Fatalf("Expected 2 annotations, got %d", len(annotations))
+	}
+	if annotations[0].Body != "Confirmed this is our AWS SES sending IP" || annotations[1].Body != "SPF include added" {
+		t.Errorf("Expected annotations in insertion order, got %+v", annotations)
+	}
+	if annotations[0].Author != "alice" || annotations[0].ReportID != 1 {
+		t.Errorf("Expected author alice on report 1, got %+v", annotations[0])
+	}
+
+	if err := storage.AddAnnotation(&Annotation{ReportID: 999, Author: "alice", Body: "x"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("AddAnnotation: expected ErrNotFound for a missing report, got %v", err)
+	}
+	if _, err := storage.GetAnnotations(999); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetAnnotations: expected ErrNotFound for a missing report, got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Audit log operations
+const (
+	AuditOpSave    = "save"
+	AuditOpReplay  = "replay"
+	AuditOpDelete  = "delete"
+	AuditOpRestore = "restore"
+)
+
+// ActorSystem is the audit actor for writes made outside any request,
+// such as the fetch loop
+const ActorSystem = "system"
+
+// actorKey is the context key for the audit actor
+type actorKey struct{}
+
+// WithActor returns a context that attributes storage writes to actor
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the audit actor stored in ctx, or ActorSystem
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return ActorSystem
+}
+
+// AuditEntry is a single row of the audit log
+type AuditEntry struct {
+	ID        int64  `json:"id"`
+	Operation string `json:"operation"`
+	ReportID  string `json:"report_id"`
+	Actor     string `json:"actor"`
+	Timestamp int64  `json:"timestamp"`
+	Details   string `json:"details,omitempty"`
+}
+
+// AuditFilter narrows the entries returned by GetAuditLog.
+// Zero values disable the corresponding filter.
+type AuditFilter struct {
+	From int64
+	To   int64
+}
+
+// appendAudit records a write operation within tx
+func appendAudit(ctx context.Context, tx *sql.Tx, operation, reportID, details string) error {
+	_, err := tx.Exec(`
+		INSERT INTO audit_log (operation, report_id, actor, timestamp, details)
+		VALUES (?, ?, ?, ?, ?)
+	`, operation, reportID, ActorFromContext(ctx), time.Now().Unix(), details)
+	if err != nil {
+		return fmt.Errorf("insert audit entry: %w", wrapError(err))
+	}
+	return nil
+}
+
+// GetAuditLog returns audit entries matching the filter, newest first
+func (s *Storage) GetAuditLog(filter AuditFilter, limit int) ([]AuditEntry, error) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.From > 0 {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, filter.From)
+	}
+	if filter.To > 0 {
+		conditions = append(conditions, "timestamp <= ?")
+		args = append(args, filter.To)
+	}
+
+	query := `
+		SELECT id, operation, report_id, actor, timestamp, COALESCE(details, '')
+		FROM audit_log`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY timestamp DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query audit log: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	entries := []AuditEntry{}
+	for rows.Next() {
+		var e AuditEntry
+		if err := rows.Scan(&e.ID, &e.Operation, &e.ReportID, &e.Actor, &e.Timestamp, &e.Details); err != nil {
+			return nil, fmt.Errorf("scan audit row: %w", err)
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	storage, err := NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	saveTestReport(t, storage, testReportXML("r1", "example.com", 1609459200, 1609545600, "none"))
+
+	feedback := parseTestReport(t, testReportXML("r2", "example.com", 1609545600, 1609632000, "none"))
+	if err := storage.SaveReportContext(WithActor(context.Background(), "api-key-1"), feedback); err != nil {
+		t.Fatalf("Failed to save report: %v", err)
+	}
+
+	// Duplicate saves are ignored and must not be audited
+	saveTestReport(t, storage, testReportXML("r1", "example.com", 1609459200, 1609545600, "none"))
+
+	entries, err := storage.GetAuditLog(AuditFilter{}, 10)
+	if err != nil {
+		t.Fatalf("Failed to get audit log: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 audit entries, got %d", len(entries))
+	}
+
+	actors := map[string]string{}
+	for _, e := range entries {
+		if e.Operation != AuditOpSave {
+			t.Errorf("Expected operation %s, got %s", AuditOpSave, e.Operation)
+		}
+		actors[e.ReportID] = e.Actor
+	}
+	if actors["r1"] != ActorSystem {
+		t.Errorf("Expected r1 actor %s, got %s", ActorSystem, actors["r1"])
+	}
+	if actors["r2"] != "api-key-1" {
+		t.Errorf("Expected r2 actor api-key-1, got %s", actors["r2"])
+	}
+
+	entries, err = storage.GetAuditLog(AuditFilter{From: entries[0].Timestamp + 1}, 10)
+	if err != nil {
+		t.Fatalf("Failed to get audit log: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected no entries after from, got %d", len(entries))
+	}
+}
@@ -1,8 +1,14 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/goccy/go-json"
@@ -11,7 +17,21 @@ import (
 )
 
 type Storage struct {
-	db *sql.DB
+	db   *sql.DB
+	path string
+	geo  GeoLookup
+	// dedupContent rejects reports that repeat a stored report's content
+	// under a new report_id
+	dedupContent bool
+
+	// writeMu serializes report writes; SQLite allows a single writer and
+	// concurrent write transactions would fail with SQLITE_BUSY
+	writeMu sync.Mutex
+}
+
+// GeoLookup resolves geolocation details for a source IP
+type GeoLookup interface {
+	LookupIP(ip string) (country string, asn int, asnOrg string)
 }
 
 type ReportSummary struct {
@@ -25,6 +45,12 @@ type ReportSummary struct {
 	CompliantMessages int     `json:"compliant_messages"`
 	ComplianceRate    float64 `json:"compliance_rate"`
 	PolicyP           string  `json:"policy_p"`
+	// Source is the ingestion path that stored the report, see WithSource.
+	// It is empty for reports stored before sources were recorded.
+	Source string `json:"source"`
+	// DeletedAt is when the report was moved to the trash, see
+	// SoftDeleteReport, as a Unix timestamp
+	DeletedAt int64 `json:"deleted_at,omitempty"`
 }
 
 type Statistics struct {
@@ -35,6 +61,8 @@ type Statistics struct {
 	UniqueSourceIPs   int     `json:"unique_source_ips"`
 	UniqueDomains     int     `json:"unique_domains"`
 	HasData           bool    `json:"has_data"`
+	// DailyTrend is only filled in on request, see GetComplianceTrend
+	DailyTrend []TrendPoint `json:"daily_trend,omitempty"`
 }
 
 type TopSourceIP struct {
@@ -42,28 +70,100 @@ type TopSourceIP struct {
 	Count    int    `json:"count"`
 	Pass     int    `json:"pass"`
 	Fail     int    `json:"fail"`
+	Country  string `json:"country,omitempty"`
+	ASN      int    `json:"asn,omitempty"`
+	ASNOrg   string `json:"asn_org,omitempty"`
+}
+
+// SetGeoLookup enables geolocation enrichment of source IP results
+func (s *Storage) SetGeoLookup(geo GeoLookup) {
+	s.geo = geo
+}
+
+// SetDeduplicateByContent makes SaveReport reject a report with
+// ErrDuplicateContent when a report with a different report_id but the same
+// domain, org_name, date_begin and date_end is already stored. This catches
+// reporters that assign a random report_id to every copy of a report.
+func (s *Storage) SetDeduplicateByContent(enabled bool) {
+	s.dedupContent = enabled
+}
+
+// Ingestion paths recorded in the reports.source column
+const (
+	SourceIMAP = "imap"
+	SourceHTTP = "http"
+)
+
+// sourceKey is the context key for the ingestion source
+type sourceKey struct{}
+
+// WithSource returns a context that records source as the ingestion path of
+// reports saved with it
+func WithSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, sourceKey{}, source)
+}
+
+// SourceFromContext returns the ingestion source stored in ctx, or ""
+func SourceFromContext(ctx context.Context) string {
+	source, _ := ctx.Value(sourceKey{}).(string)
+	return source
 }
 
+// SaveReport stores a report, attributing the write to ActorSystem
 func (s *Storage) SaveReport(feedback *parser.Feedback) error {
+	return s.SaveReportContext(context.Background(), feedback)
+}
+
+// SaveReportContext stores a report and records it in the audit log under
+// the actor carried by ctx, tagged with the source set by WithSource.
+// Reports that already exist are ignored.
+func (s *Storage) SaveReportContext(ctx context.Context, feedback *parser.Feedback) error {
 	rawReport, err := json.Marshal(feedback)
 	if err != nil {
 		return fmt.Errorf("failed to marshal report: %w", err)
 	}
 
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
 	tx, err := s.db.Begin()
 	if err != nil {
 		return fmt.Errorf("begin transaction: %w", err)
 	}
 	defer func() { _ = tx.Rollback() }()
 
+	// A report already stored under this report_id is left to INSERT OR
+	// IGNORE, so re-fetching it stays silent
+	if s.dedupContent {
+		var existing string
+		err := tx.QueryRow(`
+			SELECT report_id FROM reports
+			WHERE domain = ? AND org_name = ? AND date_begin = ? AND date_end = ?
+			  AND NOT EXISTS (SELECT 1 FROM reports WHERE report_id = ?)
+			LIMIT 1
+		`,
+			feedback.PolicyPublished.Domain,
+			feedback.ReportMetadata.OrgName,
+			feedback.ReportMetadata.DateRange.Begin,
+			feedback.ReportMetadata.DateRange.End,
+			feedback.ReportMetadata.ReportID,
+		).Scan(&existing)
+		if err == nil {
+			return fmt.Errorf("%w: same as report %s", ErrDuplicateContent, existing)
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("query duplicate report: %w", err)
+		}
+	}
+
 	result, err := tx.Exec(`
 		INSERT OR IGNORE INTO reports (
 			report_id, org_name, email, domain,
 			date_begin, date_end, created_at,
 			policy_p, policy_sp, policy_pct,
 			total_messages, compliant_messages,
-			raw_report
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			raw_report, source
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`,
 		feedback.ReportMetadata.ReportID,
 		feedback.ReportMetadata.OrgName,
@@ -78,10 +178,11 @@ func (s *Storage) SaveReport(feedback *parser.Feedback) error {
 		feedback.GetTotalMessages(),
 		feedback.GetDMARCCompliantCount(),
 		rawReport,
+		SourceFromContext(ctx),
 	)
 
 	if err != nil {
-		return fmt.Errorf("failed to insert report: %w", err)
+		return fmt.Errorf("failed to insert report: %w", wrapError(err))
 	}
 
 	reportID, err := result.LastInsertId()
@@ -94,17 +195,46 @@ func (s *Storage) SaveReport(feedback *parser.Feedback) error {
 		return nil
 	}
 
-	for _, record := range feedback.Records {
+	if err := insertRecords(tx, reportID, feedback.Records); err != nil {
+		return err
+	}
+
+	details, _ := json.Marshal(map[string]interface{}{
+		"domain":   feedback.PolicyPublished.Domain,
+		"org_name": feedback.ReportMetadata.OrgName,
+		"messages": feedback.GetTotalMessages(),
+	})
+	if err := appendAudit(ctx, tx, AuditOpSave, feedback.ReportMetadata.ReportID, string(details)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// insertRecords stores the records of the report with row ID reportID
+func insertRecords(tx *sql.Tx, reportID int64, records []parser.Record) error {
+	for _, record := range records {
 		dkimDomains, _ := json.Marshal(record.AuthResults.DKIM)
 		spfDomains, _ := json.Marshal(record.AuthResults.SPF)
+		// NULL unless the reporter overrode the policy, so overrides can be
+		// found without parsing every record
+		var overrideReasons interface{}
+		if reasons := record.Row.PolicyEvaluated.Reason; len(reasons) > 0 {
+			data, _ := json.Marshal(reasons)
+			overrideReasons = string(data)
+		}
 
 		_, err := tx.Exec(`
 			INSERT INTO records (
 				report_id, source_ip, count,
 				disposition, dkim_result, spf_result,
-				header_from, envelope_from,
-				dkim_domains, spf_domains
-			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+				header_from, envelope_from, envelope_to,
+				dkim_domains, spf_domains, override_reasons
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		`,
 			reportID,
 			record.Row.SourceIP,
@@ -114,46 +244,217 @@ func (s *Storage) SaveReport(feedback *parser.Feedback) error {
 			record.Row.PolicyEvaluated.SPF,
 			record.Identifiers.HeaderFrom,
 			record.Identifiers.EnvelopeFrom,
+			record.Identifiers.EnvelopeTo,
 			dkimDomains,
 			spfDomains,
+			overrideReasons,
 		)
 
 		if err != nil {
-			return fmt.Errorf("failed to insert record: %w", err)
+			return fmt.Errorf("failed to insert record: %w", wrapError(err))
 		}
 	}
+	return nil
+}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("commit transaction: %w", err)
+// liveRecords restricts a query on records to those of reports that are
+// not in the trash
+const liveRecords = "report_id IN (SELECT id FROM reports WHERE deleted_at IS NULL)"
+
+// ReportFilter narrows the reports returned by GetReports and CountReports.
+// Zero values disable the corresponding filter.
+type ReportFilter struct {
+	Domain string
+	// ReportID matches the reporter-assigned report ID
+	ReportID string
+	// From is the earliest date_begin, as a Unix timestamp
+	From int64
+	// To is the latest date_end, as a Unix timestamp
+	To int64
+}
+
+// where builds the SQL WHERE clause and arguments for the filter. Reports
+// in the trash are always excluded.
+func (f ReportFilter) where() (string, []interface{}) {
+	conditions := []string{"deleted_at IS NULL"}
+	var args []interface{}
+
+	if f.Domain != "" {
+		conditions = append(conditions, "domain = ?")
+		args = append(args, f.Domain)
+	}
+	if f.ReportID != "" {
+		conditions = append(conditions, "report_id = ?")
+		args = append(args, f.ReportID)
+	}
+	if f.From > 0 {
+		conditions = append(conditions, "date_begin >= ?")
+		args = append(args, f.From)
+	}
+	if f.To > 0 {
+		conditions = append(conditions, "date_end <= ?")
+		args = append(args, f.To)
 	}
 
-	return nil
+	return "WHERE " + strings.Join(conditions, " AND "), args
 }
 
-func (s *Storage) GetReports(limit, offset int) ([]ReportSummary, error) {
+func (s *Storage) GetReports(filter ReportFilter, limit, offset int) ([]ReportSummary, error) {
+	where, args := filter.where()
 	rows, err := s.db.Query(`
 		SELECT id, report_id, org_name, domain,
 		       date_begin, date_end,
 		       total_messages, compliant_messages,
-		       policy_p
+		       policy_p, COALESCE(source, ''), COALESCE(deleted_at, 0)
 		FROM reports
+		`+where+`
 		ORDER BY date_begin DESC
 		LIMIT ? OFFSET ?
-	`, limit, offset)
+	`, append(args, limit, offset)...)
 
 	if err != nil {
 		return nil, fmt.Errorf("query reports: %w", err)
 	}
 	defer func() { _ = rows.Close() }()
 
-	var reports []ReportSummary
+	return scanReportSummaries(rows)
+}
+
+// GetReportsByDomain returns the reports for domain, compared
+// case-insensitively, newest first
+func (s *Storage) GetReportsByDomain(domain string, limit, offset int) ([]ReportSummary, error) {
+	rows, err := s.db.Query(`
+		SELECT id, report_id, org_name, domain,
+		       date_begin, date_end,
+		       total_messages, compliant_messages,
+		       policy_p, COALESCE(source, ''), COALESCE(deleted_at, 0)
+		FROM reports
+		WHERE LOWER(domain) = LOWER(?) AND deleted_at IS NULL
+		ORDER BY date_begin DESC
+		LIMIT ? OFFSET ?
+	`, domain, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("query reports for domain %s: %w", domain, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	return scanReportSummaries(rows)
+}
+
+// CountReportsByDomain returns the number of reports for domain, compared
+// case-insensitively
+func (s *Storage) CountReportsByDomain(domain string) (int, error) {
+	var count int
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM reports
+		WHERE LOWER(domain) = LOWER(?) AND deleted_at IS NULL
+	`, domain).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count reports for domain %s: %w", domain, err)
+	}
+	return count, nil
+}
+
+// ListDomains returns every domain with reports, lowercased and sorted
+func (s *Storage) ListDomains() ([]string, error) {
+	rows, err := s.db.Query(`
+		SELECT DISTINCT LOWER(domain) AS domain
+		FROM reports
+		WHERE deleted_at IS NULL
+		ORDER BY domain
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query domains: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	domains := []string{}
+	for rows.Next() {
+		var domain string
+		if err := rows.Scan(&domain); err != nil {
+			return nil, fmt.Errorf("scan domain row: %w", err)
+		}
+		domains = append(domains, domain)
+	}
+	return domains, rows.Err()
+}
+
+// GetReportsByDateRange returns reports whose date range lies within from
+// and to (Unix timestamps), newest first. A zero bound is not applied.
+func (s *Storage) GetReportsByDateRange(from, to int64, limit, offset int) ([]ReportSummary, error) {
+	return s.GetReports(ReportFilter{From: from, To: to}, limit, offset)
+}
+
+// GetReportsSince returns reports stored at or after t, newest first
+func (s *Storage) GetReportsSince(t time.Time) ([]ReportSummary, error) {
+	rows, err := s.db.Query(`
+		SELECT id, report_id, org_name, domain,
+		       date_begin, date_end,
+		       total_messages, compliant_messages,
+		       policy_p, COALESCE(source, ''), COALESCE(deleted_at, 0)
+		FROM reports
+		WHERE created_at >= ? AND deleted_at IS NULL
+		ORDER BY created_at DESC
+	`, t.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("query reports since %s: %w", t.Format(time.RFC3339), err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	return scanReportSummaries(rows)
+}
+
+// ListReportIDs returns the report_id of up to limit reports, skipping the
+// first offset, in the order they were stored
+func (s *Storage) ListReportIDs(limit, offset int) ([]string, error) {
+	rows, err := s.db.Query(`
+		SELECT report_id FROM reports WHERE deleted_at IS NULL ORDER BY id LIMIT ? OFFSET ?
+	`, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("query report IDs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	return scanReportIDs(rows)
+}
+
+// ListReportIDsSince returns the report_id of up to limit reports stored at
+// or after t, in the order they were stored
+func (s *Storage) ListReportIDsSince(t time.Time, limit int) ([]string, error) {
+	rows, err := s.db.Query(`
+		SELECT report_id FROM reports WHERE created_at >= ? AND deleted_at IS NULL ORDER BY id LIMIT ?
+	`, t.Unix(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("query report IDs since %s: %w", t.Format(time.RFC3339), err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	return scanReportIDs(rows)
+}
+
+// scanReportIDs reads single-column report_id rows
+func scanReportIDs(rows *sql.Rows) ([]string, error) {
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan report ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// scanReportSummaries reads report summary rows and computes compliance rates
+func scanReportSummaries(rows *sql.Rows) ([]ReportSummary, error) {
+	reports := []ReportSummary{}
 	for rows.Next() {
 		var r ReportSummary
 		err := rows.Scan(
 			&r.ID, &r.ReportID, &r.OrgName, &r.Domain,
 			&r.DateBegin, &r.DateEnd,
 			&r.TotalMessages, &r.CompliantMessages,
-			&r.PolicyP,
+			&r.PolicyP, &r.Source, &r.DeletedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("scan report row: %w", err)
@@ -169,11 +470,21 @@ func (s *Storage) GetReports(limit, offset int) ([]ReportSummary, error) {
 	return reports, nil
 }
 
+// CountReports returns the number of reports matching the filter
+func (s *Storage) CountReports(filter ReportFilter) (int, error) {
+	where, args := filter.where()
+	var count int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM reports "+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count reports: %w", err)
+	}
+	return count, nil
+}
+
 func (s *Storage) GetReportByID(id int64) (*parser.Feedback, error) {
 	var rawReport string
-	err := s.db.QueryRow("SELECT raw_report FROM reports WHERE id = ?", id).Scan(&rawReport)
+	err := s.db.QueryRow("SELECT raw_report FROM reports WHERE id = ? AND deleted_at IS NULL", id).Scan(&rawReport)
 	if err != nil {
-		return nil, fmt.Errorf("query report %d: %w", id, err)
+		return nil, fmt.Errorf("query report %d: %w", id, wrapError(err))
 	}
 
 	var feedback parser.Feedback
@@ -184,6 +495,23 @@ func (s *Storage) GetReportByID(id int64) (*parser.Feedback, error) {
 	return &feedback, nil
 }
 
+// GetReportByExternalID returns the report with the reporter-assigned
+// report_id, as opposed to the row ID used by GetReportByID
+func (s *Storage) GetReportByExternalID(reportID string) (*parser.Feedback, error) {
+	var rawReport string
+	err := s.db.QueryRow("SELECT raw_report FROM reports WHERE report_id = ? AND deleted_at IS NULL", reportID).Scan(&rawReport)
+	if err != nil {
+		return nil, fmt.Errorf("query report %q: %w", reportID, wrapError(err))
+	}
+
+	var feedback parser.Feedback
+	if err := json.Unmarshal([]byte(rawReport), &feedback); err != nil {
+		return nil, fmt.Errorf("unmarshal report %q: %w", reportID, err)
+	}
+
+	return &feedback, nil
+}
+
 func (s *Storage) GetStatistics() (*Statistics, error) {
 	var stats Statistics
 
@@ -193,6 +521,7 @@ func (s *Storage) GetStatistics() (*Statistics, error) {
 			COALESCE(SUM(total_messages), 0) as total_messages,
 			COALESCE(SUM(compliant_messages), 0) as compliant_messages
 		FROM reports
+		WHERE deleted_at IS NULL
 	`).Scan(&stats.TotalReports, &stats.TotalMessages, &stats.CompliantMessages)
 
 	if err != nil {
@@ -205,12 +534,12 @@ func (s *Storage) GetStatistics() (*Statistics, error) {
 		stats.ComplianceRate = float64(stats.CompliantMessages) / float64(stats.TotalMessages) * 100
 	}
 
-	err = s.db.QueryRow("SELECT COUNT(DISTINCT source_ip) FROM records").Scan(&stats.UniqueSourceIPs)
+	err = s.db.QueryRow("SELECT COUNT(DISTINCT source_ip) FROM records WHERE " + liveRecords).Scan(&stats.UniqueSourceIPs)
 	if err != nil {
 		return nil, fmt.Errorf("query unique source IPs: %w", err)
 	}
 
-	err = s.db.QueryRow("SELECT COUNT(DISTINCT domain) FROM reports").Scan(&stats.UniqueDomains)
+	err = s.db.QueryRow("SELECT COUNT(DISTINCT domain) FROM reports WHERE deleted_at IS NULL").Scan(&stats.UniqueDomains)
 	if err != nil {
 		return nil, fmt.Errorf("query unique domains: %w", err)
 	}
@@ -218,6 +547,97 @@ func (s *Storage) GetStatistics() (*Statistics, error) {
 	return &stats, nil
 }
 
+// ExtendedStatistics adds report-level distribution, recency and domain
+// ranking to Statistics
+type ExtendedStatistics struct {
+	Statistics
+	// AverageComplianceRate and MedianComplianceRate are taken over the
+	// compliance rates of individual reports, unlike ComplianceRate, which
+	// weights every message equally
+	AverageComplianceRate float64 `json:"average_compliance_rate"`
+	MedianComplianceRate  float64 `json:"median_compliance_rate"`
+	// ReportsLast24h, ReportsLast7d and ReportsLast30d count reports by the
+	// time they were stored
+	ReportsLast24h int `json:"reports_last_24h"`
+	ReportsLast7d  int `json:"reports_last_7d"`
+	ReportsLast30d int `json:"reports_last_30d"`
+	// MostRecentReportTime is the latest date_end and OldestReportTime the
+	// earliest date_begin of any report, as Unix timestamps
+	MostRecentReportTime int64 `json:"most_recent_report_time"`
+	OldestReportTime     int64 `json:"oldest_report_time"`
+	// TopDomain and BottomDomain have the highest and lowest compliance
+	// rate; ties go to the domain with more messages
+	TopDomain    string `json:"top_domain"`
+	BottomDomain string `json:"bottom_domain"`
+}
+
+// GetExtendedStatistics returns Statistics together with the extended
+// fields, computed in a single query
+func (s *Storage) GetExtendedStatistics() (*ExtendedStatistics, error) {
+	var stats ExtendedStatistics
+	now := time.Now()
+
+	err := s.db.QueryRow(`
+		WITH live_reports AS (
+			SELECT * FROM reports WHERE deleted_at IS NULL
+		),
+		report_rates AS (
+			SELECT compliant_messages * 100.0 / total_messages AS rate
+			FROM live_reports
+			WHERE total_messages > 0
+		),
+		ranked AS (
+			SELECT rate,
+			       ROW_NUMBER() OVER (ORDER BY rate) AS pos,
+			       COUNT(*) OVER () AS n
+			FROM report_rates
+		),
+		domain_rates AS (
+			SELECT domain,
+			       SUM(compliant_messages) * 100.0 / SUM(total_messages) AS rate,
+			       SUM(total_messages) AS messages
+			FROM live_reports
+			GROUP BY domain
+			HAVING SUM(total_messages) > 0
+		)
+		SELECT
+			(SELECT COUNT(*) FROM live_reports),
+			(SELECT COALESCE(SUM(total_messages), 0) FROM live_reports),
+			(SELECT COALESCE(SUM(compliant_messages), 0) FROM live_reports),
+			(SELECT COUNT(DISTINCT source_ip) FROM records WHERE `+liveRecords+`),
+			(SELECT COUNT(DISTINCT domain) FROM live_reports),
+			(SELECT COALESCE(AVG(rate), 0) FROM ranked),
+			(SELECT COALESCE(AVG(rate), 0) FROM ranked WHERE pos IN ((n + 1) / 2, (n + 2) / 2)),
+			(SELECT COUNT(*) FROM live_reports WHERE created_at >= ?),
+			(SELECT COUNT(*) FROM live_reports WHERE created_at >= ?),
+			(SELECT COUNT(*) FROM live_reports WHERE created_at >= ?),
+			(SELECT COALESCE(MAX(date_end), 0) FROM live_reports),
+			(SELECT COALESCE(MIN(date_begin), 0) FROM live_reports),
+			COALESCE((SELECT domain FROM domain_rates ORDER BY rate DESC, messages DESC, domain LIMIT 1), ''),
+			COALESCE((SELECT domain FROM domain_rates ORDER BY rate ASC, messages DESC, domain LIMIT 1), '')
+	`,
+		now.Add(-24*time.Hour).Unix(),
+		now.AddDate(0, 0, -7).Unix(),
+		now.AddDate(0, 0, -30).Unix(),
+	).Scan(
+		&stats.TotalReports, &stats.TotalMessages, &stats.CompliantMessages,
+		&stats.UniqueSourceIPs, &stats.UniqueDomains,
+		&stats.AverageComplianceRate, &stats.MedianComplianceRate,
+		&stats.ReportsLast24h, &stats.ReportsLast7d, &stats.ReportsLast30d,
+		&stats.MostRecentReportTime, &stats.OldestReportTime,
+		&stats.TopDomain, &stats.BottomDomain,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query extended statistics: %w", err)
+	}
+
+	stats.HasData = stats.TotalReports > 0
+	if stats.TotalMessages > 0 {
+		stats.ComplianceRate = float64(stats.CompliantMessages) / float64(stats.TotalMessages) * 100
+	}
+	return &stats, nil
+}
+
 func (s *Storage) GetTopSourceIPs(limit int) ([]TopSourceIP, error) {
 	rows, err := s.db.Query(`
 		SELECT
@@ -226,6 +646,7 @@ func (s *Storage) GetTopSourceIPs(limit int) ([]TopSourceIP, error) {
 			SUM(CASE WHEN (dkim_result = 'pass' OR spf_result = 'pass') THEN count ELSE 0 END) as pass_count,
 			SUM(CASE WHEN (dkim_result != 'pass' AND spf_result != 'pass') THEN count ELSE 0 END) as fail_count
 		FROM records
+		WHERE `+liveRecords+`
 		GROUP BY source_ip
 		ORDER BY total_count DESC
 		LIMIT ?
@@ -242,16 +663,366 @@ func (s *Storage) GetTopSourceIPs(limit int) ([]TopSourceIP, error) {
 		if err := rows.Scan(&r.SourceIP, &r.Count, &r.Pass, &r.Fail); err != nil {
 			return nil, fmt.Errorf("scan source IP row: %w", err)
 		}
+		if s.geo != nil {
+			r.Country, r.ASN, r.ASNOrg = s.geo.LookupIP(r.SourceIP)
+		}
 		results = append(results, r)
 	}
 
 	return results, nil
 }
 
+// SourceIPStats breaks down the authentication results of one source IP.
+// DKIM and SPF counts use the policy-evaluated (aligned) results, so
+// DMARCCompliantCount is the number of messages passing aligned DKIM or
+// aligned SPF.
+type SourceIPStats struct {
+	SourceIP            string         `json:"source_ip"`
+	TotalMessages       int            `json:"total_messages"`
+	DKIMPassCount       int            `json:"dkim_pass_count"`
+	SPFPassCount        int            `json:"spf_pass_count"`
+	BothPassCount       int            `json:"both_pass_count"`
+	FullFailCount       int            `json:"full_fail_count"`
+	DMARCCompliantCount int            `json:"dmarc_compliant_count"`
+	DispositionCounts   map[string]int `json:"disposition_counts"`
+	Domains             []string       `json:"domains"`
+	Organizations       []string       `json:"organizations"`
+}
+
+// GetSourceIPStats returns the authentication breakdown for ip. The error
+// wraps ErrNotFound if no records exist for ip.
+func (s *Storage) GetSourceIPStats(ip string) (*SourceIPStats, error) {
+	stats := SourceIPStats{
+		SourceIP:          ip,
+		DispositionCounts: map[string]int{},
+		Domains:           []string{},
+		Organizations:     []string{},
+	}
+
+	err := s.db.QueryRow(`
+		SELECT
+			COALESCE(SUM(count), 0),
+			COALESCE(SUM(CASE WHEN dkim_result = 'pass' THEN count ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN spf_result = 'pass' THEN count ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN dkim_result = 'pass' AND spf_result = 'pass' THEN count ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN dkim_result != 'pass' AND spf_result != 'pass' THEN count ELSE 0 END), 0)
+		FROM records
+		WHERE source_ip = ? AND `+liveRecords+`
+	`, ip).Scan(&stats.TotalMessages, &stats.DKIMPassCount, &stats.SPFPassCount, &stats.BothPassCount, &stats.FullFailCount)
+	if err != nil {
+		return nil, fmt.Errorf("query stats for source IP %s: %w", ip, err)
+	}
+	if stats.TotalMessages == 0 {
+		return nil, fmt.Errorf("query stats for source IP %s: %w", ip, wrapError(sql.ErrNoRows))
+	}
+	stats.DMARCCompliantCount = stats.DKIMPassCount + stats.SPFPassCount - stats.BothPassCount
+
+	rows, err := s.db.Query(`
+		SELECT COALESCE(disposition, 'unknown'), SUM(count)
+		FROM records
+		WHERE source_ip = ? AND `+liveRecords+`
+		GROUP BY disposition
+	`, ip)
+	if err != nil {
+		return nil, fmt.Errorf("query dispositions for source IP %s: %w", ip, err)
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var disposition string
+		var count int
+		if err := rows.Scan(&disposition, &count); err != nil {
+			return nil, fmt.Errorf("scan disposition row: %w", err)
+		}
+		stats.DispositionCounts[disposition] = count
+	}
+
+	rows, err = s.db.Query(`
+		SELECT DISTINCT r.domain, r.org_name
+		FROM records rec
+		JOIN reports r ON r.id = rec.report_id
+		WHERE rec.source_ip = ? AND r.deleted_at IS NULL
+		ORDER BY r.domain, r.org_name
+	`, ip)
+	if err != nil {
+		return nil, fmt.Errorf("query domains for source IP %s: %w", ip, err)
+	}
+	defer func() { _ = rows.Close() }()
+	domains := map[string]bool{}
+	orgs := map[string]bool{}
+	for rows.Next() {
+		var domain, org string
+		if err := rows.Scan(&domain, &org); err != nil {
+			return nil, fmt.Errorf("scan domain row: %w", err)
+		}
+		if !domains[domain] {
+			domains[domain] = true
+			stats.Domains = append(stats.Domains, domain)
+		}
+		if !orgs[org] {
+			orgs[org] = true
+			stats.Organizations = append(stats.Organizations, org)
+		}
+	}
+	sort.Strings(stats.Organizations)
+
+	return &stats, nil
+}
+
+// FailingRecord is a record that was not delivered as-is or failed both DKIM
+// and SPF
+type FailingRecord struct {
+	SourceIP      string `json:"source_ip"`
+	HeaderFrom    string `json:"header_from"`
+	Disposition   string `json:"disposition"`
+	DKIMResult    string `json:"dkim_result"`
+	SPFResult     string `json:"spf_result"`
+	Count         int    `json:"count"`
+	ReportDomain  string `json:"report_domain"`
+	ReportOrgName string `json:"report_org_name"`
+	DateBegin     int64  `json:"date_begin"`
+	// OverrideReasons explain why the disposition differs from the policy
+	OverrideReasons []parser.Reason `json:"override_reasons"`
+}
+
+// GetFailingRecords returns the records with a non-none disposition or
+// failing both DKIM and SPF, largest message count first
+func (s *Storage) GetFailingRecords(limit int) ([]FailingRecord, error) {
+	rows, err := s.db.Query(`
+		SELECT
+			rec.source_ip,
+			COALESCE(rec.header_from, ''),
+			COALESCE(rec.disposition, ''),
+			COALESCE(rec.dkim_result, ''),
+			COALESCE(rec.spf_result, ''),
+			rec.count,
+			r.domain, r.org_name, r.date_begin,
+			COALESCE(rec.override_reasons, '[]')
+		FROM records rec
+		JOIN reports r ON r.id = rec.report_id
+		WHERE r.deleted_at IS NULL
+		  AND (rec.disposition != 'none'
+		       OR (rec.dkim_result != 'pass' AND rec.spf_result != 'pass'))
+		ORDER BY rec.count DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query failing records: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	records := []FailingRecord{}
+	for rows.Next() {
+		var fr FailingRecord
+		var overrideReasons string
+		if err := rows.Scan(
+			&fr.SourceIP, &fr.HeaderFrom, &fr.Disposition,
+			&fr.DKIMResult, &fr.SPFResult, &fr.Count,
+			&fr.ReportDomain, &fr.ReportOrgName, &fr.DateBegin,
+			&overrideReasons,
+		); err != nil {
+			return nil, fmt.Errorf("scan failing record row: %w", err)
+		}
+		if err := json.Unmarshal([]byte(overrideReasons), &fr.OverrideReasons); err != nil {
+			return nil, fmt.Errorf("unmarshal override reasons: %w", err)
+		}
+		records = append(records, fr)
+	}
+	return records, nil
+}
+
+// OverrideReasonStats counts the records a reporter applied a policy
+// override reason to, such as forwarded or mailing_list
+type OverrideReasonStats struct {
+	Reason   string `json:"reason"`
+	Records  int    `json:"records"`
+	Messages int    `json:"messages"`
+}
+
+// GetOverrideReasonStats returns the policy override reasons found in
+// records, most messages first
+func (s *Storage) GetOverrideReasonStats() ([]OverrideReasonStats, error) {
+	rows, err := s.db.Query(`
+		SELECT
+			COALESCE(json_extract(reason.value, '$.Type'), '') AS reason_type,
+			COUNT(*),
+			SUM(rec.count)
+		FROM records rec, json_each(rec.override_reasons) reason
+		WHERE rec.override_reasons IS NOT NULL AND ` + liveRecords + `
+		GROUP BY reason_type
+		ORDER BY SUM(rec.count) DESC, reason_type
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query override reason stats: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	stats := []OverrideReasonStats{}
+	for rows.Next() {
+		var st OverrideReasonStats
+		if err := rows.Scan(&st.Reason, &st.Records, &st.Messages); err != nil {
+			return nil, fmt.Errorf("scan override reason row: %w", err)
+		}
+		stats = append(stats, st)
+	}
+	return stats, rows.Err()
+}
+
+// PolicyInfo holds the DMARC policy a domain published in its latest report
+type PolicyInfo struct {
+	Domain         string `json:"domain"`
+	P              string `json:"p"`
+	SP             string `json:"sp"`
+	PCT            int    `json:"pct"`
+	ADKIM          string `json:"adkim"`
+	ASPF           string `json:"aspf"`
+	FO             string `json:"fo"`
+	LastReportDate int64  `json:"last_report_date"`
+}
+
+// GetDomainPolicy returns the published policy from the most recent report for a domain
+func (s *Storage) GetDomainPolicy(domain string) (PolicyInfo, error) {
+	var rawReport string
+	var dateEnd int64
+	err := s.db.QueryRow(`
+		SELECT raw_report, date_end
+		FROM reports
+		WHERE domain = ? AND deleted_at IS NULL
+		ORDER BY date_end DESC, id DESC
+		LIMIT 1
+	`, domain).Scan(&rawReport, &dateEnd)
+	if err != nil {
+		return PolicyInfo{}, fmt.Errorf("query policy for domain %s: %w", domain, wrapError(err))
+	}
+
+	var feedback parser.Feedback
+	if err := json.Unmarshal([]byte(rawReport), &feedback); err != nil {
+		return PolicyInfo{}, fmt.Errorf("unmarshal policy for domain %s: %w", domain, err)
+	}
+
+	policy := feedback.PolicyPublished
+	return PolicyInfo{
+		Domain:         domain,
+		P:              policy.P,
+		SP:             policy.SP,
+		PCT:            policy.PCT,
+		ADKIM:          policy.ADKIM,
+		ASPF:           policy.ASPF,
+		FO:             policy.FO,
+		LastReportDate: dateEnd,
+	}, nil
+}
+
 func (s *Storage) Close() error {
 	return s.db.Close()
 }
 
+// Backup writes a consistent copy of the database to destPath while it stays
+// open for reads and writes. destPath must not exist yet.
+func (s *Storage) Backup(destPath string) error {
+	if _, err := os.Stat(destPath); err == nil {
+		return fmt.Errorf("backup to %s: file already exists", destPath)
+	}
+	if _, err := s.db.Exec(`VACUUM INTO ?`, destPath); err != nil {
+		return fmt.Errorf("backup to %s: %w", destPath, err)
+	}
+	return nil
+}
+
+// Auto-vacuum modes accepted by the database.auto_vacuum setting
+const (
+	AutoVacuumNone        = "none"
+	AutoVacuumIncremental = "incremental"
+)
+
+// Vacuum rebuilds the database file, returning the space of deleted rows to
+// the filesystem
+func (s *Storage) Vacuum() error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if _, err := s.db.Exec(`VACUUM`); err != nil {
+		return fmt.Errorf("vacuum: %w", err)
+	}
+	return nil
+}
+
+// reclaimFreePages returns free pages to the filesystem when the database
+// uses auto_vacuum=INCREMENTAL. In other modes SQLite ignores it. Callers
+// hold writeMu.
+func (s *Storage) reclaimFreePages() error {
+	// The pragma frees one page per step, so its rows must be read to the end
+	rows, err := s.db.Query(`PRAGMA incremental_vacuum`)
+	if err != nil {
+		return fmt.Errorf("incremental vacuum: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("incremental vacuum: %w", err)
+	}
+	return nil
+}
+
+// EnableIncrementalVacuum switches the database to auto_vacuum=INCREMENTAL.
+// SQLite only applies the change on the next VACUUM, so one is run when the
+// mode changes. It reports whether the database was rebuilt.
+func (s *Storage) EnableIncrementalVacuum(ctx context.Context) (bool, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	// The pending mode is per connection, so the pragma and the VACUUM
+	// must run on the same one
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("get connection: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	// 2 is INCREMENTAL
+	var mode int
+	if err := conn.QueryRowContext(ctx, `PRAGMA auto_vacuum`).Scan(&mode); err != nil {
+		return false, fmt.Errorf("query auto_vacuum: %w", err)
+	}
+	if mode == 2 {
+		return false, nil
+	}
+
+	if _, err := conn.ExecContext(ctx, `PRAGMA auto_vacuum = INCREMENTAL`); err != nil {
+		return false, fmt.Errorf("set auto_vacuum: %w", err)
+	}
+	if _, err := conn.ExecContext(ctx, `VACUUM`); err != nil {
+		return false, fmt.Errorf("vacuum: %w", err)
+	}
+	return true, nil
+}
+
+// Ping checks that the database can be opened and read. PingContext alone
+// only opens a connection, so the schema is read as well to catch a file
+// that is corrupted or not a SQLite database.
+func (s *Storage) Ping(ctx context.Context) error {
+	if err := s.db.PingContext(ctx); err != nil {
+		return openError(s.path, err)
+	}
+	var tables int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master`).Scan(&tables); err != nil {
+		return openError(s.path, err)
+	}
+	return nil
+}
+
+// DatabaseSize returns the size of the database in bytes
+func (s *Storage) DatabaseSize() (int64, error) {
+	var size int64
+	err := s.db.QueryRow(`
+		SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()
+	`).Scan(&size)
+	if err != nil {
+		return 0, fmt.Errorf("query database size: %w", err)
+	}
+	return size, nil
+}
+
 // DomainStats holds statistics for a single domain
 type DomainStats struct {
 	Domain            string  `json:"domain"`
@@ -266,6 +1037,12 @@ type OrgStats struct {
 	Reports int    `json:"reports"`
 }
 
+// OrgMessageStats holds the message volume reported by an organization
+type OrgMessageStats struct {
+	OrgName  string `json:"org_name"`
+	Messages int    `json:"messages"`
+}
+
 // DispositionStats holds statistics for a disposition type
 type DispositionStats struct {
 	Disposition string `json:"disposition"`
@@ -280,15 +1057,35 @@ type AuthResultStats struct {
 
 // GetDomainStats returns statistics grouped by domain
 func (s *Storage) GetDomainStats() ([]DomainStats, error) {
+	stats, err := s.queryDomainStats("WHERE deleted_at IS NULL")
+	if err != nil {
+		return nil, fmt.Errorf("query domain stats: %w", err)
+	}
+	return stats, nil
+}
+
+// GetDomainStatsBetween returns per-domain message totals for reports that
+// began in [from, to)
+func (s *Storage) GetDomainStatsBetween(from, to time.Time) ([]DomainStats, error) {
+	stats, err := s.queryDomainStats("WHERE date_begin >= ? AND date_begin < ? AND deleted_at IS NULL", from.Unix(), to.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("query domain stats between %s and %s: %w", from.Format(time.RFC3339), to.Format(time.RFC3339), err)
+	}
+	return stats, nil
+}
+
+// queryDomainStats aggregates reports per domain, restricted by where
+func (s *Storage) queryDomainStats(where string, args ...interface{}) ([]DomainStats, error) {
 	rows, err := s.db.Query(`
 		SELECT domain,
 		       COALESCE(SUM(total_messages), 0) as total_messages,
 		       COALESCE(SUM(compliant_messages), 0) as compliant_messages
 		FROM reports
+		`+where+`
 		GROUP BY domain
-	`)
+	`, args...)
 	if err != nil {
-		return nil, fmt.Errorf("query domain stats: %w", err)
+		return nil, err
 	}
 	defer func() { _ = rows.Close() }()
 
@@ -311,6 +1108,7 @@ func (s *Storage) GetOrgStats() ([]OrgStats, error) {
 	rows, err := s.db.Query(`
 		SELECT org_name, COUNT(*) as reports
 		FROM reports
+		WHERE deleted_at IS NULL
 		GROUP BY org_name
 	`)
 	if err != nil {
@@ -329,12 +1127,38 @@ func (s *Storage) GetOrgStats() ([]OrgStats, error) {
 	return stats, nil
 }
 
+// GetOrgMessageStats returns total message counts grouped by reporting
+// organization
+func (s *Storage) GetOrgMessageStats() ([]OrgMessageStats, error) {
+	rows, err := s.db.Query(`
+		SELECT org_name, COALESCE(SUM(total_messages), 0) as messages
+		FROM reports
+		WHERE deleted_at IS NULL
+		GROUP BY org_name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query org message stats: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	stats := []OrgMessageStats{}
+	for rows.Next() {
+		var om OrgMessageStats
+		if err := rows.Scan(&om.OrgName, &om.Messages); err != nil {
+			return nil, fmt.Errorf("scan org message stats row: %w", err)
+		}
+		stats = append(stats, om)
+	}
+	return stats, nil
+}
+
 // GetDispositionStats returns message counts grouped by disposition
 func (s *Storage) GetDispositionStats() ([]DispositionStats, error) {
 	rows, err := s.db.Query(`
 		SELECT COALESCE(disposition, 'unknown') as disposition,
 		       SUM(count) as total_count
 		FROM records
+		WHERE ` + liveRecords + `
 		GROUP BY disposition
 	`)
 	if err != nil {
@@ -359,6 +1183,7 @@ func (s *Storage) GetSPFStats() ([]AuthResultStats, error) {
 		SELECT COALESCE(spf_result, 'unknown') as result,
 		       SUM(count) as total_count
 		FROM records
+		WHERE ` + liveRecords + `
 		GROUP BY spf_result
 	`)
 	if err != nil {
@@ -383,6 +1208,7 @@ func (s *Storage) GetDKIMStats() ([]AuthResultStats, error) {
 		SELECT COALESCE(dkim_result, 'unknown') as result,
 		       SUM(count) as total_count
 		FROM records
+		WHERE ` + liveRecords + `
 		GROUP BY dkim_result
 	`)
 	if err != nil {
@@ -400,3 +1226,274 @@ func (s *Storage) GetDKIMStats() ([]AuthResultStats, error) {
 	}
 	return stats, nil
 }
+
+// AuthDetailStats holds message counts for a single auth domain/result pair
+// taken from the per-record auth_results rather than the evaluated policy
+type AuthDetailStats struct {
+	Domain   string `json:"domain"`
+	Selector string `json:"selector,omitempty"`
+	Result   string `json:"result"`
+	Count    int    `json:"count"`
+}
+
+// GetDetailedSPFStats returns SPF results aggregated by domain and result
+func (s *Storage) GetDetailedSPFStats() ([]AuthDetailStats, error) {
+	rows, err := s.db.Query(`SELECT count, COALESCE(spf_domains, '[]') FROM records WHERE ` + liveRecords)
+	if err != nil {
+		return nil, fmt.Errorf("query detailed SPF stats: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	counts := make(map[AuthDetailStats]int)
+	for rows.Next() {
+		var count int
+		var raw string
+		if err := rows.Scan(&count, &raw); err != nil {
+			return nil, fmt.Errorf("scan detailed SPF stats row: %w", err)
+		}
+
+		var results []parser.SPFResult
+		if err := json.Unmarshal([]byte(raw), &results); err != nil {
+			return nil, fmt.Errorf("unmarshal SPF domains: %w", err)
+		}
+		for _, r := range results {
+			counts[AuthDetailStats{Domain: r.Domain, Result: r.Result}] += count
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate detailed SPF stats: %w", err)
+	}
+
+	return sortAuthDetailStats(counts), nil
+}
+
+// GetDetailedDKIMStats returns DKIM results aggregated by domain, selector and result
+func (s *Storage) GetDetailedDKIMStats() ([]AuthDetailStats, error) {
+	rows, err := s.db.Query(`SELECT count, COALESCE(dkim_domains, '[]') FROM records WHERE ` + liveRecords)
+	if err != nil {
+		return nil, fmt.Errorf("query detailed DKIM stats: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	counts := make(map[AuthDetailStats]int)
+	for rows.Next() {
+		var count int
+		var raw string
+		if err := rows.Scan(&count, &raw); err != nil {
+			return nil, fmt.Errorf("scan detailed DKIM stats row: %w", err)
+		}
+
+		var results []parser.DKIMResult
+		if err := json.Unmarshal([]byte(raw), &results); err != nil {
+			return nil, fmt.Errorf("unmarshal DKIM domains: %w", err)
+		}
+		for _, r := range results {
+			counts[AuthDetailStats{Domain: r.Domain, Selector: r.Selector, Result: r.Result}] += count
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate detailed DKIM stats: %w", err)
+	}
+
+	return sortAuthDetailStats(counts), nil
+}
+
+// sortAuthDetailStats flattens aggregated counts ordered by count descending
+func sortAuthDetailStats(counts map[AuthDetailStats]int) []AuthDetailStats {
+	stats := make([]AuthDetailStats, 0, len(counts))
+	for key, count := range counts {
+		key.Count = count
+		stats = append(stats, key)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		if stats[i].Domain != stats[j].Domain {
+			return stats[i].Domain < stats[j].Domain
+		}
+		if stats[i].Selector != stats[j].Selector {
+			return stats[i].Selector < stats[j].Selector
+		}
+		return stats[i].Result < stats[j].Result
+	})
+	return stats
+}
+
+// TrendPoint holds message counts for a single day
+type TrendPoint struct {
+	Date              string  `json:"date"`
+	TotalMessages     int     `json:"total_messages"`
+	CompliantMessages int     `json:"compliant_messages"`
+	ComplianceRate    float64 `json:"compliance_rate"`
+	SPFFailures       int     `json:"spf_failures"`
+	DKIMFailures      int     `json:"dkim_failures"`
+}
+
+// sinceDays returns the unix timestamp days ago, or 0 for days <= 0
+func sinceDays(days int) int64 {
+	if days <= 0 {
+		return 0
+	}
+	return time.Now().AddDate(0, 0, -days).Unix()
+}
+
+// GetComplianceTrend returns daily compliance for a domain over the last
+// days days, bucketed by report begin date. An empty domain covers all
+// domains and days <= 0 covers all time.
+func (s *Storage) GetComplianceTrend(domain string, days int) ([]TrendPoint, error) {
+	trend, err := s.queryTrend("(? = '' OR r.domain = ?)", domain, domain, sinceDays(days))
+	if err != nil {
+		return nil, fmt.Errorf("query compliance trend: %w", err)
+	}
+	return trend, nil
+}
+
+// GetSourceIPTrend returns daily message counts for a single source IP over
+// the last days days (days <= 0 covers all time)
+func (s *Storage) GetSourceIPTrend(ip string, days int) ([]TrendPoint, error) {
+	trend, err := s.queryTrend("rec.source_ip = ?", ip, sinceDays(days))
+	if err != nil {
+		return nil, fmt.Errorf("query source IP trend for %s: %w", ip, err)
+	}
+	return trend, nil
+}
+
+// queryTrend aggregates records per day. cond is ANDed with the date bound,
+// and args must end with the minimum date_begin.
+func (s *Storage) queryTrend(cond string, args ...interface{}) ([]TrendPoint, error) {
+	rows, err := s.db.Query(`
+		SELECT
+			date(r.date_begin, 'unixepoch') as day,
+			COALESCE(SUM(rec.count), 0) as total_count,
+			COALESCE(SUM(CASE WHEN (rec.dkim_result = 'pass' OR rec.spf_result = 'pass') THEN rec.count ELSE 0 END), 0) as pass_count,
+			COALESCE(SUM(CASE WHEN rec.spf_result != 'pass' THEN rec.count ELSE 0 END), 0) as spf_fail_count,
+			COALESCE(SUM(CASE WHEN rec.dkim_result != 'pass' THEN rec.count ELSE 0 END), 0) as dkim_fail_count
+		FROM records rec
+		JOIN reports r ON r.id = rec.report_id
+		WHERE `+cond+` AND r.deleted_at IS NULL AND r.date_begin >= ?
+		GROUP BY day
+		ORDER BY day
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	trend := []TrendPoint{}
+	for rows.Next() {
+		var p TrendPoint
+		if err := rows.Scan(&p.Date, &p.TotalMessages, &p.CompliantMessages, &p.SPFFailures, &p.DKIMFailures); err != nil {
+			return nil, fmt.Errorf("scan trend row: %w", err)
+		}
+		if p.TotalMessages > 0 {
+			p.ComplianceRate = float64(p.CompliantMessages) / float64(p.TotalMessages) * 100
+		}
+		trend = append(trend, p)
+	}
+	return trend, nil
+}
+
+// HeatmapDay is the message volume and compliance of one calendar day
+type HeatmapDay struct {
+	Date           string  `json:"date"`
+	TotalMessages  int     `json:"total_messages"`
+	ComplianceRate float64 `json:"compliance_rate"`
+}
+
+// GetReportHeatmapData returns message volume and compliance per day of
+// year (UTC), bucketed by report begin date. Days without reports are
+// omitted.
+func (s *Storage) GetReportHeatmapData(year int) ([]HeatmapDay, error) {
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	rows, err := s.db.Query(`
+		SELECT strftime('%Y-%m-%d', date_begin, 'unixepoch') AS day,
+		       COALESCE(SUM(total_messages), 0),
+		       COALESCE(SUM(compliant_messages), 0)
+		FROM reports
+		WHERE date_begin >= ? AND date_begin < ? AND deleted_at IS NULL
+		GROUP BY day
+		ORDER BY day
+	`, start.Unix(), start.AddDate(1, 0, 0).Unix())
+	if err != nil {
+		return nil, fmt.Errorf("query heatmap for %d: %w", year, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	days := []HeatmapDay{}
+	for rows.Next() {
+		var d HeatmapDay
+		var compliant int
+		if err := rows.Scan(&d.Date, &d.TotalMessages, &compliant); err != nil {
+			return nil, fmt.Errorf("scan heatmap day: %w", err)
+		}
+		if d.TotalMessages > 0 {
+			d.ComplianceRate = float64(compliant) / float64(d.TotalMessages) * 100
+		}
+		days = append(days, d)
+	}
+	return days, rows.Err()
+}
+
+// GetActiveSourceIPs returns source IPs seen in reports from the last days
+// days, busiest first
+func (s *Storage) GetActiveSourceIPs(days, limit int) ([]string, error) {
+	rows, err := s.db.Query(`
+		SELECT rec.source_ip
+		FROM records rec
+		JOIN reports r ON r.id = rec.report_id
+		WHERE r.date_begin >= ? AND r.deleted_at IS NULL
+		GROUP BY rec.source_ip
+		ORDER BY SUM(rec.count) DESC
+		LIMIT ?
+	`, sinceDays(days), limit)
+	if err != nil {
+		return nil, fmt.Errorf("query active source IPs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	ips := []string{}
+	for rows.Next() {
+		var ip string
+		if err := rows.Scan(&ip); err != nil {
+			return nil, fmt.Errorf("scan active source IP row: %w", err)
+		}
+		ips = append(ips, ip)
+	}
+	return ips, nil
+}
+
+// GetDomainTopSourceIPs returns the top sending IPs for a single domain
+// over the last days days (days <= 0 covers all time)
+func (s *Storage) GetDomainTopSourceIPs(domain string, days, limit int) ([]TopSourceIP, error) {
+	rows, err := s.db.Query(`
+		SELECT
+			rec.source_ip,
+			SUM(rec.count) as total_count,
+			SUM(CASE WHEN (rec.dkim_result = 'pass' OR rec.spf_result = 'pass') THEN rec.count ELSE 0 END) as pass_count,
+			SUM(CASE WHEN (rec.dkim_result != 'pass' AND rec.spf_result != 'pass') THEN rec.count ELSE 0 END) as fail_count
+		FROM records rec
+		JOIN reports r ON r.id = rec.report_id
+		WHERE r.domain = ? AND r.date_begin >= ? AND r.deleted_at IS NULL
+		GROUP BY rec.source_ip
+		ORDER BY total_count DESC
+		LIMIT ?
+	`, domain, sinceDays(days), limit)
+	if err != nil {
+		return nil, fmt.Errorf("query domain top source IPs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	results := []TopSourceIP{}
+	for rows.Next() {
+		var r TopSourceIP
+		if err := rows.Scan(&r.SourceIP, &r.Count, &r.Pass, &r.Fail); err != nil {
+			return nil, fmt.Errorf("scan domain source IP row: %w", err)
+		}
+		if s.geo != nil {
+			r.Country, r.ASN, r.ASNOrg = s.geo.LookupIP(r.SourceIP)
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
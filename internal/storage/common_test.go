@@ -1,7 +1,19 @@
 package storage
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"pgregory.net/rapid"
 
 	"github.com/meysam81/parse-dmarc/internal/parser"
 )
@@ -124,3 +136,1202 @@ func TestGetStatistics_HasData(t *testing.T) {
 		}
 	})
 }
+
+// testReportXML builds a minimal single-record DMARC report for tests
+func testReportXML(reportID, domain string, begin, end int64, policy string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<feedback>
+  <report_metadata>
+    <org_name>google.com</org_name>
+    <email>noreply-dmarc-support@google.com</email>
+    <report_id>%s</report_id>
+    <date_range>
+      <begin>%d</begin>
+      <end>%d</end>
+    </date_range>
+  </report_metadata>
+  <policy_published>
+    <domain>%s</domain>
+    <adkim>s</adkim>
+    <aspf>r</aspf>
+    <p>%s</p>
+    <sp>none</sp>
+    <pct>100</pct>
+    <fo>1</fo>
+  </policy_published>
+  <record>
+    <row>
+      <source_ip>192.0.2.1</source_ip>
+      <count>10</count>
+      <policy_evaluated>
+        <disposition>none</disposition>
+        <dkim>pass</dkim>
+        <spf>fail</spf>
+      </policy_evaluated>
+    </row>
+    <identifiers>
+      <header_from>%s</header_from>
+    </identifiers>
+    <auth_results>
+      <spf>
+        <domain>%s</domain>
+        <result>fail</result>
+      </spf>
+      <dkim>
+        <domain>%s</domain>
+        <selector>s1</selector>
+        <result>pass</result>
+      </dkim>
+    </auth_results>
+  </record>
+</feedback>`, reportID, begin, end, domain, policy, domain, domain, domain)
+}
+
+// saveTestReport parses and stores the given report XML
+func parseTestReport(t *testing.T, xmlData string) *parser.Feedback {
+	t.Helper()
+	feedback, err := parser.ParseReport([]byte(xmlData))
+	if err != nil {
+		t.Fatalf("Failed to parse report: %v", err)
+	}
+	return feedback
+}
+
+func saveTestReport(t *testing.T, s *Storage, xmlData string) {
+	t.Helper()
+	feedback := parseTestReport(t, xmlData)
+	if err := s.SaveReport(feedback); err != nil {
+		t.Fatalf("Failed to save report: %v", err)
+	}
+}
+
+func TestGetExtendedStatistics(t *testing.T) {
+	storage, err := NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	t.Run("empty database", func(t *testing.T) {
+		stats, err := storage.GetExtendedStatistics()
+		if err != nil {
+			t.Fatalf("Failed to get extended statistics: %v", err)
+		}
+		if stats.HasData || stats.TopDomain != "" || stats.MedianComplianceRate != 0 {
+			t.Errorf("Expected empty statistics, got %+v", stats)
+		}
+	})
+
+	// Three reports with 0%, 50% and 100% compliance
+	for i, spf := range []string{"fail", "pass", "pass"} {
+		xml := testReportXML(fmt.Sprintf("r%d", i), "example.com", 1609459200+int64(i)*86400, 1609545600+int64(i)*86400, "none")
+		xml = strings.Replace(xml, "<dkim>pass</dkim>\n        <spf>fail</spf>", "<dkim>fail</dkim>\n        <spf>"+spf+"</spf>", 1)
+		if i == 1 {
+			xml = strings.Replace(xml, "</record>", "</record>\n  <record><row><source_ip>192.0.2.2</source_ip><count>10</count><policy_evaluated><disposition>none</disposition><dkim>fail</dkim><spf>fail</spf></policy_evaluated></row><identifiers><header_from>example.com</header_from></identifiers></record>", 1)
+		}
+		saveTestReport(t, storage, xml)
+	}
+	saveTestReport(t, storage, testReportXML("other", "example.org", 1609459200, 1609545600, "none"))
+	if _, err := storage.db.Exec("UPDATE reports SET created_at = ? WHERE report_id = 'r0'", time.Now().AddDate(0, 0, -10).Unix()); err != nil {
+		t.Fatalf("Failed to backdate report: %v", err)
+	}
+
+	stats, err := storage.GetExtendedStatistics()
+	if err != nil {
+		t.Fatalf("Failed to get extended statistics: %v", err)
+	}
+
+	if stats.TotalReports != 4 || stats.UniqueDomains != 2 || !stats.HasData {
+		t.Errorf("Unexpected base statistics: %+v", stats.Statistics)
+	}
+	// Report rates are 0, 50, 100 and 100
+	if stats.AverageComplianceRate != 62.5 {
+		t.Errorf("Expected average 62.5, got %f", stats.AverageComplianceRate)
+	}
+	if stats.MedianComplianceRate != 75 {
+		t.Errorf("Expected median 75, got %f", stats.MedianComplianceRate)
+	}
+	if stats.ReportsLast24h != 3 || stats.ReportsLast7d != 3 || stats.ReportsLast30d != 4 {
+		t.Errorf("Expected 3/3/4 recent reports, got %d/%d/%d", stats.ReportsLast24h, stats.ReportsLast7d, stats.ReportsLast30d)
+	}
+	if stats.OldestReportTime != 1609459200 || stats.MostRecentReportTime != 1609545600+2*86400 {
+		t.Errorf("Unexpected report time range %d to %d", stats.OldestReportTime, stats.MostRecentReportTime)
+	}
+	if stats.TopDomain != "example.org" || stats.BottomDomain != "example.com" {
+		t.Errorf("Expected top example.org and bottom example.com, got %s and %s", stats.TopDomain, stats.BottomDomain)
+	}
+}
+
+func TestGetDomainPolicy(t *testing.T) {
+	storage, err := NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	if _, err := storage.GetDomainPolicy("example.com"); err == nil {
+		t.Errorf("Expected error for unknown domain, got nil")
+	}
+
+	saveTestReport(t, storage, testReportXML("r1", "example.com", 1609459200, 1609545600, "none"))
+	saveTestReport(t, storage, testReportXML("r2", "example.com", 1609545600, 1609632000, "quarantine"))
+
+	policy, err := storage.GetDomainPolicy("example.com")
+	if err != nil {
+		t.Fatalf("Failed to get domain policy: %v", err)
+	}
+
+	if policy.P != "quarantine" {
+		t.Errorf("Expected policy quarantine, got %s", policy.P)
+	}
+	if policy.ADKIM != "s" || policy.ASPF != "r" || policy.FO != "1" {
+		t.Errorf("Unexpected alignment/fo values: %+v", policy)
+	}
+	if policy.PCT != 100 {
+		t.Errorf("Expected PCT 100, got %d", policy.PCT)
+	}
+	if policy.LastReportDate != 1609632000 {
+		t.Errorf("Expected LastReportDate 1609632000, got %d", policy.LastReportDate)
+	}
+}
+
+func TestGetDetailedAuthStats(t *testing.T) {
+	storage, err := NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	saveTestReport(t, storage, testReportXML("r1", "example.com", 1609459200, 1609545600, "none"))
+	saveTestReport(t, storage, testReportXML("r2", "example.com", 1609545600, 1609632000, "none"))
+
+	spf, err := storage.GetDetailedSPFStats()
+	if err != nil {
+		t.Fatalf("Failed to get detailed SPF stats: %v", err)
+	}
+	if len(spf) != 1 || spf[0].Domain != "example.com" || spf[0].Result != "fail" || spf[0].Count != 20 {
+		t.Errorf("Unexpected detailed SPF stats: %+v", spf)
+	}
+
+	dkim, err := storage.GetDetailedDKIMStats()
+	if err != nil {
+		t.Fatalf("Failed to get detailed DKIM stats: %v", err)
+	}
+	if len(dkim) != 1 || dkim[0].Selector != "s1" || dkim[0].Result != "pass" || dkim[0].Count != 20 {
+		t.Errorf("Unexpected detailed DKIM stats: %+v", dkim)
+	}
+}
+
+func TestCountReports(t *testing.T) {
+	storage, err := NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	saveTestReport(t, storage, testReportXML("r1", "example.com", 1609459200, 1609545600, "none"))
+	saveTestReport(t, storage, testReportXML("r2", "example.com", 1609545600, 1609632000, "none"))
+	saveTestReport(t, storage, testReportXML("r3", "example.org", 1609545600, 1609632000, "none"))
+
+	total, err := storage.CountReports(ReportFilter{})
+	if err != nil {
+		t.Fatalf("Failed to count reports: %v", err)
+	}
+	if total != 3 {
+		t.Errorf("Expected 3 reports, got %d", total)
+	}
+
+	filter := ReportFilter{Domain: "example.com"}
+	total, err = storage.CountReports(filter)
+	if err != nil {
+		t.Fatalf("Failed to count reports: %v", err)
+	}
+	if total != 2 {
+		t.Errorf("Expected 2 reports for example.com, got %d", total)
+	}
+
+	reports, err := storage.GetReports(filter, 1, 0)
+	if err != nil {
+		t.Fatalf("Failed to get reports: %v", err)
+	}
+	if len(reports) != 1 || reports[0].ReportID != "r2" {
+		t.Errorf("Expected newest example.com report r2, got %+v", reports)
+	}
+}
+
+func TestGetComplianceTrend(t *testing.T) {
+	storage, err := NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	saveTestReport(t, storage, testReportXML("r1", "example.com", 1609459200, 1609545600, "none"))
+	saveTestReport(t, storage, testReportXML("r2", "example.com", 1609545600, 1609632000, "none"))
+	saveTestReport(t, storage, testReportXML("r3", "example.org", 1609545600, 1609632000, "none"))
+
+	trend, err := storage.GetComplianceTrend("example.com", 0)
+	if err != nil {
+		t.Fatalf("Failed to get compliance trend: %v", err)
+	}
+	if len(trend) != 2 {
+		t.Fatalf("Expected 2 trend points, got %d", len(trend))
+	}
+	if trend[0].Date != "2021-01-01" || trend[0].TotalMessages != 10 || trend[0].SPFFailures != 10 {
+		t.Errorf("Unexpected first trend point: %+v", trend[0])
+	}
+
+	recent, err := storage.GetComplianceTrend("example.com", 7)
+	if err != nil {
+		t.Fatalf("Failed to get compliance trend: %v", err)
+	}
+	if len(recent) != 0 {
+		t.Errorf("Expected no trend points in the last 7 days, got %d", len(recent))
+	}
+
+	sources, err := storage.GetDomainTopSourceIPs("example.org", 0, 10)
+	if err != nil {
+		t.Fatalf("Failed to get domain top source IPs: %v", err)
+	}
+	if len(sources) != 1 || sources[0].Count != 10 {
+		t.Errorf("Unexpected domain top source IPs: %+v", sources)
+	}
+}
+
+func TestGetReportHeatmapData(t *testing.T) {
+	storage, err := NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	saveTestReport(t, storage, testReportXML("r1", "example.com", 1609459200, 1609545600, "none"))
+	saveTestReport(t, storage, testReportXML("r2", "example.org", 1609459200, 1609545600, "none"))
+	saveTestReport(t, storage, testReportXML("r3", "example.com", 1609545600, 1609632000, "none"))
+	// 2020-12-31, outside the requested year
+	saveTestReport(t, storage, testReportXML("r4", "example.com", 1609372800, 1609459200, "none"))
+
+	days, err := storage.GetReportHeatmapData(2021)
+	if err != nil {
+		t.Fatalf("Failed to get heatmap data: %v", err)
+	}
+	if len(days) != 2 {
+		t.Fatalf("Expected 2 days, got %d: %+v", len(days), days)
+	}
+	if days[0].Date != "2021-01-01" || days[0].TotalMessages != 20 {
+		t.Errorf("Expected 20 messages on 2021-01-01, got %+v", days[0])
+	}
+	if days[1].Date != "2021-01-02" || days[1].TotalMessages != 10 {
+		t.Errorf("Expected 10 messages on 2021-01-02, got %+v", days[1])
+	}
+	if days[0].ComplianceRate != 100 {
+		t.Errorf("Expected compliance rate 100, got %f", days[0].ComplianceRate)
+	}
+
+	empty, err := storage.GetReportHeatmapData(2019)
+	if err != nil {
+		t.Fatalf("Failed to get heatmap data: %v", err)
+	}
+	if empty == nil || len(empty) != 0 {
+		t.Errorf("Expected an empty, non-nil slice, got %#v", empty)
+	}
+}
+
+// seedBenchReports inserts n synthetic reports spread over ten domains and a
+// year of begin dates, bypassing SaveReport for speed
+func seedBenchReports(b *testing.B, s *Storage, n int) {
+	b.Helper()
+	tx, err := s.db.Begin()
+	if err != nil {
+		b.Fatalf("Failed to begin transaction: %v", err)
+	}
+	stmt, err := tx.Prepare(`
+		INSERT INTO reports (report_id, org_name, domain, date_begin, date_end, created_at, policy_p, total_messages, compliant_messages, raw_report)
+		VALUES (?, 'google.com', ?, ?, ?, ?, 'none', 100, 90, '{}')
+	`)
+	if err != nil {
+		b.Fatalf("Failed to prepare insert: %v", err)
+	}
+	const start = 1609459200
+	for i := 0; i < n; i++ {
+		begin := int64(start + (i%365)*86400)
+		domain := fmt.Sprintf("example%d.com", i%10)
+		if _, err := stmt.Exec(fmt.Sprintf("bench-%d", i), domain, begin, begin+86400, begin); err != nil {
+			b.Fatalf("Failed to insert report: %v", err)
+		}
+	}
+	_ = stmt.Close()
+	if err := tx.Commit(); err != nil {
+		b.Fatalf("Failed to commit: %v", err)
+	}
+}
+
+func BenchmarkGetReports_DomainDateRange(b *testing.B) {
+	storage, err := NewStorage(filepath.Join(b.TempDir(), "bench.sqlite"))
+	if err != nil {
+		b.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+	seedBenchReports(b, storage, 100000)
+
+	// April 2021, a month out of the seeded year
+	filter := ReportFilter{Domain: "example3.com", From: 1617235200, To: 1619827200}
+	run := func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := storage.GetReports(filter, 50, 0); err != nil {
+				b.Fatalf("Failed to get reports: %v", err)
+			}
+		}
+	}
+
+	b.Run("without_index", func(b *testing.B) {
+		if _, err := storage.db.Exec("DROP INDEX idx_reports_domain_date"); err != nil {
+			b.Fatalf("Failed to drop index: %v", err)
+		}
+		defer func() {
+			b.StopTimer()
+			_ = storage.init()
+		}()
+		b.ResetTimer()
+		run(b)
+	})
+	b.Run("with_index", run)
+}
+
+// seedBenchReportRecords adds perReport records to each of the first n
+// reports, spread over 1,000 source IPs with mixed results
+func seedBenchReportRecords(b *testing.B, s *Storage, n, perReport int) {
+	b.Helper()
+	tx, err := s.db.Begin()
+	if err != nil {
+		b.Fatalf("Failed to begin transaction: %v", err)
+	}
+	stmt, err := tx.Prepare(`
+		INSERT INTO records (report_id, source_ip, count, disposition, dkim_result, spf_result, header_from)
+		VALUES (?, ?, 10, ?, ?, ?, 'example.com')
+	`)
+	if err != nil {
+		b.Fatalf("Failed to prepare insert: %v", err)
+	}
+	dispositions := []string{"none", "none", "quarantine", "reject"}
+	results := []string{"pass", "pass", "pass", "fail"}
+	for r := 1; r <= n; r++ {
+		for j := 0; j < perReport; j++ {
+			i := r*perReport + j
+			ip := fmt.Sprintf("198.51.%d.%d", (i/250)%4, i%250)
+			if _, err := stmt.Exec(r, ip, dispositions[i%4], results[i%4], results[(i/4)%4]); err != nil {
+				b.Fatalf("Failed to insert record: %v", err)
+			}
+		}
+	}
+	_ = stmt.Close()
+	if err := tx.Commit(); err != nil {
+		b.Fatalf("Failed to commit: %v", err)
+	}
+}
+
+// benchRecordsPerReport is the number of records per report in the
+// datasets used by the query benchmarks
+const benchRecordsPerReport = 100
+
+// benchDatasets runs fn against databases of 1K, 10K and 100K reports. The
+// 100K dataset holds 10M records and takes a while to seed, so it is
+// skipped with -short.
+func benchDatasets(b *testing.B, fn func(b *testing.B, s *Storage)) {
+	for _, n := range []int{1000, 10000, 100000} {
+		if testing.Short() && n > 10000 {
+			continue
+		}
+		storage, err := NewStorage(filepath.Join(b.TempDir(), fmt.Sprintf("bench-%d.sqlite", n)))
+		if err != nil {
+			b.Fatalf("Failed to create storage: %v", err)
+		}
+		seedBenchReports(b, storage, n)
+		seedBenchReportRecords(b, storage, n, benchRecordsPerReport)
+
+		b.Run(fmt.Sprintf("reports=%d", n), func(b *testing.B) {
+			fn(b, storage)
+		})
+		_ = storage.Close()
+	}
+}
+
+func BenchmarkGetStatistics(b *testing.B) {
+	benchDatasets(b, func(b *testing.B, s *Storage) {
+		for i := 0; i < b.N; i++ {
+			if _, err := s.GetStatistics(); err != nil {
+				b.Fatalf("Failed to get statistics: %v", err)
+			}
+		}
+	})
+}
+
+func BenchmarkGetTopSourceIPs(b *testing.B) {
+	benchDatasets(b, func(b *testing.B, s *Storage) {
+		for i := 0; i < b.N; i++ {
+			if _, err := s.GetTopSourceIPs(10); err != nil {
+				b.Fatalf("Failed to get top source IPs: %v", err)
+			}
+		}
+	})
+}
+
+func BenchmarkGetDomainStats(b *testing.B) {
+	benchDatasets(b, func(b *testing.B, s *Storage) {
+		for i := 0; i < b.N; i++ {
+			if _, err := s.GetDomainStats(); err != nil {
+				b.Fatalf("Failed to get domain stats: %v", err)
+			}
+		}
+	})
+}
+
+const recordsByIPDispositionQuery = `SELECT SUM(count) FROM records WHERE source_ip = ? AND disposition = ?`
+
+// queryPlan returns the EXPLAIN QUERY PLAN details for query, one per line
+func queryPlan(tb testing.TB, s *Storage, query string, args ...interface{}) string {
+	tb.Helper()
+	rows, err := s.db.Query("EXPLAIN QUERY PLAN "+query, args...)
+	if err != nil {
+		tb.Fatalf("Failed to explain query: %v", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var plan []string
+	for rows.Next() {
+		var id, parent, notUsed int
+		var detail string
+		if err := rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+			tb.Fatalf("Failed to scan query plan: %v", err)
+		}
+		plan = append(plan, detail)
+	}
+	return strings.Join(plan, "\n")
+}
+
+func TestRecordsQueryPlan(t *testing.T) {
+	storage, err := NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	tests := []struct {
+		name  string
+		query string
+		args  []interface{}
+		index string
+	}{
+		{"source ip and disposition", recordsByIPDispositionQuery, []interface{}{"192.0.2.1", "reject"}, "idx_records_source_ip_disposition"},
+		{"disposition stats", "SELECT disposition, SUM(count) FROM records GROUP BY disposition", nil, "idx_records_disposition"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := queryPlan(t, storage, tt.query, tt.args...)
+			if !strings.Contains(plan, tt.index) {
+				t.Errorf("Expected query plan to use %s, got %q", tt.index, plan)
+			}
+		})
+	}
+}
+
+// seedBenchRecords inserts n synthetic records over 1,000 source IPs
+func seedBenchRecords(b *testing.B, s *Storage, n int) {
+	b.Helper()
+	tx, err := s.db.Begin()
+	if err != nil {
+		b.Fatalf("Failed to begin transaction: %v", err)
+	}
+	stmt, err := tx.Prepare(`INSERT INTO records (report_id, source_ip, count, disposition) VALUES (1, ?, 10, ?)`)
+	if err != nil {
+		b.Fatalf("Failed to prepare insert: %v", err)
+	}
+	dispositions := []string{"none", "quarantine", "reject"}
+	for i := 0; i < n; i++ {
+		ip := fmt.Sprintf("192.0.%d.%d", (i/250)%4, i%250)
+		if _, err := stmt.Exec(ip, dispositions[i%len(dispositions)]); err != nil {
+			b.Fatalf("Failed to insert record: %v", err)
+		}
+	}
+	_ = stmt.Close()
+	if err := tx.Commit(); err != nil {
+		b.Fatalf("Failed to commit: %v", err)
+	}
+}
+
+func BenchmarkRecordsBySourceIPDisposition(b *testing.B) {
+	storage, err := NewStorage(filepath.Join(b.TempDir(), "bench.sqlite"))
+	if err != nil {
+		b.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+	seedBenchRecords(b, storage, 100000)
+
+	run := func(b *testing.B) {
+		b.Logf("query plan:\n%s", queryPlan(b, storage, recordsByIPDispositionQuery, "192.0.1.7", "reject"))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			var total sql.NullInt64
+			if err := storage.db.QueryRow(recordsByIPDispositionQuery, "192.0.1.7", "reject").Scan(&total); err != nil {
+				b.Fatalf("Failed to query records: %v", err)
+			}
+		}
+	}
+
+	b.Run("without_index", func(b *testing.B) {
+		for _, index := range []string{"idx_records_source_ip_disposition", "idx_records_source_ip"} {
+			if _, err := storage.db.Exec("DROP INDEX " + index); err != nil {
+				b.Fatalf("Failed to drop index: %v", err)
+			}
+		}
+		defer func() {
+			b.StopTimer()
+			_ = storage.init()
+		}()
+		run(b)
+	})
+	b.Run("with_index", run)
+}
+
+func TestGetReportsByDateRange(t *testing.T) {
+	storage, err := NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	day := int64(86400)
+	start := int64(1609459200)
+	saveTestReport(t, storage, testReportXML("day1", "example.com", start, start+day, "none"))
+	saveTestReport(t, storage, testReportXML("day2", "example.com", start+day, start+2*day, "none"))
+	saveTestReport(t, storage, testReportXML("day3", "example.com", start+2*day, start+3*day, "none"))
+
+	tests := []struct {
+		name     string
+		from, to int64
+		want     []string
+	}{
+		{"empty range", start + 10*day, start + 11*day, []string{}},
+		{"partial overlap", start + day/2, start + 5*day/2, []string{"day2"}},
+		{"exact match", start + day, start + 2*day, []string{"day2"}},
+		{"whole range", start, start + 3*day, []string{"day3", "day2", "day1"}},
+		{"from only", start + day, 0, []string{"day3", "day2"}},
+		{"unbounded", 0, 0, []string{"day3", "day2", "day1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reports, err := storage.GetReportsByDateRange(tt.from, tt.to, 50, 0)
+			if err != nil {
+				t.Fatalf("Failed to get reports: %v", err)
+			}
+			got := make([]string, 0, len(reports))
+			for _, r := range reports {
+				got = append(got, r.ReportID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected reports %v, got %v", tt.want, got)
+			}
+
+			count, err := storage.CountReports(ReportFilter{From: tt.from, To: tt.to})
+			if err != nil {
+				t.Fatalf("Failed to count reports: %v", err)
+			}
+			if count != len(tt.want) {
+				t.Errorf("Expected count %d, got %d", len(tt.want), count)
+			}
+		})
+	}
+}
+
+func TestGetReportByExternalID(t *testing.T) {
+	storage, err := NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	reportID := "20230101.google.com.12345"
+	feedback := parseTestReport(t, testReportXML(reportID, "example.com", 1609459200, 1609545600, "none"))
+	if err := storage.SaveReport(feedback); err != nil {
+		t.Fatalf("Failed to save report: %v", err)
+	}
+	saveTestReport(t, storage, testReportXML("other", "example.com", 1609459200, 1609545600, "none"))
+
+	reports, err := storage.GetReports(ReportFilter{ReportID: reportID}, 1, 0)
+	if err != nil || len(reports) != 1 {
+		t.Fatalf("Failed to get saved report: %v", err)
+	}
+	byID, err := storage.GetReportByID(reports[0].ID)
+	if err != nil {
+		t.Fatalf("Failed to get report by ID: %v", err)
+	}
+
+	byExternalID, err := storage.GetReportByExternalID(reportID)
+	if err != nil {
+		t.Fatalf("Failed to get report by external ID: %v", err)
+	}
+	if !reflect.DeepEqual(byExternalID, byID) {
+		t.Errorf("Expected the same report by both IDs:\nby ID:          %+v\nby external ID: %+v", byID, byExternalID)
+	}
+	if !reflect.DeepEqual(byExternalID, feedback) {
+		t.Errorf("Report did not round-trip:\nsaved: %+v\ngot:   %+v", feedback, byExternalID)
+	}
+}
+
+func TestGetReportsByDomain(t *testing.T) {
+	storage, err := NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	saveTestReport(t, storage, testReportXML("a1", "example.com", 1609459200, 1609545600, "none"))
+	saveTestReport(t, storage, testReportXML("a2", "Example.COM", 1609545600, 1609632000, "none"))
+	saveTestReport(t, storage, testReportXML("b1", "example.org", 1609459200, 1609545600, "none"))
+	saveTestReport(t, storage, testReportXML("c1", "example.net", 1609459200, 1609545600, "none"))
+
+	reports, err := storage.GetReportsByDomain("EXAMPLE.com", 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get reports: %v", err)
+	}
+	var ids []string
+	for _, r := range reports {
+		ids = append(ids, r.ReportID)
+	}
+	if !reflect.DeepEqual(ids, []string{"a2", "a1"}) {
+		t.Errorf("Expected reports [a2 a1], got %v", ids)
+	}
+
+	reports, err = storage.GetReportsByDomain("example.com", 1, 1)
+	if err != nil {
+		t.Fatalf("Failed to get reports: %v", err)
+	}
+	if len(reports) != 1 || reports[0].ReportID != "a1" {
+		t.Errorf("Expected second page to hold a1, got %+v", reports)
+	}
+
+	count, err := storage.CountReportsByDomain("EXAMPLE.com")
+	if err != nil {
+		t.Fatalf("Failed to count reports: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 reports for example.com, got %d", count)
+	}
+
+	reports, err = storage.GetReportsByDomain("missing.example", 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get reports: %v", err)
+	}
+	if reports == nil || len(reports) != 0 {
+		t.Errorf("Expected empty list for unknown domain, got %+v", reports)
+	}
+
+	domains, err := storage.ListDomains()
+	if err != nil {
+		t.Fatalf("Failed to list domains: %v", err)
+	}
+	if !reflect.DeepEqual(domains, []string{"example.com", "example.net", "example.org"}) {
+		t.Errorf("Expected sorted, deduplicated domains, got %v", domains)
+	}
+}
+
+func TestGetReportsSince(t *testing.T) {
+	storage, err := NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	saveTestReport(t, storage, testReportXML("old", "example.com", 1609459200, 1609545600, "none"))
+	saveTestReport(t, storage, testReportXML("new", "example.com", 1609545600, 1609632000, "none"))
+	if _, err := storage.db.Exec("UPDATE reports SET created_at = ? WHERE report_id = 'old'", time.Now().Add(-48*time.Hour).Unix()); err != nil {
+		t.Fatalf("Failed to backdate report: %v", err)
+	}
+
+	reports, err := storage.GetReportsSince(time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to get reports: %v", err)
+	}
+	if len(reports) != 1 || reports[0].ReportID != "new" {
+		t.Errorf("Expected only report new, got %+v", reports)
+	}
+
+	plan := queryPlan(t, storage, "SELECT id FROM reports WHERE created_at >= ? ORDER BY created_at DESC", 0)
+	if !strings.Contains(plan, "idx_reports_created_at") {
+		t.Errorf("Expected query plan to use idx_reports_created_at, got %q", plan)
+	}
+}
+
+func TestListReportIDs(t *testing.T) {
+	storage, err := NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	for _, id := range []string{"a", "b", "c"} {
+		saveTestReport(t, storage, testReportXML(id, "example.com", 1609459200, 1609545600, "none"))
+	}
+	if _, err := storage.db.Exec("UPDATE reports SET created_at = ? WHERE report_id = 'a'", time.Now().Add(-48*time.Hour).Unix()); err != nil {
+		t.Fatalf("Failed to backdate report: %v", err)
+	}
+
+	ids, err := storage.ListReportIDs(2, 1)
+	if err != nil {
+		t.Fatalf("Failed to list report IDs: %v", err)
+	}
+	if strings.Join(ids, ",") != "b,c" {
+		t.Errorf("Expected [b c], got %v", ids)
+	}
+
+	ids, err = storage.ListReportIDsSince(time.Now().Add(-time.Hour), 10)
+	if err != nil {
+		t.Fatalf("Failed to list report IDs: %v", err)
+	}
+	if strings.Join(ids, ",") != "b,c" {
+		t.Errorf("Expected [b c], got %v", ids)
+	}
+
+	ids, err = storage.ListReportIDsSince(time.Now().Add(-time.Hour), 1)
+	if err != nil {
+		t.Fatalf("Failed to list report IDs: %v", err)
+	}
+	if strings.Join(ids, ",") != "b" {
+		t.Errorf("Expected [b], got %v", ids)
+	}
+
+	ids, err = storage.ListReportIDs(10, 3)
+	if err != nil {
+		t.Fatalf("Failed to list report IDs: %v", err)
+	}
+	if ids == nil || len(ids) != 0 {
+		t.Errorf("Expected empty non-nil slice, got %#v", ids)
+	}
+}
+
+func TestSaveReport_DeduplicateByContent(t *testing.T) {
+	storage, err := NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	saveTestReport(t, storage, testReportXML("uuid-1", "example.com", 1609459200, 1609545600, "none"))
+
+	// Without deduplication the same content under a new ID is stored
+	saveTestReport(t, storage, testReportXML("uuid-2", "example.com", 1609459200, 1609545600, "none"))
+
+	storage.SetDeduplicateByContent(true)
+	err = storage.SaveReport(parseTestReport(t, testReportXML("uuid-3", "example.com", 1609459200, 1609545600, "none")))
+	if !errors.Is(err, ErrDuplicateContent) {
+		t.Errorf("Expected ErrDuplicateContent, got %v", err)
+	}
+
+	// The same report_id is still ignored silently, and other date ranges
+	// or domains are stored
+	saveTestReport(t, storage, testReportXML("uuid-1", "example.com", 1609459200, 1609545600, "none"))
+	saveTestReport(t, storage, testReportXML("uuid-4", "example.com", 1609545600, 1609632000, "none"))
+	saveTestReport(t, storage, testReportXML("uuid-5", "example.org", 1609459200, 1609545600, "none"))
+
+	total, err := storage.CountReports(ReportFilter{})
+	if err != nil {
+		t.Fatalf("Failed to count reports: %v", err)
+	}
+	if total != 4 {
+		t.Errorf("Expected 4 reports, got %d", total)
+	}
+}
+
+func TestSaveReport_EnvelopeTo(t *testing.T) {
+	storage, err := NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	// Simulate a database created before envelope_to existed
+	downgradeSchema(t, storage, "ALTER TABLE records DROP COLUMN envelope_to")
+	if err := RunMigrations(storage.db); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	xmlData := strings.Replace(
+		testReportXML("envelope", "example.com", 1609459200, 1609545600, "none"),
+		"<header_from>", "<envelope_to>example.net</envelope_to>\n      <header_from>", 1,
+	)
+	saveTestReport(t, storage, xmlData)
+
+	var envelopeTo string
+	if err := storage.db.QueryRow("SELECT envelope_to FROM records").Scan(&envelopeTo); err != nil {
+		t.Fatalf("Failed to query envelope_to: %v", err)
+	}
+	if envelopeTo != "example.net" {
+		t.Errorf("Expected envelope_to example.net, got %s", envelopeTo)
+	}
+}
+
+func TestSaveReport_Source(t *testing.T) {
+	storage, err := NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	// Simulate a database created before reports were tagged with a source
+	downgradeSchema(t, storage, "ALTER TABLE reports DROP COLUMN source")
+	if err := RunMigrations(storage.db); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	saveTestReport(t, storage, testReportXML("untagged", "example.com", 1609459200, 1609545600, "none"))
+	feedback := parseTestReport(t, testReportXML("pushed", "example.com", 1609545600, 1609632000, "none"))
+	if err := storage.SaveReportContext(WithSource(context.Background(), SourceHTTP), feedback); err != nil {
+		t.Fatalf("Failed to save report: %v", err)
+	}
+
+	reports, err := storage.GetReports(ReportFilter{}, 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get reports: %v", err)
+	}
+	sources := map[string]string{}
+	for _, r := range reports {
+		sources[r.ReportID] = r.Source
+	}
+	if sources["pushed"] != SourceHTTP {
+		t.Errorf("Expected source %q for pushed report, got %q", SourceHTTP, sources["pushed"])
+	}
+	if sources["untagged"] != "" {
+		t.Errorf("Expected empty source for untagged report, got %q", sources["untagged"])
+	}
+}
+
+func TestGetFailingRecords(t *testing.T) {
+	storage, err := NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	saveTestReport(t, storage, testReportXML("passing", "example.com", 1609459200, 1609545600, "none"))
+	saveTestReport(t, storage, strings.Replace(
+		testReportXML("rejected", "example.org", 1609459200, 1609545600, "reject"),
+		"<disposition>none</disposition>", "<disposition>reject</disposition>", 1,
+	))
+
+	records, err := storage.GetFailingRecords(10)
+	if err != nil {
+		t.Fatalf("Failed to get failing records: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("Expected 1 failing record, got %d", len(records))
+	}
+	if records[0].ReportDomain != "example.org" || records[0].Disposition != "reject" {
+		t.Errorf("Expected rejected record for example.org, got %+v", records[0])
+	}
+}
+
+func TestGetOverrideReasonStats(t *testing.T) {
+	storage, err := NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	withReasons := func(reportID string, reasons string) string {
+		return strings.Replace(
+			testReportXML(reportID, "example.com", 1609459200, 1609545600, "reject"),
+			"<spf>fail</spf>\n      </policy_evaluated>",
+			"<spf>fail</spf>\n"+reasons+"      </policy_evaluated>", 1,
+		)
+	}
+	saveTestReport(t, storage, withReasons("r1", "<reason><type>forwarded</type><comment>via list</comment></reason>"))
+	saveTestReport(t, storage, withReasons("r2", "<reason><type>Forwarded</type></reason><reason><type>mailing_list</type></reason>"))
+	saveTestReport(t, storage, testReportXML("r3", "example.com", 1609459200, 1609545600, "none"))
+
+	stats, err := storage.GetOverrideReasonStats()
+	if err != nil {
+		t.Fatalf("Failed to get override reason stats: %v", err)
+	}
+	want := []OverrideReasonStats{
+		{Reason: "forwarded", Records: 2, Messages: 20},
+		{Reason: "mailing_list", Records: 1, Messages: 10},
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("Expected %+v, got %+v", want, stats)
+	}
+
+	// Records without overrides are stored with NULL reasons
+	var withoutReasons int
+	if err := storage.db.QueryRow("SELECT COUNT(*) FROM records WHERE override_reasons IS NULL").Scan(&withoutReasons); err != nil {
+		t.Fatalf("Failed to count records: %v", err)
+	}
+	if withoutReasons != 1 {
+		t.Errorf("Expected 1 record without override reasons, got %d", withoutReasons)
+	}
+}
+
+func TestGetOrgMessageStats(t *testing.T) {
+	storage, err := NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	saveTestReport(t, storage, testReportXML("r1", "example.com", 1609459200, 1609545600, "none"))
+	saveTestReport(t, storage, testReportXML("r2", "example.org", 1609459200, 1609545600, "none"))
+
+	stats, err := storage.GetOrgMessageStats()
+	if err != nil {
+		t.Fatalf("Failed to get org message stats: %v", err)
+	}
+	if len(stats) != 1 {
+		t.Fatalf("Expected 1 organization, got %d", len(stats))
+	}
+	if stats[0].OrgName != "google.com" || stats[0].Messages != 20 {
+		t.Errorf("Expected google.com with 20 messages, got %+v", stats[0])
+	}
+}
+
+func TestGetSourceIPStats(t *testing.T) {
+	storage, err := NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	saveTestReport(t, storage, testReportXML("r1", "example.com", 1609459200, 1609545600, "none"))
+	saveTestReport(t, storage, testReportXML("r2", "example.org", 1609459200, 1609545600, "none"))
+
+	stats, err := storage.GetSourceIPStats("192.0.2.1")
+	if err != nil {
+		t.Fatalf("Failed to get source IP stats: %v", err)
+	}
+	if stats.TotalMessages != 20 {
+		t.Errorf("Expected 20 messages, got %d", stats.TotalMessages)
+	}
+	if stats.DKIMPassCount != 20 || stats.SPFPassCount != 0 || stats.BothPassCount != 0 {
+		t.Errorf("Expected 20 DKIM passes and no SPF passes, got %+v", stats)
+	}
+	if stats.FullFailCount != 0 || stats.DMARCCompliantCount != 20 {
+		t.Errorf("Expected 20 compliant messages and no full failures, got %+v", stats)
+	}
+	if stats.DispositionCounts["none"] != 20 {
+		t.Errorf("Expected 20 messages with disposition none, got %v", stats.DispositionCounts)
+	}
+	if len(stats.Domains) != 2 || stats.Domains[0] != "example.com" || stats.Domains[1] != "example.org" {
+		t.Errorf("Expected domains [example.com example.org], got %v", stats.Domains)
+	}
+	if len(stats.Organizations) != 1 || stats.Organizations[0] != "google.com" {
+		t.Errorf("Expected organizations [google.com], got %v", stats.Organizations)
+	}
+
+	if _, err := storage.GetSourceIPStats("198.51.100.1"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected sql.ErrNoRows for unknown IP, got %v", err)
+	}
+}
+
+func TestDatabaseSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db.sqlite")
+	storage, err := NewStorage(path)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	saveTestReport(t, storage, testReportXML("r1", "example.com", 1609459200, 1609545600, "none"))
+
+	size, err := storage.DatabaseSize()
+	if err != nil {
+		t.Fatalf("Failed to get database size: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat database: %v", err)
+	}
+	if size != info.Size() {
+		t.Errorf("Expected size %d, got %d", info.Size(), size)
+	}
+}
+
+func TestBackup(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := NewStorage(filepath.Join(dir, "db.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	saveTestReport(t, storage, testReportXML("r1", "example.com", 1609459200, 1609545600, "none"))
+
+	dest := filepath.Join(dir, "backup.sqlite")
+	if err := storage.Backup(dest); err != nil {
+		t.Fatalf("Failed to back up: %v", err)
+	}
+	if err := storage.Backup(dest); err == nil {
+		t.Error("Expected error backing up over an existing file")
+	}
+
+	backup, err := NewStorage(dest)
+	if err != nil {
+		t.Fatalf("Failed to open backup: %v", err)
+	}
+	defer func() { _ = backup.Close() }()
+
+	stats, err := backup.GetStatistics()
+	if err != nil {
+		t.Fatalf("Failed to get statistics: %v", err)
+	}
+	if stats.TotalReports != 1 {
+		t.Errorf("Expected 1 report in backup, got %d", stats.TotalReports)
+	}
+}
+
+func TestVacuum(t *testing.T) {
+	storage, err := NewStorage(filepath.Join(t.TempDir(), "db.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	saveTestReport(t, storage, testReportXML("r1", "example.com", 1609459200, 1609545600, "none"))
+
+	if err := storage.Vacuum(); err != nil {
+		t.Fatalf("Failed to vacuum: %v", err)
+	}
+
+	rebuilt, err := storage.EnableIncrementalVacuum(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to enable incremental vacuum: %v", err)
+	}
+	if !rebuilt {
+		t.Error("Expected database to be rebuilt when switching mode")
+	}
+
+	var mode int
+	if err := storage.db.QueryRow(`PRAGMA auto_vacuum`).Scan(&mode); err != nil {
+		t.Fatalf("Failed to query auto_vacuum: %v", err)
+	}
+	if mode != 2 {
+		t.Errorf("Expected auto_vacuum 2 (incremental), got %d", mode)
+	}
+
+	rebuilt, err = storage.EnableIncrementalVacuum(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to enable incremental vacuum: %v", err)
+	}
+	if rebuilt {
+		t.Error("Expected no rebuild when already incremental")
+	}
+
+	stats, err := storage.GetStatistics()
+	if err != nil {
+		t.Fatalf("Failed to get statistics: %v", err)
+	}
+	if stats.TotalReports != 1 {
+		t.Errorf("Expected 1 report after vacuum, got %d", stats.TotalReports)
+	}
+}
+
+// feedbackGen generates reports with edge-case values: empty and Unicode
+// strings, large counts and IPv4 or IPv6 source IPs
+func feedbackGen() *rapid.Generator[*parser.Feedback] {
+	text := rapid.OneOf(rapid.Just(""), rapid.String(), rapid.StringMatching(`[a-z0-9.-]{1,30}`))
+	ip := rapid.OneOf(
+		rapid.Custom(func(t *rapid.T) string {
+			return netip.AddrFrom4([4]byte(rapid.SliceOfN(rapid.Byte(), 4, 4).Draw(t, "v4"))).String()
+		}),
+		rapid.Custom(func(t *rapid.T) string {
+			return netip.AddrFrom16([16]byte(rapid.SliceOfN(rapid.Byte(), 16, 16).Draw(t, "v6"))).String()
+		}),
+	)
+	result := rapid.SampledFrom([]string{"pass", "fail", "none", ""})
+
+	record := rapid.Custom(func(t *rapid.T) parser.Record {
+		return parser.Record{
+			Row: parser.Row{
+				SourceIP: ip.Draw(t, "source_ip"),
+				Count:    rapid.IntRange(0, 1<<31).Draw(t, "count"),
+				PolicyEvaluated: parser.PolicyEvaluated{
+					Disposition: rapid.SampledFrom([]string{"none", "quarantine", "reject"}).Draw(t, "disposition"),
+					DKIM:        result.Draw(t, "dkim"),
+					SPF:         result.Draw(t, "spf"),
+				},
+			},
+			Identifiers: parser.Identifiers{
+				HeaderFrom:   text.Draw(t, "header_from"),
+				EnvelopeFrom: text.Draw(t, "envelope_from"),
+				EnvelopeTo:   text.Draw(t, "envelope_to"),
+			},
+		}
+	})
+
+	return rapid.Custom(func(t *rapid.T) *parser.Feedback {
+		return &parser.Feedback{
+			ReportMetadata: parser.ReportMetadata{
+				OrgName:  text.Draw(t, "org_name"),
+				Email:    text.Draw(t, "email"),
+				ReportID: rapid.StringN(1, 64, -1).Draw(t, "report_id"),
+				DateRange: parser.DateRange{
+					Begin: rapid.Int64Range(0, 1<<40).Draw(t, "begin"),
+					End:   rapid.Int64Range(0, 1<<40).Draw(t, "end"),
+				},
+			},
+			PolicyPublished: parser.PolicyPublished{
+				Domain: text.Draw(t, "domain"),
+				P:      rapid.SampledFrom([]string{"none", "quarantine", "reject", ""}).Draw(t, "p"),
+				PCT:    rapid.IntRange(0, 100).Draw(t, "pct"),
+			},
+			Records: rapid.SliceOfN(record, 0, 5).Draw(t, "records"),
+		}
+	})
+}
+
+func TestSaveReportProperties(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		storage, err := NewStorage(":memory:")
+		if err != nil {
+			t.Fatalf("Failed to create storage: %v", err)
+		}
+		defer func() { _ = storage.Close() }()
+
+		feedback := feedbackGen().Draw(t, "feedback")
+
+		// SaveReport only fails on database errors, so every generated
+		// report must save
+		for i := 0; i < 2; i++ {
+			if err := storage.SaveReport(feedback); err != nil {
+				t.Fatalf("Failed to save report (attempt %d): %v", i+1, err)
+			}
+		}
+
+		filter := ReportFilter{ReportID: feedback.ReportMetadata.ReportID}
+		count, err := storage.CountReports(filter)
+		if err != nil {
+			t.Fatalf("Failed to count reports: %v", err)
+		}
+		if count != 1 {
+			t.Fatalf("Expected saving twice to store 1 report, got %d", count)
+		}
+		var records int
+		if err := storage.db.QueryRow(`SELECT COUNT(*) FROM records`).Scan(&records); err != nil {
+			t.Fatalf("Failed to count records: %v", err)
+		}
+		if records != len(feedback.Records) {
+			t.Fatalf("Expected %d records after saving twice, got %d", len(feedback.Records), records)
+		}
+
+		reports, err := storage.GetReports(filter, 1, 0)
+		if err != nil || len(reports) != 1 {
+			t.Fatalf("Failed to get saved report: %v", err)
+		}
+		got, err := storage.GetReportByID(reports[0].ID)
+		if err != nil {
+			t.Fatalf("Failed to get report by ID: %v", err)
+		}
+		if !reflect.DeepEqual(got, feedback) {
+			t.Fatalf("Report did not round-trip:\nsaved: %+v\ngot:   %+v", feedback, got)
+		}
+	})
+}
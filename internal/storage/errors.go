@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Errors returned by Storage methods, so callers can tell a missing row or a
+// rejected write from a database failure with errors.Is. The underlying
+// driver error stays in the chain.
+var (
+	// ErrNotFound means the requested row does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrDuplicate means a write violated a UNIQUE or PRIMARY KEY constraint
+	ErrDuplicate = errors.New("duplicate")
+	// ErrConstraintViolation means a write violated any other constraint,
+	// such as NOT NULL or FOREIGN KEY
+	ErrConstraintViolation = errors.New("constraint violation")
+	// ErrDuplicateContent means a report with another report_id but the
+	// same domain, reporter and date range is already stored, see
+	// SetDeduplicateByContent
+	ErrDuplicateContent = errors.New("duplicate report content")
+)
+
+// wrapError tags err with the matching typed error. Errors that are not a
+// missing row or a constraint failure are returned unchanged.
+func wrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+	if typed := constraintError(err); typed != nil {
+		return fmt.Errorf("%w: %w", typed, err)
+	}
+	return err
+}
+
+// ErrCorrupt means the database file is damaged or is not a SQLite database
+var ErrCorrupt = errors.New("database is corrupted")
+
+// openError describes why the database at path could not be opened or read,
+// telling a missing directory, a permission problem and a corrupted file
+// apart. The returned error matches fs.ErrNotExist, fs.ErrPermission or
+// ErrCorrupt respectively.
+func openError(path string, err error) error {
+	switch kind := openErrorKind(err); {
+	case kind == ErrCorrupt:
+		return fmt.Errorf("database %s is corrupted or not a SQLite database: %w: %w", path, ErrCorrupt, err)
+	case kind == fs.ErrPermission:
+		return fmt.Errorf("database %s: permission denied: %w: %w", path, fs.ErrPermission, err)
+	case kind == fs.ErrNotExist:
+		// SQLite creates a missing file, so failing to open it means the
+		// directory is missing or not accessible
+		dir := filepath.Dir(path)
+		if _, statErr := os.Stat(dir); statErr != nil {
+			if errors.Is(statErr, fs.ErrNotExist) {
+				return fmt.Errorf("database %s: directory %s not found: %w: %w", path, dir, fs.ErrNotExist, err)
+			}
+			if errors.Is(statErr, fs.ErrPermission) {
+				return fmt.Errorf("database %s: permission denied: %w: %w", path, fs.ErrPermission, err)
+			}
+		}
+		if _, statErr := os.Stat(path); errors.Is(statErr, fs.ErrPermission) {
+			return fmt.Errorf("database %s: permission denied: %w: %w", path, fs.ErrPermission, err)
+		}
+	}
+	return fmt.Errorf("open database %s: %w", path, err)
+}
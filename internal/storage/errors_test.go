@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWrapError(t *testing.T) {
+	storage, err := NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	saveTestReport(t, storage, testReportXML("existing", "example.com", 1609459200, 1609545600, "none"))
+
+	insertReport := func(reportID, orgName interface{}) error {
+		_, err := storage.db.Exec(`
+			INSERT INTO reports (report_id, org_name, domain, date_begin, date_end, created_at, raw_report)
+			VALUES (?, ?, 'example.com', 0, 0, 0, '{}')
+		`, reportID, orgName)
+		return wrapError(err)
+	}
+
+	t.Run("duplicate", func(t *testing.T) {
+		err := insertReport("existing", "google.com")
+		if !errors.Is(err, ErrDuplicate) {
+			t.Errorf("Expected ErrDuplicate, got %v", err)
+		}
+		if errors.Is(err, ErrConstraintViolation) {
+			t.Errorf("Expected duplicate not to be reported as ErrConstraintViolation")
+		}
+	})
+
+	t.Run("constraint violation", func(t *testing.T) {
+		err := insertReport("new", nil)
+		if !errors.Is(err, ErrConstraintViolation) {
+			t.Errorf("Expected ErrConstraintViolation, got %v", err)
+		}
+		if errors.Is(err, ErrDuplicate) {
+			t.Errorf("Expected NOT NULL violation not to be reported as ErrDuplicate")
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		err := storage.db.QueryRow("SELECT id FROM reports WHERE report_id = 'missing'").Scan(new(int64))
+		err = wrapError(err)
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Expected sql.ErrNoRows to stay in the chain, got %v", err)
+		}
+	})
+
+	t.Run("other errors", func(t *testing.T) {
+		_, err := storage.db.Exec("SELECT * FROM no_such_table")
+		if wrapped := wrapError(err); wrapped != err {
+			t.Errorf("Expected error to be returned unchanged, got %v", wrapped)
+		}
+		if wrapError(nil) != nil {
+			t.Error("Expected nil for nil error")
+		}
+	})
+}
+
+func TestNotFoundErrors(t *testing.T) {
+	storage, err := NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	if _, err := storage.GetReportByID(999); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetReportByID: expected ErrNotFound, got %v", err)
+	}
+	if _, err := storage.GetReportByExternalID("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetReportByExternalID: expected ErrNotFound, got %v", err)
+	}
+	if _, err := storage.GetDomainPolicy("missing.example"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetDomainPolicy: expected ErrNotFound, got %v", err)
+	}
+	if _, err := storage.GetSourceIPStats("198.51.100.1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetSourceIPStats: expected ErrNotFound, got %v", err)
+	}
+	if err := storage.AcknowledgeAlert(999); !errors.Is(err, ErrNotFound) {
+		t.Errorf("AcknowledgeAlert: expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestNewStorage_OpenErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("missing directory", func(t *testing.T) {
+		_, err := NewStorage(filepath.Join(dir, "missing", "db.sqlite"))
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Expected fs.ErrNotExist, got %v", err)
+		}
+	})
+
+	t.Run("corrupted file", func(t *testing.T) {
+		path := filepath.Join(dir, "corrupt.sqlite")
+		if err := os.WriteFile(path, bytes.Repeat([]byte("not a database "), 512), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		_, err := NewStorage(path)
+		if !errors.Is(err, ErrCorrupt) {
+			t.Errorf("Expected ErrCorrupt, got %v", err)
+		}
+	})
+
+	t.Run("permission denied", func(t *testing.T) {
+		if os.Geteuid() == 0 {
+			t.Skip("file permissions are not enforced for root")
+		}
+		locked := filepath.Join(dir, "locked")
+		if err := os.Mkdir(locked, 0o000); err != nil {
+			t.Fatal(err)
+		}
+		_, err := NewStorage(filepath.Join(locked, "db.sqlite"))
+		if !errors.Is(err, fs.ErrPermission) {
+			t.Errorf("Expected fs.ErrPermission, got %v", err)
+		}
+	})
+}
+
+func TestPing(t *testing.T) {
+	storage, err := NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+
+	if err := storage.Ping(context.Background()); err != nil {
+		t.Errorf("Expected ping to succeed, got %v", err)
+	}
+	_ = storage.Close()
+	if err := storage.Ping(context.Background()); err == nil {
+		t.Error("Expected ping on a closed database to fail")
+	}
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// migrations are the numbered schema changes applied by RunMigrations;
+// migrations[i] brings the schema to version i+1. Version 0 is whatever
+// CREATE TABLE IF NOT EXISTS in init leaves behind, which for databases from
+// releases before versioning lacks the columns added since. Column additions
+// are no-ops when init already created the column. Only ever append: an
+// applied migration is never run again.
+var migrations = []func(tx *sql.Tx) error{
+	// 1: reports can be trashed; GetTrashedReports lists the trash by
+	// deletion time
+	func(tx *sql.Tx) error {
+		if err := addColumnIfMissing(tx, "reports", "deleted_at", "INTEGER"); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_reports_deleted_at ON reports(deleted_at) WHERE deleted_at IS NOT NULL`)
+		return err
+	},
+	// 2: records keep the envelope_to identifier
+	addColumn("records", "envelope_to", "TEXT"),
+	// 3: records keep the policy override reasons
+	addColumn("records", "override_reasons", "TEXT"),
+	// 4: reports remember how they were ingested
+	addColumn("reports", "source", "TEXT"),
+}
+
+// addColumn returns a migration adding column to table
+func addColumn(table, column, decl string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		return addColumnIfMissing(tx, table, column, decl)
+	}
+}
+
+// addColumnIfMissing adds column to table unless it already exists
+func addColumnIfMissing(tx *sql.Tx, table, column, decl string) error {
+	var count int
+	err := tx.QueryRow("SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", table, column).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("inspect %s columns: %w", table, err)
+	}
+	if count > 0 {
+		return nil
+	}
+	if _, err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, decl)); err != nil {
+		return fmt.Errorf("add column %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+// RunMigrations applies the migrations above the database's user_version,
+// each in its own transaction, and records them in schema_migrations. It
+// fails on a database migrated by a newer build.
+func RunMigrations(db *sql.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			applied_at INTEGER NOT NULL
+		)
+	`); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	current, err := schemaVersion(db)
+	if err != nil {
+		return err
+	}
+	if current > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than the latest known version %d", current, len(migrations))
+	}
+
+	for i := current; i < len(migrations); i++ {
+		if err := applyMigration(db, i+1, migrations[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyMigration runs migration version and records it
+func applyMigration(db *sql.DB, version int, migrate func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin migration %d: %w", version, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := migrate(tx); err != nil {
+		return fmt.Errorf("apply migration %d: %w", version, err)
+	}
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, version, time.Now().Unix()); err != nil {
+		return fmt.Errorf("record migration %d: %w", version, err)
+	}
+	// PRAGMA does not take bound parameters
+	if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", version)); err != nil {
+		return fmt.Errorf("set schema version %d: %w", version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit migration %d: %w", version, err)
+	}
+	return nil
+}
+
+// schemaVersion returns the user_version pragma of db
+func schemaVersion(db *sql.DB) (int, error) {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return 0, fmt.Errorf("query schema version: %w", err)
+	}
+	return version, nil
+}
+
+// SchemaVersion returns the number of the last migration applied to the
+// database
+func (s *Storage) SchemaVersion() (int, error) {
+	return schemaVersion(s.db)
+}
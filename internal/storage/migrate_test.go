@@ -0,0 +1,147 @@
+package storage
+
+import "testing"
+
+// appliedMigrations returns the versions recorded in schema_migrations
+func appliedMigrations(t *testing.T, s *Storage) []int {
+	t.Helper()
+	rows, err := s.db.Query("SELECT version FROM schema_migrations ORDER BY version")
+	if err != nil {
+		t.Fatalf("Failed to query schema_migrations: %v", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var versions []int
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			t.Fatalf("Failed to scan version: %v", err)
+		}
+		versions = append(versions, v)
+	}
+	return versions
+}
+
+func assertSchemaCurrent(t *testing.T, s *Storage) {
+	t.Helper()
+	version, err := s.SchemaVersion()
+	if err != nil {
+		t.Fatalf("Failed to get schema version: %v", err)
+	}
+	if version != len(migrations) {
+		t.Errorf("Expected schema version %d, got %d", len(migrations), version)
+	}
+	if applied := appliedMigrations(t, s); len(applied) != len(migrations) {
+		t.Errorf("Expected %d recorded migrations, got %v", len(migrations), applied)
+	}
+}
+
+// downgradeSchema runs stmts and resets the schema version to simulate a
+// database created before schema versioning
+func downgradeSchema(t *testing.T, s *Storage, stmts string) {
+	t.Helper()
+	if _, err := s.db.Exec(stmts + "; DELETE FROM schema_migrations; PRAGMA user_version = 0"); err != nil {
+		t.Fatalf("Failed to downgrade schema: %v", err)
+	}
+}
+
+func TestRunMigrations(t *testing.T) {
+	t.Run("fresh install", func(t *testing.T) {
+		storage, err := NewStorage(":memory:")
+		if err != nil {
+			t.Fatalf("Failed to create storage: %v", err)
+		}
+		defer func() { _ = storage.Close() }()
+
+		assertSchemaCurrent(t, storage)
+	})
+
+	t.Run("upgrade from v0", func(t *testing.T) {
+		storage, err := NewStorage(":memory:")
+		if err != nil {
+			t.Fatalf("Failed to create storage: %v", err)
+		}
+		defer func() { _ = storage.Close() }()
+
+		// Simulate a database created before schema versioning
+		if _, err := storage.db.Exec(`
+			DROP TABLE schema_migrations;
+			DROP INDEX idx_reports_deleted_at;
+			ALTER TABLE reports DROP COLUMN deleted_at;
+			ALTER TABLE reports DROP COLUMN source;
+			ALTER TABLE records DROP COLUMN envelope_to;
+			ALTER TABLE records DROP COLUMN override_reasons;
+			PRAGMA user_version = 0;
+		`); err != nil {
+			t.Fatalf("Failed to reset schema version: %v", err)
+		}
+		if _, err := storage.db.Exec(`INSERT INTO reports (report_id, org_name, domain, date_begin, date_end, created_at, raw_report)
+			VALUES ('before-upgrade', 'Example Org', 'example.com', 1609459200, 1609545600, 1609545600, '')`); err != nil {
+			t.Fatalf("Failed to insert report: %v", err)
+		}
+
+		if err := RunMigrations(storage.db); err != nil {
+			t.Fatalf("Failed to run migrations: %v", err)
+		}
+		assertSchemaCurrent(t, storage)
+
+		var index int
+		if err := storage.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = 'idx_reports_deleted_at'").Scan(&index); err != nil {
+			t.Fatalf("Failed to query indexes: %v", err)
+		}
+		if index != 1 {
+			t.Error("Expected migration 1 to create idx_reports_deleted_at")
+		}
+		for _, c := range []struct{ table, column string }{
+			{"reports", "deleted_at"},
+			{"reports", "source"},
+			{"records", "envelope_to"},
+			{"records", "override_reasons"},
+		} {
+			var n int
+			if err := storage.db.QueryRow("SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", c.table, c.column).Scan(&n); err != nil {
+				t.Fatalf("Failed to inspect columns: %v", err)
+			}
+			if n != 1 {
+				t.Errorf("Expected migrations to add %s.%s", c.table, c.column)
+			}
+		}
+		count, err := storage.CountReports(ReportFilter{})
+		if err != nil {
+			t.Fatalf("Failed to count reports: %v", err)
+		}
+		if count != 1 {
+			t.Errorf("Expected the report to survive the upgrade, got %d reports", count)
+		}
+	})
+
+	t.Run("idempotent", func(t *testing.T) {
+		storage, err := NewStorage(":memory:")
+		if err != nil {
+			t.Fatalf("Failed to create storage: %v", err)
+		}
+		defer func() { _ = storage.Close() }()
+
+		for i := 0; i < 2; i++ {
+			if err := RunMigrations(storage.db); err != nil {
+				t.Fatalf("Failed to run migrations again: %v", err)
+			}
+		}
+		assertSchemaCurrent(t, storage)
+	})
+
+	t.Run("newer database", func(t *testing.T) {
+		storage, err := NewStorage(":memory:")
+		if err != nil {
+			t.Fatalf("Failed to create storage: %v", err)
+		}
+		defer func() { _ = storage.Close() }()
+
+		if _, err := storage.db.Exec("PRAGMA user_version = 1000"); err != nil {
+			t.Fatalf("Failed to set schema version: %v", err)
+		}
+		if err := RunMigrations(storage.db); err == nil {
+			t.Error("Expected an error for a database migrated by a newer build")
+		}
+	})
+}
@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/goccy/go-json"
+
+	"github.com/meysam81/parse-dmarc/internal/parser"
+)
+
+// ReportColumns are the values derived from a report when it is saved
+type ReportColumns struct {
+	OrgName           string `json:"org_name"`
+	Email             string `json:"email"`
+	Domain            string `json:"domain"`
+	DateBegin         int64  `json:"date_begin"`
+	DateEnd           int64  `json:"date_end"`
+	PolicyP           string `json:"policy_p"`
+	PolicySP          string `json:"policy_sp"`
+	PolicyPCT         int    `json:"policy_pct"`
+	TotalMessages     int    `json:"total_messages"`
+	CompliantMessages int    `json:"compliant_messages"`
+	Records           int    `json:"records"`
+}
+
+// ReportColumnsFor returns the columns SaveReport would store for feedback
+func ReportColumnsFor(feedback *parser.Feedback) ReportColumns {
+	return ReportColumns{
+		OrgName:           feedback.ReportMetadata.OrgName,
+		Email:             feedback.ReportMetadata.Email,
+		Domain:            feedback.PolicyPublished.Domain,
+		DateBegin:         feedback.ReportMetadata.DateRange.Begin,
+		DateEnd:           feedback.ReportMetadata.DateRange.End,
+		PolicyP:           feedback.PolicyPublished.P,
+		PolicySP:          feedback.PolicyPublished.SP,
+		PolicyPCT:         feedback.PolicyPublished.PCT,
+		TotalMessages:     feedback.GetTotalMessages(),
+		CompliantMessages: feedback.GetDMARCCompliantCount(),
+		Records:           len(feedback.Records),
+	}
+}
+
+// Diff returns the JSON names of the columns that differ between c and other
+func (c ReportColumns) Diff(other ReportColumns) []string {
+	var changed []string
+	add := func(name string, differs bool) {
+		if differs {
+			changed = append(changed, name)
+		}
+	}
+	add("org_name", c.OrgName != other.OrgName)
+	add("email", c.Email != other.Email)
+	add("domain", c.Domain != other.Domain)
+	add("date_begin", c.DateBegin != other.DateBegin)
+	add("date_end", c.DateEnd != other.DateEnd)
+	add("policy_p", c.PolicyP != other.PolicyP)
+	add("policy_sp", c.PolicySP != other.PolicySP)
+	add("policy_pct", c.PolicyPCT != other.PolicyPCT)
+	add("total_messages", c.TotalMessages != other.TotalMessages)
+	add("compliant_messages", c.CompliantMessages != other.CompliantMessages)
+	add("records", c.Records != other.Records)
+	return changed
+}
+
+// StoredReport is a saved report with its raw JSON and stored columns
+type StoredReport struct {
+	ID       int64
+	ReportID string
+	Feedback *parser.Feedback
+	Columns  ReportColumns
+}
+
+// GetStoredReports returns up to limit reports matching filter with a row
+// ID above afterID, in ID order, so callers can page through every report
+func (s *Storage) GetStoredReports(filter ReportFilter, afterID int64, limit int) ([]StoredReport, error) {
+	where, args := filter.where()
+	if where == "" {
+		where = "WHERE id > ?"
+	} else {
+		where += " AND id > ?"
+	}
+	args = append(args, afterID, limit)
+
+	rows, err := s.db.Query(`
+		SELECT id, report_id, raw_report,
+		       org_name, COALESCE(email, ''), domain,
+		       date_begin, date_end,
+		       COALESCE(policy_p, ''), COALESCE(policy_sp, ''), COALESCE(policy_pct, 0),
+		       total_messages, compliant_messages,
+		       (SELECT COUNT(*) FROM records WHERE records.report_id = reports.id)
+		FROM reports
+		`+where+`
+		ORDER BY id
+		LIMIT ?
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query stored reports: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	reports := []StoredReport{}
+	for rows.Next() {
+		var r StoredReport
+		var raw string
+		c := &r.Columns
+		if err := rows.Scan(
+			&r.ID, &r.ReportID, &raw,
+			&c.OrgName, &c.Email, &c.Domain,
+			&c.DateBegin, &c.DateEnd,
+			&c.PolicyP, &c.PolicySP, &c.PolicyPCT,
+			&c.TotalMessages, &c.CompliantMessages,
+			&c.Records,
+		); err != nil {
+			return nil, fmt.Errorf("scan stored report: %w", err)
+		}
+
+		var feedback parser.Feedback
+		if err := json.Unmarshal([]byte(raw), &feedback); err != nil {
+			return nil, fmt.Errorf("unmarshal report %s: %w", r.ReportID, err)
+		}
+		r.Feedback = &feedback
+		reports = append(reports, r)
+	}
+	return reports, rows.Err()
+}
+
+// ReportUpdate replaces the stored content of the report with row ID ID
+type ReportUpdate struct {
+	ID       int64
+	Feedback *parser.Feedback
+}
+
+// UpdateReports rewrites the columns, raw JSON and records of each report
+// in a single transaction
+func (s *Storage) UpdateReports(ctx context.Context, updates []ReportUpdate) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, u := range updates {
+		feedback := u.Feedback
+		rawReport, err := json.Marshal(feedback)
+		if err != nil {
+			return fmt.Errorf("failed to marshal report: %w", err)
+		}
+
+		_, err = tx.Exec(`
+			UPDATE reports SET
+				org_name = ?, email = ?, domain = ?,
+				date_begin = ?, date_end = ?,
+				policy_p = ?, policy_sp = ?, policy_pct = ?,
+				total_messages = ?, compliant_messages = ?,
+				raw_report = ?
+			WHERE id = ?
+		`,
+			feedback.ReportMetadata.OrgName,
+			feedback.ReportMetadata.Email,
+			feedback.PolicyPublished.Domain,
+			feedback.ReportMetadata.DateRange.Begin,
+			feedback.ReportMetadata.DateRange.End,
+			feedback.PolicyPublished.P,
+			feedback.PolicyPublished.SP,
+			feedback.PolicyPublished.PCT,
+			feedback.GetTotalMessages(),
+			feedback.GetDMARCCompliantCount(),
+			rawReport,
+			u.ID,
+		)
+		if err != nil {
+			return fmt.Errorf("update report %s: %w", feedback.ReportMetadata.ReportID, wrapError(err))
+		}
+
+		if _, err := tx.Exec(`DELETE FROM records WHERE report_id = ?`, u.ID); err != nil {
+			return fmt.Errorf("delete records of report %s: %w", feedback.ReportMetadata.ReportID, err)
+		}
+		if err := insertRecords(tx, u.ID, feedback.Records); err != nil {
+			return err
+		}
+
+		if err := appendAudit(ctx, tx, AuditOpReplay, feedback.ReportMetadata.ReportID, ""); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	// Replacing records frees pages
+	return s.reclaimFreePages()
+}
@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/meysam81/parse-dmarc/internal/parser"
+)
+
+func TestUpdateReports(t *testing.T) {
+	storage, err := NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	saveTestReport(t, storage, testReportXML("r1", "example.com", 1609459200, 1609545600, "none"))
+	saveTestReport(t, storage, testReportXML("r2", "example.org", 1609459200, 1609545600, "none"))
+
+	// Simulate a column written by an older, buggy version
+	if _, err := storage.db.Exec(`UPDATE reports SET total_messages = 99 WHERE report_id = 'r1'`); err != nil {
+		t.Fatalf("Failed to corrupt report: %v", err)
+	}
+
+	reports, err := storage.GetStoredReports(ReportFilter{Domain: "example.com"}, 0, 10)
+	if err != nil {
+		t.Fatalf("Failed to get stored reports: %v", err)
+	}
+	if len(reports) != 1 {
+		t.Fatalf("Expected 1 report, got %d", len(reports))
+	}
+	r := reports[0]
+	if r.Columns.Records != 1 {
+		t.Errorf("Expected 1 record, got %d", r.Columns.Records)
+	}
+	changed := ReportColumnsFor(r.Feedback).Diff(r.Columns)
+	if len(changed) != 1 || changed[0] != "total_messages" {
+		t.Fatalf("Expected total_messages to differ, got %v", changed)
+	}
+
+	ctx := WithActor(context.Background(), "replay")
+	if err := storage.UpdateReports(ctx, []ReportUpdate{{ID: r.ID, Feedback: r.Feedback}}); err != nil {
+		t.Fatalf("Failed to update reports: %v", err)
+	}
+
+	reports, err = storage.GetStoredReports(ReportFilter{Domain: "example.com"}, 0, 10)
+	if err != nil {
+		t.Fatalf("Failed to get stored reports: %v", err)
+	}
+	if got := reports[0].Columns; got.TotalMessages != 10 || got.Records != 1 {
+		t.Errorf("Expected 10 messages in 1 record after update, got %+v", got)
+	}
+
+	entries, err := storage.GetAuditLog(AuditFilter{}, 10)
+	if err != nil {
+		t.Fatalf("Failed to get audit log: %v", err)
+	}
+	if len(entries) == 0 || entries[0].Operation != AuditOpReplay || entries[0].Actor != "replay" {
+		t.Errorf("Expected replay audit entry by replay, got %+v", entries)
+	}
+}
+
+func TestGetStoredReports_Paging(t *testing.T) {
+	storage, err := NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	saveTestReport(t, storage, testReportXML("r1", "example.com", 1609459200, 1609545600, "none"))
+	saveTestReport(t, storage, testReportXML("r2", "example.com", 1612137600, 1612224000, "none"))
+	saveTestReport(t, storage, testReportXML("r3", "example.com", 1614556800, 1614643200, "none"))
+
+	first, err := storage.GetStoredReports(ReportFilter{From: 1612137600}, 0, 1)
+	if err != nil {
+		t.Fatalf("Failed to get stored reports: %v", err)
+	}
+	if len(first) != 1 || first[0].ReportID != "r2" {
+		t.Fatalf("Expected r2 first, got %+v", first)
+	}
+
+	rest, err := storage.GetStoredReports(ReportFilter{From: 1612137600}, first[0].ID, 10)
+	if err != nil {
+		t.Fatalf("Failed to get stored reports: %v", err)
+	}
+	if len(rest) != 1 || rest[0].ReportID != "r3" {
+		t.Errorf("Expected only r3 after r2, got %+v", rest)
+	}
+}
+
+func TestUpdateReports_ReclaimsFreePages(t *testing.T) {
+	storage, err := NewStorage(filepath.Join(t.TempDir(), "db.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	ctx := context.Background()
+	if _, err := storage.EnableIncrementalVacuum(ctx); err != nil {
+		t.Fatalf("Failed to enable incremental vacuum: %v", err)
+	}
+	saveTestReport(t, storage, testReportXML("r1", "example.com", 1609459200, 1609545600, "none"))
+
+	reports, err := storage.GetStoredReports(ReportFilter{}, 0, 10)
+	if err != nil || len(reports) != 1 {
+		t.Fatalf("Failed to get stored reports: %v", err)
+	}
+	r := reports[0]
+	record := r.Feedback.Records[0]
+
+	// Grow the records table, then shrink it again to free its pages
+	for _, n := range []int{5000, 1} {
+		r.Feedback.Records = make([]parser.Record, n)
+		for i := range r.Feedback.Records {
+			r.Feedback.Records[i] = record
+		}
+		if err := storage.UpdateReports(ctx, []ReportUpdate{{ID: r.ID, Feedback: r.Feedback}}); err != nil {
+			t.Fatalf("Failed to update reports: %v", err)
+		}
+	}
+
+	var free int
+	if err := storage.db.QueryRow(`PRAGMA freelist_count`).Scan(&free); err != nil {
+		t.Fatalf("Failed to query freelist_count: %v", err)
+	}
+	if free != 0 {
+		t.Errorf("Expected freed pages to be reclaimed, got %d on the free list", free)
+	}
+}
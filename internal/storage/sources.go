@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RecordFetch stores t as the time of the last successful fetch from source
+func (s *Storage) RecordFetch(source string, t time.Time) error {
+	_, err := s.db.Exec(`
+		INSERT INTO source_fetches (source, last_success_at) VALUES (?, ?)
+		ON CONFLICT (source) DO UPDATE SET last_success_at = excluded.last_success_at
+	`, source, t.Unix())
+	if err != nil {
+		return fmt.Errorf("record fetch for %s: %w", source, wrapError(err))
+	}
+	return nil
+}
+
+// GetLastFetch returns the time of the last successful fetch from source,
+// or the zero time if it was never fetched
+func (s *Storage) GetLastFetch(source string) (time.Time, error) {
+	var lastSuccess int64
+	err := s.db.QueryRow(`
+		SELECT last_success_at FROM source_fetches WHERE source = ?
+	`, source).Scan(&lastSuccess)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("query last fetch for %s: %w", source, err)
+	}
+	return time.Unix(lastSuccess, 0), nil
+}
@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordFetch(t *testing.T) {
+	storage, err := NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	last, err := storage.GetLastFetch("imap://dmarc@mail.example.com:993/INBOX")
+	if err != nil {
+		t.Fatalf("Failed to get last fetch: %v", err)
+	}
+	if !last.IsZero() {
+		t.Errorf("Expected zero time for a source never fetched, got %v", last)
+	}
+
+	for _, ts := range []int64{1700000000, 1700000600} {
+		if err := storage.RecordFetch("imap://dmarc@mail.example.com:993/INBOX", time.Unix(ts, 0)); err != nil {
+			t.Fatalf("Failed to record fetch: %v", err)
+		}
+	}
+
+	last, err = storage.GetLastFetch("imap://dmarc@mail.example.com:993/INBOX")
+	if err != nil {
+		t.Fatalf("Failed to get last fetch: %v", err)
+	}
+	if last.Unix() != 1700000600 {
+		t.Errorf("Expected last fetch 1700000600, got %d", last.Unix())
+	}
+}
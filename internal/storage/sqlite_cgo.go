@@ -3,10 +3,13 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"io/fs"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
 // NewStorage creates a new storage instance
@@ -16,7 +19,11 @@ func NewStorage(dbPath string) (*Storage, error) {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	storage := &Storage{db: db}
+	storage := &Storage{db: db, path: dbPath}
+	if err := storage.Ping(context.Background()); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
 	if err := storage.init(); err != nil {
 		return nil, fmt.Errorf("initialize database schema: %w", err)
 	}
@@ -41,7 +48,9 @@ func (s *Storage) init() error {
 		policy_pct INTEGER,
 		total_messages INTEGER,
 		compliant_messages INTEGER,
-		raw_report TEXT NOT NULL
+		raw_report TEXT NOT NULL,
+		source TEXT,
+		deleted_at INTEGER
 	);
 
 	CREATE TABLE IF NOT EXISTS records (
@@ -54,20 +63,103 @@ func (s *Storage) init() error {
 		spf_result TEXT,
 		header_from TEXT,
 		envelope_from TEXT,
+		envelope_to TEXT,
 		dkim_domains TEXT,
 		spf_domains TEXT,
+		override_reasons TEXT,
+		FOREIGN KEY (report_id) REFERENCES reports(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS alerts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		domain TEXT NOT NULL,
+		threshold_type TEXT NOT NULL,
+		threshold_value REAL NOT NULL,
+		value REAL NOT NULL,
+		triggered_at INTEGER NOT NULL,
+		report_id TEXT NOT NULL,
+		acknowledged INTEGER NOT NULL DEFAULT 0,
+		UNIQUE (domain, threshold_type, threshold_value, report_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS audit_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		operation TEXT NOT NULL,
+		report_id TEXT NOT NULL,
+		actor TEXT NOT NULL,
+		timestamp INTEGER NOT NULL,
+		details TEXT
+	);
+
+	CREATE TABLE IF NOT EXISTS annotations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		report_id INTEGER NOT NULL,
+		author TEXT NOT NULL,
+		body TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
 		FOREIGN KEY (report_id) REFERENCES reports(id)
 	);
 
+	CREATE TABLE IF NOT EXISTS source_fetches (
+		source TEXT PRIMARY KEY,
+		last_success_at INTEGER NOT NULL
+	);
+
 	CREATE INDEX IF NOT EXISTS idx_reports_date_begin ON reports(date_begin);
 	CREATE INDEX IF NOT EXISTS idx_reports_domain ON reports(domain);
+	CREATE INDEX IF NOT EXISTS idx_reports_domain_date ON reports(domain, date_begin);
+	-- GetReportsSince filters and orders by created_at
+	CREATE INDEX IF NOT EXISTS idx_reports_created_at ON reports(created_at DESC);
+	-- GetOrgStats groups by org_name
+	CREATE INDEX IF NOT EXISTS idx_reports_org_name ON reports(org_name);
 	CREATE INDEX IF NOT EXISTS idx_records_report_id ON records(report_id);
 	CREATE INDEX IF NOT EXISTS idx_records_source_ip ON records(source_ip);
+	CREATE INDEX IF NOT EXISTS idx_records_source_ip_disposition ON records(source_ip, disposition);
+	CREATE INDEX IF NOT EXISTS idx_records_disposition ON records(disposition);
+	CREATE INDEX IF NOT EXISTS idx_alerts_triggered_at ON alerts(triggered_at);
+	CREATE INDEX IF NOT EXISTS idx_audit_log_timestamp ON audit_log(timestamp);
+	CREATE INDEX IF NOT EXISTS idx_annotations_report_id ON annotations(report_id, created_at);
 	`
 
 	if _, err := s.db.Exec(schema); err != nil {
 		return fmt.Errorf("exec schema: %w", err)
 	}
 
+	if err := RunMigrations(s.db); err != nil {
+		return fmt.Errorf("run migrations: %w", err)
+	}
+
+	return nil
+}
+
+// constraintError returns ErrDuplicate or ErrConstraintViolation if err is a
+// SQLite constraint failure, and nil otherwise
+func constraintError(err error) error {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) || sqliteErr.Code != sqlite3.ErrConstraint {
+		return nil
+	}
+	switch sqliteErr.ExtendedCode {
+	case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+		return ErrDuplicate
+	}
+	return ErrConstraintViolation
+}
+
+// openErrorKind returns ErrCorrupt, fs.ErrPermission or fs.ErrNotExist if err
+// is a SQLite failure to open or read the database file, and nil otherwise
+func openErrorKind(err error) error {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return nil
+	}
+	switch sqliteErr.Code {
+	case sqlite3.ErrCorrupt, sqlite3.ErrNotADB:
+		return ErrCorrupt
+	case sqlite3.ErrPerm, sqlite3.ErrReadonly, sqlite3.ErrAuth:
+		return fs.ErrPermission
+	case sqlite3.ErrCantOpen:
+		return fs.ErrNotExist
+	}
 	return nil
 }
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SoftDeleteReport moves a report to the trash, attributing the write to
+// ActorSystem
+func (s *Storage) SoftDeleteReport(id int64) error {
+	return s.SoftDeleteReportContext(context.Background(), id)
+}
+
+// SoftDeleteReportContext moves the report with row ID id to the trash under
+// the actor carried by ctx. Trashed reports keep their records and audit
+// trail but are left out of every query except GetTrashedReports until
+// restored. The error wraps ErrNotFound if no such report is outside the
+// trash.
+func (s *Storage) SoftDeleteReportContext(ctx context.Context, id int64) error {
+	return s.setTrashed(ctx, id, true)
+}
+
+// RestoreReport moves a report out of the trash, attributing the write to
+// ActorSystem
+func (s *Storage) RestoreReport(id int64) error {
+	return s.RestoreReportContext(context.Background(), id)
+}
+
+// RestoreReportContext moves the report with row ID id out of the trash
+// under the actor carried by ctx. The error wraps ErrNotFound if no such
+// report is in the trash.
+func (s *Storage) RestoreReportContext(ctx context.Context, id int64) error {
+	return s.setTrashed(ctx, id, false)
+}
+
+// setTrashed sets or clears deleted_at on a report and audits the change
+func (s *Storage) setTrashed(ctx context.Context, id int64, trashed bool) error {
+	state, op := "deleted_at IS NULL", AuditOpDelete
+	var deletedAt interface{} = time.Now().Unix()
+	if !trashed {
+		state, op, deletedAt = "deleted_at IS NOT NULL", AuditOpRestore, nil
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var reportID string
+	if err := tx.QueryRow("SELECT report_id FROM reports WHERE id = ? AND "+state, id).Scan(&reportID); err != nil {
+		return fmt.Errorf("query report %d: %w", id, wrapError(err))
+	}
+
+	if _, err := tx.Exec("UPDATE reports SET deleted_at = ? WHERE id = ?", deletedAt, id); err != nil {
+		return fmt.Errorf("%s report %d: %w", op, id, wrapError(err))
+	}
+	if err := appendAudit(ctx, tx, op, reportID, ""); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetTrashedReports returns reports in the trash, most recently deleted
+// first
+func (s *Storage) GetTrashedReports(limit, offset int) ([]ReportSummary, error) {
+	rows, err := s.db.Query(`
+		SELECT id, report_id, org_name, domain,
+		       date_begin, date_end,
+		       total_messages, compliant_messages,
+		       policy_p, COALESCE(source, ''), deleted_at
+		FROM reports
+		WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id DESC
+		LIMIT ? OFFSET ?
+	`, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("query trashed reports: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	return scanReportSummaries(rows)
+}
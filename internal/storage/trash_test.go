@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSoftDeleteReport(t *testing.T) {
+	storage, err := NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	// Simulate a database created before reports could be trashed
+	downgradeSchema(t, storage, "DROP INDEX idx_reports_deleted_at; ALTER TABLE reports DROP COLUMN deleted_at")
+	if err := RunMigrations(storage.db); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	saveTestReport(t, storage, testReportXML("keep", "example.com", 1609459200, 1609545600, "none"))
+	saveTestReport(t, storage, testReportXML("spam", "spam.example", 1609459200, 1609545600, "none"))
+
+	reports, err := storage.GetReports(ReportFilter{ReportID: "spam"}, 1, 0)
+	if err != nil || len(reports) != 1 {
+		t.Fatalf("Failed to get saved report: %v", err)
+	}
+	id := reports[0].ID
+
+	if err := storage.SoftDeleteReportContext(WithActor(context.Background(), "alice"), id); err != nil {
+		t.Fatalf("Failed to soft-delete report: %v", err)
+	}
+	if err := storage.SoftDeleteReport(id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound deleting a trashed report, got %v", err)
+	}
+
+	reports, err = storage.GetReports(ReportFilter{}, 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get reports: %v", err)
+	}
+	if len(reports) != 1 || reports[0].ReportID != "keep" {
+		t.Errorf("Expected only report keep, got %+v", reports)
+	}
+	if _, err := storage.GetReportByID(id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for trashed report, got %v", err)
+	}
+
+	stats, err := storage.GetStatistics()
+	if err != nil {
+		t.Fatalf("Failed to get statistics: %v", err)
+	}
+	if stats.TotalReports != 1 || stats.UniqueDomains != 1 || stats.TotalMessages != 10 {
+		t.Errorf("Expected statistics of one report, got %+v", stats)
+	}
+	dispositions, err := storage.GetDispositionStats()
+	if err != nil {
+		t.Fatalf("Failed to get disposition stats: %v", err)
+	}
+	if len(dispositions) != 1 || dispositions[0].Count != 10 {
+		t.Errorf("Expected records of trashed report to be excluded, got %+v", dispositions)
+	}
+
+	trashed, err := storage.GetTrashedReports(10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get trashed reports: %v", err)
+	}
+	if len(trashed) != 1 || trashed[0].ReportID != "spam" || trashed[0].DeletedAt == 0 {
+		t.Errorf("Expected report spam in the trash, got %+v", trashed)
+	}
+
+	if err := storage.RestoreReport(id); err != nil {
+		t.Fatalf("Failed to restore report: %v", err)
+	}
+	if err := storage.RestoreReport(id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound restoring a report not in the trash, got %v", err)
+	}
+	count, err := storage.CountReports(ReportFilter{})
+	if err != nil {
+		t.Fatalf("Failed to count reports: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 reports after restore, got %d", count)
+	}
+	trashed, err = storage.GetTrashedReports(10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get trashed reports: %v", err)
+	}
+	if len(trashed) != 0 {
+		t.Errorf("Expected empty trash after restore, got %+v", trashed)
+	}
+
+	entries, err := storage.GetAuditLog(AuditFilter{}, 10)
+	if err != nil {
+		t.Fatalf("Failed to get audit log: %v", err)
+	}
+	ops := map[string]string{}
+	for _, e := range entries {
+		if e.ReportID == "spam" {
+			ops[e.Operation] = e.Actor
+		}
+	}
+	if ops[AuditOpDelete] != "alice" || ops[AuditOpRestore] != ActorSystem {
+		t.Errorf("Expected delete by alice and restore by system in audit log, got %v", ops)
+	}
+}
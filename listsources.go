@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/meysam81/parse-dmarc/internal/cli/output"
+	"github.com/meysam81/parse-dmarc/internal/config"
+	"github.com/meysam81/parse-dmarc/internal/storage"
+	"github.com/urfave/cli/v3"
+)
+
+// reportSource is a configured place reports are fetched from
+type reportSource struct {
+	Type      string `json:"type"`
+	Address   string `json:"address"`
+	Reachable bool   `json:"reachable"`
+	Error     string `json:"error,omitempty"`
+	// LastFetch is the Unix time of the last successful fetch, or 0
+	LastFetch int64 `json:"last_fetch"`
+}
+
+func listSourcesCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "list-sources",
+		Usage: "List configured report sources, whether they are reachable and when they were last fetched",
+		Description: "Reachability is checked by opening a TCP connection, without logging in or\n" +
+			"reading mail, so the command is safe to run at any time.",
+		Flags: []cli.Flag{
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "How long to wait for each source to accept a connection",
+				Value: 5 * time.Second,
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			store, err := storage.NewStorage(cfg.Database.Path)
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+			defer func() { _ = store.Close() }()
+
+			sources := []reportSource{}
+			if cfg.IMAP.Host != "" {
+				source := reportSource{Type: "imap", Address: imapSource(&cfg.IMAP)}
+				dialer := net.Dialer{Timeout: cmd.Duration("timeout")}
+				addr := net.JoinHostPort(cfg.IMAP.Host, strconv.Itoa(cfg.IMAP.Port))
+				if conn, err := dialer.DialContext(ctx, "tcp", addr); err != nil {
+					source.Error = err.Error()
+				} else {
+					_ = conn.Close()
+					source.Reachable = true
+				}
+				last, err := store.GetLastFetch(source.Address)
+				if err != nil {
+					return fmt.Errorf("failed to read last fetch: %w", err)
+				}
+				if !last.IsZero() {
+					source.LastFetch = last.Unix()
+				}
+				sources = append(sources, source)
+			}
+
+			out := output.New(os.Stdout, cmd.String("output"))
+			if len(sources) == 0 {
+				_ = out.Text("No report sources configured")
+				return out.JSON(sources)
+			}
+
+			rows := make([][]string, 0, len(sources))
+			for _, s := range sources {
+				status := "reachable"
+				if !s.Reachable {
+					status = "unreachable: " + s.Error
+				}
+				lastFetch := "never"
+				if s.LastFetch > 0 {
+					lastFetch = time.Unix(s.LastFetch, 0).UTC().Format(time.RFC3339)
+				}
+				rows = append(rows, []string{s.Type, s.Address, status, lastFetch})
+			}
+			_ = out.Table([]string{"TYPE", "ADDRESS", "STATUS", "LAST FETCH"}, rows)
+			return out.JSON(sources)
+		},
+	}
+}
+
+// imapSource identifies an IMAP mailbox in the source_fetches table
+func imapSource(cfg *config.IMAPConfig) string {
+	return fmt.Sprintf("imap://%s@%s/%s", cfg.Username, net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)), cfg.Mailbox)
+}
@@ -2,24 +2,34 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/rand/v2"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/meysam81/parse-dmarc/internal/api"
+	"github.com/meysam81/parse-dmarc/internal/cli/output"
 	"github.com/meysam81/parse-dmarc/internal/config"
+	"github.com/meysam81/parse-dmarc/internal/geoip"
 	"github.com/meysam81/parse-dmarc/internal/imap"
 	"github.com/meysam81/parse-dmarc/internal/logger"
 	mcpserver "github.com/meysam81/parse-dmarc/internal/mcp"
 	"github.com/meysam81/parse-dmarc/internal/mcp/oauth"
 	"github.com/meysam81/parse-dmarc/internal/metrics"
+	"github.com/meysam81/parse-dmarc/internal/notifications"
 	"github.com/meysam81/parse-dmarc/internal/parser"
+	"github.com/meysam81/parse-dmarc/internal/publisher"
 	"github.com/meysam81/parse-dmarc/internal/storage"
 	"github.com/rs/zerolog"
 	"github.com/urfave/cli/v3"
+	"golang.org/x/sync/errgroup"
 )
 
 var (
@@ -31,31 +41,48 @@ var (
 	log *zerolog.Logger
 )
 
+// notificationWorkers bounds concurrent notification deliveries
+const notificationWorkers = 4
+
 func main() {
+	log = logger.NewLogger("info", false)
+
 	cli.VersionPrinter = func(c *cli.Command) {
 		fmt.Println(version)
 	}
 	cmd := &cli.Command{
-		Name:                  "parse-dmarc",
-		Usage:                 "Parse and analyze DMARC reports from IMAP mailbox",
-		Version:               version,
-		EnableShellCompletion: true,
-		Suggest:               true,
-		Before: func(ctx context.Context, c *cli.Command) (context.Context, error) {
-			log = logger.NewLogger("info", false)
-			return ctx, nil
-		},
+		Name:                            "parse-dmarc",
+		Usage:                           "Parse and analyze DMARC reports from IMAP mailbox",
+		Version:                         version,
+		EnableShellCompletion:           true,
+		ConfigureShellCompletionCommand: configureCompletionCommand,
+		Suggest:                         true,
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:    "config",
 				Aliases: []string{"c"},
-				Usage:   "Path to configuration file",
+				Usage:   "Path to configuration file (JSON, or TOML/YAML if the path ends in .toml, .yaml or .yml)",
 				Value:   "config.json",
 				Sources: cli.EnvVars("PARSE_DMARC_CONFIG"),
 			},
+			&cli.StringSliceFlag{
+				Name:    "set",
+				Aliases: []string{"config-override"},
+				Usage:   "Override a config field by its path, e.g. --set imap.port=143 (repeatable)",
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "Output format for subcommands: text or json",
+				Value:   output.FormatText,
+				Sources: cli.EnvVars("PARSE_DMARC_OUTPUT"),
+				Validator: func(format string) error {
+					return output.ValidateFormat(format)
+				},
+			},
 			&cli.BoolFlag{
 				Name:    "gen-config",
-				Usage:   "Generate sample configuration file",
+				Usage:   "Generate sample configuration file (format follows the --config extension)",
 				Sources: cli.EnvVars("PARSE_DMARC_GEN_CONFIG"),
 			},
 			&cli.BoolFlag{
@@ -63,16 +90,34 @@ func main() {
 				Usage:   "Fetch reports once and exit",
 				Sources: cli.EnvVars("PARSE_DMARC_FETCH_ONCE"),
 			},
+			&cli.BoolFlag{
+				Name:    "print-reports",
+				Usage:   "Print each parsed report to stdout before it is saved",
+				Sources: cli.EnvVars("PARSE_DMARC_PRINT_REPORTS"),
+			},
+			&cli.StringFlag{
+				Name:    "report-format",
+				Usage:   "Format of --print-reports output: text or json",
+				Value:   output.FormatText,
+				Sources: cli.EnvVars("PARSE_DMARC_REPORT_FORMAT"),
+				Validator: func(format string) error {
+					return output.ValidateFormat(format)
+				},
+			},
 			&cli.BoolFlag{
 				Name:    "serve-only",
 				Usage:   "Only serve the dashboard without fetching",
 				Sources: cli.EnvVars("PARSE_DMARC_SERVE_ONLY"),
 			},
-			&cli.IntFlag{
+			&cli.StringFlag{
 				Name:    "fetch-interval",
-				Usage:   "Interval in seconds between fetch operations",
-				Value:   300,
+				Usage:   "Interval between fetch operations as a duration (e.g. 5m, 1h) or whole seconds, at least 10s",
+				Value:   "5m",
 				Sources: cli.EnvVars("PARSE_DMARC_FETCH_INTERVAL"),
+				Validator: func(interval string) error {
+					_, err := parseFetchInterval(interval)
+					return err
+				},
 			},
 			&cli.BoolFlag{
 				Name:    "metrics",
@@ -145,13 +190,38 @@ func main() {
 				Name:  "version",
 				Usage: "Show version information",
 				Action: func(ctx context.Context, cmd *cli.Command) error {
-					fmt.Printf("Version:    %s\n", version)
-					fmt.Printf("Commit:     %s\n", commit)
-					fmt.Printf("Build Date: %s\n", date)
-					fmt.Printf("Built By:   %s\n", builtBy)
-					return nil
+					out := output.New(os.Stdout, cmd.String("output"))
+					_ = out.Text(fmt.Sprintf("Version:    %s", version))
+					_ = out.Text(fmt.Sprintf("Commit:     %s", commit))
+					_ = out.Text(fmt.Sprintf("Build Date: %s", date))
+					_ = out.Text(fmt.Sprintf("Built By:   %s", builtBy))
+					return out.JSON(map[string]string{
+						"version":    version,
+						"commit":     commit,
+						"build_date": date,
+						"built_by":   builtBy,
+					})
 				},
 			},
+			serveCommand(),
+			fetchCommand(),
+			validateConfigCommand(),
+			genDockerComposeCommand(),
+			genAlertingRulesCommand(),
+			genKubernetesManifestsCommand(),
+			genPasswordHashCommand(),
+			digestCommand(),
+			checkDomainCommand(),
+			backupCommand(),
+			migrateCommand(),
+			replayCommand(),
+			replayIMAPCommand(),
+			listEnvVarsCommand(),
+			listSourcesCommand(),
+			benchCommand(),
+			mcpInspectCommand(),
+			testWebhookCommand(),
+			analyzeCommand(),
 		},
 	}
 
@@ -161,11 +231,19 @@ func main() {
 }
 
 func run(ctx context.Context, cmd *cli.Command) error {
+	return runWithMode(ctx, cmd, cmd.Bool("serve-only"), cmd.Bool("fetch-once"))
+}
+
+// runWithMode is the default action. serveOnly skips fetching, fetchOnce
+// runs a single fetch cycle and exits; with neither, reports are fetched
+// every --fetch-interval while the dashboard is served.
+func runWithMode(ctx context.Context, cmd *cli.Command, serveOnly, fetchOnce bool) error {
 	configPath := cmd.String("config")
 	genConfig := cmd.Bool("gen-config")
-	fetchOnce := cmd.Bool("fetch-once")
-	serveOnly := cmd.Bool("serve-only")
-	fetchInterval := cmd.Int("fetch-interval")
+	var printer *reportPrinter
+	if cmd.Bool("print-reports") {
+		printer = newReportPrinter(os.Stdout, cmd.String("report-format"))
+	}
 	metricsEnabled := cmd.Bool("metrics")
 	mcpMode := cmd.Bool("mcp")
 	mcpHTTPAddr := cmd.String("mcp-http")
@@ -189,7 +267,7 @@ func run(ctx context.Context, cmd *cli.Command) error {
 		return nil
 	}
 
-	cfg, err := config.Load(configPath)
+	cfg, err := loadConfig(cmd)
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
@@ -205,12 +283,33 @@ func run(ctx context.Context, cmd *cli.Command) error {
 		}
 	}
 
+	fetchInterval, err := fetchIntervalFor(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
 	store, err := storage.NewStorage(cfg.Database.Path)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
 	defer func() { _ = store.Close() }()
 
+	if err := configureAutoVacuum(ctx, store, cfg.Database.AutoVacuum); err != nil {
+		return err
+	}
+	store.SetDeduplicateByContent(cfg.Database.DeduplicateByContent)
+
+	if cfg.Geo.DatabasePath != "" {
+		resolver, err := geoip.NewResolver(cfg.Geo.DatabasePath, log)
+		if err != nil {
+			return fmt.Errorf("failed to initialize GeoIP: %w", err)
+		}
+		defer func() { _ = resolver.Close() }()
+		store.SetGeoLookup(resolver)
+		go resolver.Run(ctx)
+		log.Info().Str("path", cfg.Geo.DatabasePath).Msg("GeoIP enrichment enabled")
+	}
+
 	// Handle MCP mode
 	if mcpMode || mcpHTTPAddr != "" {
 		// Build OAuth config if enabled
@@ -258,10 +357,74 @@ func run(ctx context.Context, cmd *cli.Command) error {
 		log.Info().Msg("prometheus metrics enabled at /metrics")
 	}
 
-	ctx, stop := signal.NotifyContext(ctx, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+	pub, err := newPublisher(ctx, &cfg.Notifications)
+	if err != nil {
+		return fmt.Errorf("failed to initialize publishers: %w", err)
+	}
+	defer func() { _ = pub.Close() }()
+
+	// In continuous mode SIGHUP reloads the configuration instead of
+	// shutting down. The reloader listens for it from here on so a SIGHUP
+	// during startup or the initial fetch is not fatal.
+	shutdownSignals := []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	var reloader *configReloader
+	if serveOnly || fetchOnce {
+		shutdownSignals = append(shutdownSignals, syscall.SIGHUP)
+	} else {
+		reloader = newConfigReloader(cfg, joinOverrides(cmd.StringSlice("set")))
+	}
+	ctx, stop := signal.NotifyContext(ctx, shutdownSignals...)
 	defer stop()
 
+	if cfg.Backup.Schedule != "" {
+		backups, err := startBackupSchedule(cfg, store)
+		if err != nil {
+			return fmt.Errorf("failed to schedule backups: %w", err)
+		}
+		defer backups.Stop()
+	}
+
 	server := api.NewServer(store, cfg.Server.Host, cfg.Server.Port, m, log)
+	server.SetTimeouts(time.Duration(cfg.Server.ReadTimeout), time.Duration(cfg.Server.WriteTimeout),
+		time.Duration(cfg.Server.IdleTimeout), time.Duration(cfg.Server.ReadHeaderTimeout))
+	server.SetRateLimit(cfg.Server.RateLimit.RequestsPerSecond, cfg.Server.RateLimit.Burst)
+	if err := server.SetAllowedCIDRs(cfg.Server.AllowedCIDRs); err != nil {
+		return fmt.Errorf("failed to configure IP allowlist: %w", err)
+	}
+	server.SetBasicAuth(cfg.Server.BasicAuth.Username, cfg.Server.BasicAuth.PasswordHash)
+	server.SetMetricsBasicAuth(cfg.Metrics.BasicAuth.Username, cfg.Metrics.BasicAuth.PasswordHash)
+	if jwt := cfg.Server.JWT; jwt.Issuer != "" {
+		verifier := oauth.NewCachingVerifier(oauth.NewOIDCVerifier(&oauth.Config{
+			Enabled:  true,
+			Issuer:   jwt.Issuer,
+			Audience: jwt.Audience,
+			JWKSURI:  jwt.JWKSURI,
+		}), 5*time.Minute)
+		server.SetJWTAuth(verifier, jwt.APIKeys)
+		log.Info().Str("issuer", jwt.Issuer).Msg("JWT authentication enabled for /api")
+	}
+	if cfg.Server.TLSCertFile != "" || cfg.Server.TLSKeyFile != "" || cfg.Server.TLSClientCA != "" {
+		if err := server.SetTLS(cfg.Server.TLSCertFile, cfg.Server.TLSKeyFile, cfg.Server.TLSClientCA, cfg.Server.TLSClientAuth); err != nil {
+			return fmt.Errorf("failed to configure TLS: %w", err)
+		}
+	}
+	if cfg.Server.IngestEnabled {
+		if err := server.SetWebhook(cfg.Server.IngestPath, cfg.Server.WebhookAPIKeys()); err != nil {
+			return fmt.Errorf("failed to configure webhook: %w", err)
+		}
+		log.Info().Str("path", cfg.Server.IngestPath).Msg("report webhook enabled")
+	}
+	server.SetReportSavedHook(func(ctx context.Context, feedback *parser.Feedback) {
+		checkAlerts(cfg.Alerts, store, feedback)
+		if err := pub.Publish(ctx, publisher.NewReportEvent(feedback)); err != nil {
+			log.Error().Err(err).Str("report_id", feedback.ReportMetadata.ReportID).Msg("failed to publish report event")
+		}
+	})
+	for _, p := range pub {
+		if src, ok := p.(api.EventSource); ok {
+			server.SetEventSource(src)
+		}
+	}
 	serverErrChan := make(chan error, 1)
 	go func() {
 		serverErrChan <- server.Start(ctx)
@@ -284,7 +447,10 @@ func run(ctx context.Context, cmd *cli.Command) error {
 	}
 
 	if fetchOnce {
-		if err := fetchReports(cfg, store, m); err != nil {
+		if !sleepJitter(ctx, cfg.FetchJitterSeconds) {
+			return nil
+		}
+		if err := fetchReports(ctx, cfg, store, m, pub, printer); err != nil {
 			return fmt.Errorf("failed to fetch reports: %w", err)
 		}
 		server.RefreshMetrics()
@@ -292,20 +458,44 @@ func run(ctx context.Context, cmd *cli.Command) error {
 		return nil
 	}
 
-	log.Info().Int("interval_seconds", fetchInterval).Msg("starting continuous fetch mode")
+	log.Info().Dur("interval", fetchInterval).Msg("starting continuous fetch mode")
 
-	if err := fetchReports(cfg, store, m); err != nil {
-		log.Error().Err(err).Msg("initial fetch failed")
+	if sleepJitter(ctx, cfg.FetchJitterSeconds) {
+		if err := fetchReports(ctx, cfg, store, m, pub, printer); err != nil {
+			if errors.Is(err, errStrictMode) {
+				return fmt.Errorf("failed to fetch reports: %w", err)
+			}
+			log.Error().Err(err).Msg("initial fetch failed")
+		}
+		server.RefreshMetrics()
 	}
-	server.RefreshMetrics()
 
-	ticker := time.NewTicker(time.Duration(fetchInterval) * time.Second)
+	// A reload takes effect from the next tick; the HTTP server, storage
+	// and publishers keep the configuration they were started with.
+	go reloader.watch(ctx, configPath, func(c *config.Config) (time.Duration, error) {
+		return fetchIntervalFor(cmd, c)
+	})
+
+	ticker := time.NewTicker(fetchInterval)
 	defer ticker.Stop()
 
 	for {
 		select {
+		case interval := <-reloader.reloaded:
+			if interval != fetchInterval {
+				fetchInterval = interval
+				ticker.Reset(fetchInterval)
+				log.Info().Dur("interval", fetchInterval).Msg("fetch interval changed")
+			}
 		case <-ticker.C:
-			if err := fetchReports(cfg, store, m); err != nil {
+			cfg := reloader.current.Load()
+			if !sleepJitter(ctx, cfg.FetchJitterSeconds) {
+				continue
+			}
+			if err := fetchReports(ctx, cfg, store, m, pub, printer); err != nil {
+				if errors.Is(err, errStrictMode) {
+					return fmt.Errorf("failed to fetch reports: %w", err)
+				}
 				log.Error().Err(err).Msg("fetch failed")
 			}
 			server.RefreshMetrics()
@@ -320,7 +510,7 @@ func run(ctx context.Context, cmd *cli.Command) error {
 	}
 }
 
-func fetchReports(cfg *config.Config, store *storage.Storage, m *metrics.Metrics) error {
+func fetchReports(ctx context.Context, cfg *config.Config, store *storage.Storage, m *metrics.Metrics, pub publisher.Publisher, printer *reportPrinter) error {
 	log.Info().Msg("fetching DMARC reports")
 
 	fetchStart := time.Now()
@@ -355,6 +545,9 @@ func fetchReports(cfg *config.Config, store *storage.Storage, m *metrics.Metrics
 	if m != nil {
 		m.ReportsFetched.Add(float64(len(reports)))
 	}
+	if err := store.RecordFetch(imapSource(&cfg.IMAP), time.Now()); err != nil {
+		log.Warn().Err(err).Msg("failed to record fetch time")
+	}
 
 	if len(reports) == 0 {
 		log.Info().Msg("no new reports found")
@@ -367,56 +560,475 @@ func fetchReports(cfg *config.Config, store *storage.Storage, m *metrics.Metrics
 
 	log.Info().Int("count", len(reports)).Msg("processing reports")
 
-	// Process each report
-	processed := 0
+	var attachments []imap.Attachment
 	for _, report := range reports {
-		for _, attachment := range report.Attachments {
-			if m != nil {
-				m.AttachmentsTotal.Inc()
-			}
+		attachments = append(attachments, report.Attachments...)
+	}
+	processed, err := processAttachments(ctx, cfg, store, m, pub, printer, attachments)
 
-			feedback, err := parser.ParseReport(attachment.Data)
-			if err != nil {
-				log.Warn().Err(err).Str("filename", attachment.Filename).Msg("failed to parse report")
-				if m != nil {
-					m.ReportParseErrors.Inc()
-				}
-				continue
-			}
-			if m != nil {
-				m.ReportsParsed.Inc()
+	if m != nil {
+		m.RecordFetchDuration(time.Since(fetchStart))
+		m.LastFetchTimestamp.SetToCurrentTime()
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Info().Int("count", processed).Msg("reports processed")
+	return nil
+}
+
+// minFetchInterval is the shortest accepted --fetch-interval
+const minFetchInterval = 10 * time.Second
+
+// parseFetchInterval parses a --fetch-interval value. Durations such as "5m"
+// are accepted, as are plain integers, which are read as seconds.
+func parseFetchInterval(s string) (time.Duration, error) {
+	interval, err := time.ParseDuration(s)
+	if err != nil {
+		seconds, convErr := strconv.Atoi(s)
+		if convErr != nil {
+			return 0, fmt.Errorf("invalid fetch interval %q: expected a duration such as 5m or a number of seconds", s)
+		}
+		interval = time.Duration(seconds) * time.Second
+	}
+	if interval < minFetchInterval {
+		return 0, fmt.Errorf("fetch interval %s is shorter than the minimum of %s", interval, minFetchInterval)
+	}
+	return interval, nil
+}
+
+// configReloader holds the configuration used by continuous fetch mode and
+// replaces it when the config file is reloaded on SIGHUP
+type configReloader struct {
+	current atomic.Pointer[config.Config]
+	// reloaded receives the fetch interval of each accepted reload
+	reloaded chan time.Duration
+	watcher  *config.Watcher
+}
+
+// newConfigReloader starts with cfg and listens for SIGHUP right away, so a
+// signal that arrives before watch runs is queued instead of terminating
+// the process
+func newConfigReloader(cfg *config.Config, overrides []string) *configReloader {
+	r := &configReloader{
+		reloaded: make(chan time.Duration, 1),
+		watcher: &config.Watcher{
+			Overrides: overrides,
+			OnError: func(err error) {
+				log.Error().Err(err).Msg("failed to reload config, keeping the current one")
+			},
+		},
+	}
+	r.current.Store(cfg)
+	r.watcher.Notify()
+	return r
+}
+
+// watch reloads the config from path on SIGHUP until ctx is done. A reload
+// is adopted only if it validates and intervalFor accepts it.
+func (r *configReloader) watch(ctx context.Context, path string, intervalFor func(*config.Config) (time.Duration, error)) {
+	_ = r.watcher.Watch(ctx, path, func(newCfg *config.Config) {
+		if err := newCfg.Validate(); err != nil {
+			log.Error().Err(err).Msg("reloaded config is invalid, keeping the current one")
+			return
+		}
+		interval, err := intervalFor(newCfg)
+		if err != nil {
+			log.Error().Err(err).Msg("reloaded config is invalid, keeping the current one")
+			return
+		}
+		r.current.Store(newCfg)
+		log.Info().Dur("interval", interval).Msg("configuration reloaded")
+		select {
+		case r.reloaded <- interval:
+		default:
+			// replace a pending interval the loop has not picked up yet
+			select {
+			case <-r.reloaded:
+			default:
 			}
+			r.reloaded <- interval
+		}
+	})
+}
 
-			if err := store.SaveReport(feedback); err != nil {
-				log.Error().Err(err).Str("report_id", feedback.ReportMetadata.ReportID).Msg("failed to save report")
-				if m != nil {
-					m.ReportStoreErrors.Inc()
-				}
-				continue
+// fetchIntervalFor returns the continuous-mode fetch interval:
+// --fetch-interval when given, otherwise fetch_interval from cfg, otherwise
+// the flag's default.
+func fetchIntervalFor(cmd *cli.Command, cfg *config.Config) (time.Duration, error) {
+	if !cmd.IsSet("fetch-interval") && cfg.FetchInterval != "" {
+		return parseFetchInterval(cfg.FetchInterval)
+	}
+	return parseFetchInterval(cmd.String("fetch-interval"))
+}
+
+// sleepJitter waits a random duration between 0 and maxSeconds seconds. It
+// returns false if ctx is cancelled first.
+func sleepJitter(ctx context.Context, maxSeconds int) bool {
+	if maxSeconds <= 0 {
+		return true
+	}
+	delay := rand.N(time.Duration(maxSeconds)*time.Second + 1)
+	log.Debug().Dur("delay", delay).Msg("delaying fetch")
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// errStrictMode marks a fetch aborted by a failed attachment in strict mode
+var errStrictMode = errors.New("strict mode")
+
+// processAttachments parses and saves attachments on cfg.Workers goroutines
+// and returns the number of reports saved. Failed attachments are logged and
+// skipped, unless cfg.StrictMode is set: then no further attachments are
+// started after the first failure, and the error lists every attachment that
+// failed. Once ctx is cancelled no further attachments are started.
+func processAttachments(ctx context.Context, cfg *config.Config, store *storage.Storage, m *metrics.Metrics, pub publisher.Publisher, printer *reportPrinter, attachments []imap.Attachment) (int, error) {
+	var processed atomic.Int64
+	var g errgroup.Group
+	g.SetLimit(cfg.Workers)
+
+	var mu sync.Mutex
+	var failed []error
+	var stop atomic.Bool
+
+	for _, attachment := range attachments {
+		g.Go(func() error {
+			if stop.Load() || ctx.Err() != nil {
+				return nil
 			}
-			if m != nil {
-				m.ReportsStored.Inc()
+			saved, err := processAttachment(ctx, cfg, store, m, pub, printer, attachment)
+			if saved {
+				processed.Add(1)
 			}
+			if err != nil && cfg.StrictMode && ctx.Err() == nil {
+				stop.Store(true)
+				mu.Lock()
+				failed = append(failed, err)
+				mu.Unlock()
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	if len(failed) > 0 {
+		return int(processed.Load()), fmt.Errorf("%w: %d attachment(s) failed:\n%w", errStrictMode, len(failed), errors.Join(failed...))
+	}
+	return int(processed.Load()), nil
+}
+
+// processAttachment parses and saves a single attachment, then raises alerts
+// and publishes the report event. It returns true if the report was saved,
+// and the parse or store error if it could not be. Attachments that are not
+// DMARC reports are skipped without an error.
+func processAttachment(ctx context.Context, cfg *config.Config, store *storage.Storage, m *metrics.Metrics, pub publisher.Publisher, printer *reportPrinter, attachment imap.Attachment) (bool, error) {
+	if m != nil {
+		m.AttachmentsTotal.Inc()
+	}
 
-			log.Info().
-				Str("report_id", feedback.ReportMetadata.ReportID).
-				Str("org", feedback.ReportMetadata.OrgName).
-				Str("domain", feedback.PolicyPublished.Domain).
-				Int("messages", feedback.GetTotalMessages()).
-				Msg("saved report")
-			processed++
+	feedback, err := parser.ParseReportWithContext(ctx, attachment.Data)
+	if err != nil && ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	if errors.Is(err, parser.ErrNotDMARCReport) {
+		log.Debug().Err(err).Str("filename", attachment.Filename).Msg("skipping attachment that is not a DMARC report")
+		return false, nil
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("filename", attachment.Filename).Msg("failed to parse report")
+		if m != nil {
+			m.ReportParseErrors.Inc()
 		}
+		return false, fmt.Errorf("%s: parse: %w", attachment.Filename, err)
+	}
+	if m != nil {
+		m.ReportsParsed.Inc()
+	}
+	if expected, actual := parser.FormatForFilename(attachment.Filename), parser.DetectFormat(attachment.Data); expected != "" && expected != actual {
+		log.Warn().
+			Str("filename", attachment.Filename).
+			Str("format", actual).
+			Msg("attachment format does not match its extension")
+	}
+
+	if err := printer.Print(feedback); err != nil {
+		log.Warn().Err(err).Str("report_id", feedback.ReportMetadata.ReportID).Msg("failed to print report")
 	}
 
+	err = store.SaveReportContext(storage.WithSource(context.Background(), storage.SourceIMAP), feedback)
+	if errors.Is(err, storage.ErrDuplicateContent) {
+		log.Info().Err(err).Str("report_id", feedback.ReportMetadata.ReportID).Msg("skipping report with duplicate content")
+		return false, nil
+	}
+	if err != nil {
+		log.Error().Err(err).Str("report_id", feedback.ReportMetadata.ReportID).Msg("failed to save report")
+		if m != nil {
+			m.ReportStoreErrors.Inc()
+		}
+		return false, fmt.Errorf("%s: save report %s: %w", attachment.Filename, feedback.ReportMetadata.ReportID, err)
+	}
 	if m != nil {
-		m.RecordFetchDuration(time.Since(fetchStart))
-		m.LastFetchTimestamp.SetToCurrentTime()
+		m.ReportsStored.WithLabelValues(storage.SourceIMAP).Inc()
 	}
 
-	log.Info().Int("count", processed).Msg("reports processed")
+	checkAlerts(cfg.Alerts, store, feedback)
+
+	if err := pub.Publish(context.Background(), publisher.NewReportEvent(feedback)); err != nil {
+		log.Error().Err(err).Str("report_id", feedback.ReportMetadata.ReportID).Msg("failed to publish report event")
+	}
+
+	log.Info().
+		Str("report_id", feedback.ReportMetadata.ReportID).
+		Str("org", feedback.ReportMetadata.OrgName).
+		Str("domain", feedback.PolicyPublished.Domain).
+		Int("messages", feedback.GetTotalMessages()).
+		Msg("saved report")
+	return true, nil
+}
+
+// newPublisher builds the report event publishers enabled in the config
+func newPublisher(ctx context.Context, cfg *config.NotificationsConfig) (publisher.Multi, error) {
+	var pub publisher.Multi
+
+	if cfg.AMQP.URL != "" {
+		p, err := publisher.NewAMQPPublisher(cfg.AMQP.URL, cfg.AMQP.Exchange)
+		if err != nil {
+			return nil, err
+		}
+		pub = append(pub, p)
+		log.Info().Str("exchange", cfg.AMQP.Exchange).Msg("AMQP report events enabled")
+	}
+
+	if cfg.Redis.Addr != "" {
+		p, err := publisher.NewRedisPublisher(ctx, cfg.Redis.Addr, cfg.Redis.Channel)
+		if err != nil {
+			_ = pub.Close()
+			return nil, err
+		}
+		pub = append(pub, p)
+		log.Info().Str("channel", cfg.Redis.Channel).Msg("Redis report events enabled")
+	}
+
+	if len(cfg.Kafka.Brokers) > 0 {
+		p, err := publisher.NewKafkaProducer(publisher.KafkaOptions{
+			Brokers:         cfg.Kafka.Brokers,
+			Topic:           cfg.Kafka.Topic,
+			DeadLetterTopic: cfg.Kafka.DeadLetterTopic,
+			TLS:             cfg.Kafka.TLS,
+			SASLMechanism:   cfg.Kafka.SASLMechanism,
+			SASLUser:        cfg.Kafka.SASLUser,
+			SASLPassword:    cfg.Kafka.SASLPassword,
+		})
+		if err != nil {
+			_ = pub.Close()
+			return nil, err
+		}
+		pub = append(pub, p)
+		log.Info().Str("topic", cfg.Kafka.Topic).Msg("Kafka report events enabled")
+	}
+
+	awsOpts := publisher.AWSOptions{
+		Region:    cfg.AWS.Region,
+		AccessKey: cfg.AWS.AccessKey,
+		SecretKey: cfg.AWS.SecretKey,
+	}
+
+	if cfg.SNS.TopicARN != "" {
+		p, err := publisher.NewSNSPublisher(ctx, cfg.SNS.TopicARN, awsOpts)
+		if err != nil {
+			_ = pub.Close()
+			return nil, err
+		}
+		pub = append(pub, p)
+		log.Info().Str("topic_arn", cfg.SNS.TopicARN).Msg("SNS report events enabled")
+	}
+
+	if cfg.SQS.QueueURL != "" {
+		p, err := publisher.NewSQSPublisher(ctx, cfg.SQS.QueueURL, awsOpts)
+		if err != nil {
+			_ = pub.Close()
+			return nil, err
+		}
+		pub = append(pub, p)
+		log.Info().Str("queue_url", cfg.SQS.QueueURL).Msg("SQS report events enabled")
+	}
+
+	d, err := newDispatcher(cfg)
+	if err != nil {
+		_ = pub.Close()
+		return nil, err
+	}
+	if d.Len() > 0 {
+		pub = append(pub, d)
+		log.Info().Int("channels", d.Len()).Msg("report notifications enabled")
+	}
+
+	return pub, nil
+}
+
+// loadConfig loads the --config file with the --set overrides applied
+func loadConfig(cmd *cli.Command) (*config.Config, error) {
+	return config.LoadWithOverrides(cmd.String("config"), joinOverrides(cmd.StringSlice("set")))
+}
+
+// joinOverrides undoes the comma splitting of --set values: a piece without
+// "=" continues the previous value, so --set server.jwt.api_keys=a,b sets a
+// list while --set imap.port=143,imap.use_tls=false still sets two fields.
+func joinOverrides(pieces []string) []string {
+	var overrides []string
+	for _, piece := range pieces {
+		if !strings.Contains(piece, "=") && len(overrides) > 0 {
+			overrides[len(overrides)-1] += "," + piece
+			continue
+		}
+		overrides = append(overrides, piece)
+	}
+	return overrides
+}
+
+// configureAutoVacuum applies the database.auto_vacuum mode. Switching an
+// existing database to incremental rebuilds it once, so the file size is
+// logged before and after.
+func configureAutoVacuum(ctx context.Context, store *storage.Storage, mode string) error {
+	switch mode {
+	case "", storage.AutoVacuumNone:
+		return nil
+	case storage.AutoVacuumIncremental:
+	case "full":
+		return config.ErrAutoVacuumFull
+	default:
+		return fmt.Errorf("invalid database.auto_vacuum %q: expected none or incremental", mode)
+	}
+
+	before, _ := store.DatabaseSize()
+	rebuilt, err := store.EnableIncrementalVacuum(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to enable incremental vacuum: %w", err)
+	}
+	if rebuilt {
+		after, _ := store.DatabaseSize()
+		log.Info().Int64("size_before", before).Int64("size_after", after).Msg("database switched to incremental auto-vacuum")
+	}
 	return nil
 }
 
+// newDispatcher builds a notification dispatcher for the configured channels
+func newDispatcher(cfg *config.NotificationsConfig) (*notifications.NotificationDispatcher, error) {
+	d := notifications.NewDispatcher(notificationWorkers, log)
+
+	if cfg.Slack.WebhookURL != "" {
+		d.Add("slack", publisher.NewSlackPublisher(cfg.Slack.WebhookURL, cfg.DashboardURL), notifications.Filter{
+			MaxComplianceBelow: cfg.Slack.MinComplianceBelow,
+		})
+	}
+
+	if cfg.Discord.WebhookURL != "" {
+		d.Add("discord", publisher.NewDiscordPublisher(cfg.Discord.WebhookURL, cfg.DashboardURL), notifications.Filter{
+			MaxComplianceBelow: cfg.Discord.MinComplianceBelow,
+		})
+	}
+
+	if cfg.PagerDuty.RoutingKey != "" {
+		d.Add("pagerduty", publisher.NewPagerDutyPublisher(cfg.PagerDuty.RoutingKey, cfg.PagerDuty.MinComplianceBelow, cfg.DashboardURL), notifications.Filter{})
+	}
+
+	for i, ch := range cfg.Channels {
+		var sender notifications.Sender
+		switch ch.Type {
+		case config.ChannelWebhook:
+			wh := notifications.NewWebhook(ch.URL)
+			sender = notifications.SenderFunc(func(ctx context.Context, event *publisher.Event) error {
+				return wh.Send(ctx, event)
+			})
+		case config.ChannelSMTP:
+			sender = notifications.NewSMTP(notifications.SMTPOptions{
+				Host:     ch.SMTP.Host,
+				Port:     ch.SMTP.Port,
+				Username: ch.SMTP.Username,
+				Password: ch.SMTP.Password,
+				From:     ch.SMTP.From,
+				To:       ch.SMTP.To,
+			})
+		case config.ChannelSlack:
+			sender = publisher.NewSlackPublisher(ch.URL, cfg.DashboardURL)
+		case config.ChannelDiscord:
+			sender = publisher.NewDiscordPublisher(ch.URL, cfg.DashboardURL)
+		case config.ChannelPagerDuty:
+			// Resolving needs every report for the domain, so only the
+			// domain filter applies; the threshold is checked by the publisher
+			sender = publisher.NewPagerDutyPublisher(ch.RoutingKey, ch.Filters.MaxComplianceBelow, cfg.DashboardURL)
+			d.Add(fmt.Sprintf("%s[%d]", ch.Type, i), sender, notifications.Filter{Domains: ch.Filters.Domains})
+			continue
+		default:
+			return nil, fmt.Errorf("notification channel %d: unsupported type %q", i, ch.Type)
+		}
+
+		d.Add(fmt.Sprintf("%s[%d]", ch.Type, i), sender, notifications.Filter{
+			MinComplianceBelow: ch.Filters.MinComplianceBelow,
+			MaxComplianceBelow: ch.Filters.MaxComplianceBelow,
+			Domains:            ch.Filters.Domains,
+		})
+	}
+
+	return d, nil
+}
+
+// checkAlerts records an alert for every configured threshold the report
+// falls below and notifies the threshold's webhook, if set
+func checkAlerts(alerts []config.AlertConfig, store *storage.Storage, feedback *parser.Feedback) {
+	total := feedback.GetTotalMessages()
+	if len(alerts) == 0 || total == 0 {
+		return
+	}
+
+	domain := feedback.PolicyPublished.Domain
+	rate := float64(feedback.GetDMARCCompliantCount()) / float64(total) * 100
+
+	for _, ac := range alerts {
+		if !ac.Matches(domain) || rate >= ac.MinComplianceRate {
+			continue
+		}
+
+		alert := &storage.Alert{
+			Domain:         domain,
+			ThresholdType:  storage.ThresholdMinComplianceRate,
+			ThresholdValue: ac.MinComplianceRate,
+			Value:          rate,
+			TriggeredAt:    time.Now().Unix(),
+			ReportID:       feedback.ReportMetadata.ReportID,
+		}
+		created, err := store.SaveAlert(alert)
+		if err != nil {
+			log.Error().Err(err).Str("domain", domain).Msg("failed to save alert")
+			continue
+		}
+		if !created {
+			continue
+		}
+
+		log.Warn().
+			Str("domain", domain).
+			Str("report_id", alert.ReportID).
+			Float64("compliance_rate", rate).
+			Float64("threshold", ac.MinComplianceRate).
+			Msg("compliance alert triggered")
+
+		if ac.NotificationWebhook != "" {
+			if err := notifications.NewWebhook(ac.NotificationWebhook).Send(context.Background(), alert); err != nil {
+				log.Error().Err(err).Str("domain", domain).Msg("failed to send alert webhook")
+			}
+		}
+	}
+}
+
 func runMCPServer(ctx context.Context, store *storage.Storage, httpAddr string, oauthCfg *oauth.Config) error {
 	ctx, stop := signal.NotifyContext(ctx, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/meysam81/parse-dmarc/internal/config"
+	"github.com/meysam81/parse-dmarc/internal/imap"
+	"github.com/meysam81/parse-dmarc/internal/publisher"
+	"github.com/meysam81/parse-dmarc/internal/storage"
+	"github.com/rs/zerolog"
+)
+
+func BenchmarkFetchReports(b *testing.B) {
+	nop := zerolog.Nop()
+	log = &nop
+
+	const batch = 32
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			store, err := storage.NewStorage(filepath.Join(b.TempDir(), "db.sqlite"))
+			if err != nil {
+				b.Fatalf("Failed to create storage: %v", err)
+			}
+			defer func() { _ = store.Close() }()
+
+			cfg := &config.Config{Workers: workers}
+			pub := publisher.Multi{}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				attachments := make([]imap.Attachment, batch)
+				for j := range attachments {
+					attachments[j] = imap.Attachment{
+						Filename: "report.xml",
+						Data:     syntheticReport(i*batch + j),
+					}
+				}
+
+				if n, _ := processAttachments(context.Background(), cfg, store, nil, pub, nil, attachments); n != batch {
+					b.Fatalf("Expected %d reports processed, got %d", batch, n)
+				}
+			}
+			b.ReportMetric(float64(b.N*batch)/b.Elapsed().Seconds(), "reports/s")
+		})
+	}
+}
+
+func TestParseFetchInterval(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "5m", want: 5 * time.Minute},
+		{in: "1h", want: time.Hour},
+		{in: "300s", want: 300 * time.Second},
+		{in: "300", want: 300 * time.Second},
+		{in: "10", want: 10 * time.Second},
+		{in: "9s", wantErr: true},
+		{in: "5", wantErr: true},
+		{in: "-1m", wantErr: true},
+		{in: "five minutes", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseFetchInterval(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseFetchInterval(%q): expected error, got %s", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseFetchInterval(%q): unexpected error: %v", tt.in, err)
+		} else if got != tt.want {
+			t.Errorf("parseFetchInterval(%q): expected %s, got %s", tt.in, tt.want, got)
+		}
+	}
+}
+
+func TestProcessAttachmentsStrictMode(t *testing.T) {
+	nop := zerolog.Nop()
+	log = &nop
+
+	store, err := storage.NewStorage(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	attachments := []imap.Attachment{
+		{Filename: "broken.xml", Data: []byte("<feedback><report_metadata>")},
+		{Filename: "report.xml", Data: syntheticReport(1)},
+	}
+
+	cfg := &config.Config{Workers: 1}
+	n, err := processAttachments(context.Background(), cfg, store, nil, publisher.Multi{}, nil, attachments)
+	if err != nil {
+		t.Errorf("Expected failures to be skipped, got %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 report processed, got %d", n)
+	}
+
+	cfg.StrictMode = true
+	n, err = processAttachments(context.Background(), cfg, store, nil, publisher.Multi{}, nil, attachments)
+	if !errors.Is(err, errStrictMode) {
+		t.Fatalf("Expected strict mode error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "broken.xml") {
+		t.Errorf("Expected error to list broken.xml, got %v", err)
+	}
+	if n != 0 {
+		t.Errorf("Expected processing to stop after the failure, got %d reports", n)
+	}
+}
+
+func TestJoinOverrides(t *testing.T) {
+	got := joinOverrides([]string{"imap.port=143", "imap.use_tls=false", "server.jwt.api_keys=a", "b"})
+	want := []string{"imap.port=143", "imap.use_tls=false", "server.jwt.api_keys=a,b"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestConfigReloaderSIGHUPBeforeWatch(t *testing.T) {
+	nop := zerolog.Nop()
+	log = &nop
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	writeConfig := func(host string) {
+		t.Helper()
+		data := "fetch_interval = \"10m\"\n\n[imap]\nhost = \"" + host + "\"\nusername = \"u\"\npassword = \"p\"\n"
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+	}
+	writeConfig("imap.example.com")
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	// The signal arrives while the initial fetch would still be running,
+	// before watch has started; it must be queued, not kill the process
+	r := newConfigReloader(cfg, nil)
+	writeConfig("imap.new.example.com")
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatalf("Failed to send SIGHUP: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.watch(ctx, path, func(c *config.Config) (time.Duration, error) {
+		return parseFetchInterval(c.FetchInterval)
+	})
+
+	select {
+	case interval := <-r.reloaded:
+		if interval != 10*time.Minute {
+			t.Errorf("Expected interval 10m, got %s", interval)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for config reload")
+	}
+	if host := r.current.Load().IMAP.Host; host != "imap.new.example.com" {
+		t.Errorf("Expected IMAP host imap.new.example.com, got %s", host)
+	}
+}
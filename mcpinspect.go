@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/meysam81/parse-dmarc/internal/cli/output"
+	mcpserver "github.com/meysam81/parse-dmarc/internal/mcp"
+	"github.com/urfave/cli/v3"
+)
+
+func mcpInspectCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "mcp-inspect",
+		Usage: "List the tools registered on the MCP server",
+		Description: "The tools are listed through an in-memory client session, without a network\n" +
+			"listener or database. With --output json the result is exactly what an MCP\n" +
+			"client receives from tools/list.",
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			// Listing tools never touches storage, so no database is opened
+			server := mcpserver.NewServer(nil, &mcpserver.Config{Version: version})
+			result, err := server.ListTools(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to list MCP tools: %w", err)
+			}
+
+			rows := make([][]string, 0, len(result.Tools))
+			for _, tool := range result.Tools {
+				rows = append(rows, []string{tool.Name, toolInputs(tool.InputSchema), tool.Description})
+			}
+
+			out := output.New(os.Stdout, cmd.String("output"))
+			_ = out.Table([]string{"NAME", "INPUTS", "DESCRIPTION"}, rows)
+			return out.JSON(result)
+		},
+	}
+}
+
+// toolInputs lists the properties of a tool's input schema, marking
+// required ones with *
+func toolInputs(schema any) string {
+	obj, ok := schema.(map[string]any)
+	if !ok {
+		return ""
+	}
+	properties, _ := obj["properties"].(map[string]any)
+	required := map[string]bool{}
+	if list, ok := obj["required"].([]any); ok {
+		for _, name := range list {
+			if s, ok := name.(string); ok {
+				required[s] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		if required[name] {
+			name += "*"
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return "-"
+	}
+	return strings.Join(names, ",")
+}
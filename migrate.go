@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/meysam81/parse-dmarc/internal/cli/output"
+	"github.com/meysam81/parse-dmarc/internal/storage"
+	"github.com/urfave/cli/v3"
+)
+
+func migrateCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "migrate",
+		Usage: "Apply pending database schema migrations and exit",
+		Description: "Migrations also run whenever parse-dmarc opens the database; this command\n" +
+			"lets a deployment apply them ahead of starting the new version.",
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			store, err := storage.NewStorage(cfg.Database.Path)
+			if err != nil {
+				return cli.Exit(fmt.Sprintf("migration failed: %v", err), 1)
+			}
+			defer func() { _ = store.Close() }()
+
+			version, err := store.SchemaVersion()
+			if err != nil {
+				return cli.Exit(err.Error(), 1)
+			}
+
+			out := output.New(os.Stdout, cmd.String("output"))
+			_ = out.Text(fmt.Sprintf("Database %s is at schema version %d", cfg.Database.Path, version))
+			return out.JSON(map[string]interface{}{
+				"database":       cfg.Database.Path,
+				"schema_version": version,
+			})
+		},
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/meysam81/parse-dmarc/internal/cli/output"
+	"github.com/urfave/cli/v3"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func genPasswordHashCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "gen-password-hash",
+		Usage:     "Print a bcrypt hash of a password for server.basic_auth.password_hash",
+		ArgsUsage: "<password>",
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			if cmd.NArg() != 1 {
+				return cli.Exit("expected exactly one argument: <password>", 1)
+			}
+
+			hash, err := bcrypt.GenerateFromPassword([]byte(cmd.Args().First()), bcrypt.DefaultCost)
+			if err != nil {
+				return fmt.Errorf("failed to hash password: %w", err)
+			}
+
+			out := output.New(os.Stdout, cmd.String("output"))
+			_ = out.Text(string(hash))
+			return out.JSON(map[string]string{"password_hash": string(hash)})
+		},
+	}
+}
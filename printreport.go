@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/meysam81/parse-dmarc/internal/cli/output"
+	"github.com/meysam81/parse-dmarc/internal/parser"
+)
+
+// reportPrinter writes parsed reports to stdout for --print-reports.
+// Attachments are parsed by several workers, so writes are serialized to
+// keep each report in one piece.
+type reportPrinter struct {
+	mu  sync.Mutex
+	out output.Writer
+}
+
+// newReportPrinter returns a printer writing to w in format, text or json
+func newReportPrinter(w io.Writer, format string) *reportPrinter {
+	return &reportPrinter{out: output.New(w, format)}
+}
+
+// Print writes feedback as text with a row per record, or as a JSON
+// document. A nil printer prints nothing.
+func (p *reportPrinter) Print(feedback *parser.Feedback) error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := writeReportText(p.out, feedback); err != nil {
+		return err
+	}
+	if err := p.out.Text(""); err != nil {
+		return err
+	}
+	return p.out.JSON(feedback)
+}
+
+// writeReportText writes the metadata, published policy and a row per record
+// of feedback. It writes nothing in JSON mode.
+func writeReportText(out output.Writer, feedback *parser.Feedback) error {
+	meta := feedback.ReportMetadata
+	policy := feedback.PolicyPublished
+	lines := []string{
+		fmt.Sprintf("Report:   %s", meta.ReportID),
+		fmt.Sprintf("Reporter: %s <%s>", meta.OrgName, meta.Email),
+		fmt.Sprintf("Period:   %s to %s",
+			time.Unix(meta.DateRange.Begin, 0).UTC().Format(time.RFC3339),
+			time.Unix(meta.DateRange.End, 0).UTC().Format(time.RFC3339)),
+		fmt.Sprintf("Policy:   %s p=%s sp=%s pct=%d adkim=%s aspf=%s",
+			policy.Domain, policy.P, policy.SP, policy.PCT, policy.ADKIM, policy.ASPF),
+		fmt.Sprintf("Messages: %d (%d DMARC compliant)", feedback.GetTotalMessages(), feedback.GetDMARCCompliantCount()),
+	}
+	for _, line := range lines {
+		if err := out.Text(line); err != nil {
+			return err
+		}
+	}
+
+	rows := make([][]string, 0, len(feedback.Records))
+	for _, record := range feedback.Records {
+		rows = append(rows, []string{
+			record.Row.SourceIP,
+			strconv.Itoa(record.Row.Count),
+			record.Row.PolicyEvaluated.Disposition,
+			record.Row.PolicyEvaluated.DKIM,
+			record.Row.PolicyEvaluated.SPF,
+			record.Identifiers.HeaderFrom,
+			dkimResults(record.AuthResults.DKIM),
+			spfResults(record.AuthResults.SPF),
+		})
+	}
+	return out.Table([]string{"SOURCE IP", "COUNT", "DISPOSITION", "DKIM", "SPF", "HEADER FROM", "DKIM RESULTS", "SPF RESULTS"}, rows)
+}
+
+// dkimResults formats DKIM auth results as domain=result pairs
+func dkimResults(results []parser.DKIMResult) string {
+	parts := make([]string, 0, len(results))
+	for _, r := range results {
+		parts = append(parts, r.Domain+"="+r.Result)
+	}
+	return strings.Join(parts, ",")
+}
+
+// spfResults formats SPF auth results as domain=result pairs
+func spfResults(results []parser.SPFResult) string {
+	parts := make([]string, 0, len(results))
+	for _, r := range results {
+		parts = append(parts, r.Domain+"="+r.Result)
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/meysam81/parse-dmarc/internal/cli/output"
+	"github.com/meysam81/parse-dmarc/internal/parser"
+)
+
+func TestReportPrinter(t *testing.T) {
+	feedback, err := parser.ParseReport(syntheticReport(1))
+	if err != nil {
+		t.Fatalf("Failed to parse report: %v", err)
+	}
+
+	t.Run("text", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := newReportPrinter(&buf, output.FormatText).Print(feedback); err != nil {
+			t.Fatalf("Failed to print report: %v", err)
+		}
+		got := buf.String()
+		for _, want := range []string{"Report:", feedback.ReportMetadata.ReportID, "SOURCE IP", feedback.Records[0].Row.SourceIP} {
+			if !strings.Contains(got, want) {
+				t.Errorf("Expected output to contain %q, got:\n%s", want, got)
+			}
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := newReportPrinter(&buf, output.FormatJSON).Print(feedback); err != nil {
+			t.Fatalf("Failed to print report: %v", err)
+		}
+		got := buf.String()
+		if !strings.HasPrefix(got, "{") || !strings.Contains(got, feedback.ReportMetadata.ReportID) {
+			t.Errorf("Expected a JSON document with the report ID, got:\n%s", got)
+		}
+		if strings.Contains(got, "SOURCE IP") {
+			t.Errorf("Expected no table in JSON output, got:\n%s", got)
+		}
+	})
+
+	t.Run("nil printer", func(t *testing.T) {
+		var printer *reportPrinter
+		if err := printer.Print(feedback); err != nil {
+			t.Errorf("Expected nil printer to print nothing, got %v", err)
+		}
+	})
+}
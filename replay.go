@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/meysam81/parse-dmarc/internal/cli/output"
+	"github.com/meysam81/parse-dmarc/internal/parser"
+	"github.com/meysam81/parse-dmarc/internal/storage"
+	"github.com/urfave/cli/v3"
+)
+
+// replayBatchSize is the number of reports read and updated per transaction
+const replayBatchSize = 100
+
+// replaySummary counts the outcome of a replay
+type replaySummary struct {
+	Reports int `json:"reports"`
+	Changed int `json:"changed"`
+	Failed  int `json:"failed"`
+	// Fields counts the reports in which each column changed
+	Fields map[string]int `json:"fields"`
+	DryRun bool           `json:"dry_run"`
+}
+
+func replayCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "replay",
+		Usage: "Re-parse stored reports with the current parser and update what changed",
+		Description: "Stored reports are converted back to XML and parsed again, so fixes to the\n" +
+			"parser and to the derived columns apply to reports fetched before them.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "from",
+				Usage: "Only replay reports beginning on or after this date (YYYY-MM-DD)",
+			},
+			&cli.StringFlag{
+				Name:  "domain",
+				Usage: "Only replay reports for this domain",
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Show what would change without updating the database",
+			},
+		},
+		Action: runReplay,
+	}
+}
+
+func runReplay(ctx context.Context, cmd *cli.Command) error {
+	filter := storage.ReportFilter{Domain: cmd.String("domain")}
+	if from := cmd.String("from"); from != "" {
+		t, err := time.Parse(time.DateOnly, from)
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("invalid --from %q: expected YYYY-MM-DD", from), 1)
+		}
+		filter.From = t.Unix()
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := storage.NewStorage(cfg.Database.Path)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	out := output.New(os.Stdout, cmd.String("output"))
+	summary := replaySummary{Fields: map[string]int{}, DryRun: cmd.Bool("dry-run")}
+	ctx = storage.WithActor(ctx, "replay")
+
+	var afterID int64
+	for {
+		reports, err := store.GetStoredReports(filter, afterID, replayBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to read reports: %w", err)
+		}
+		if len(reports) == 0 {
+			break
+		}
+		afterID = reports[len(reports)-1].ID
+
+		var updates []storage.ReportUpdate
+		for _, r := range reports {
+			summary.Reports++
+			feedback, err := reparseReport(r.Feedback)
+			if err != nil {
+				log.Warn().Err(err).Str("report_id", r.ReportID).Msg("failed to re-parse report")
+				summary.Failed++
+				continue
+			}
+
+			changed := storage.ReportColumnsFor(feedback).Diff(r.Columns)
+			if len(changed) == 0 {
+				continue
+			}
+			summary.Changed++
+			for _, field := range changed {
+				summary.Fields[field]++
+			}
+			log.Debug().Str("report_id", r.ReportID).Strs("fields", changed).Msg("report changed")
+			updates = append(updates, storage.ReportUpdate{ID: r.ID, Feedback: feedback})
+		}
+
+		if !summary.DryRun && len(updates) > 0 {
+			if err := store.UpdateReports(ctx, updates); err != nil {
+				return fmt.Errorf("failed to update reports: %w", err)
+			}
+		}
+		_ = out.Text(fmt.Sprintf("Replayed %d reports, %d changed", summary.Reports, summary.Changed))
+	}
+
+	verb := "Updated"
+	if summary.DryRun {
+		verb = "Would update"
+	}
+	_ = out.Text(fmt.Sprintf("%s %d of %d reports (%d failed to parse)", verb, summary.Changed, summary.Reports, summary.Failed))
+	if len(summary.Fields) > 0 {
+		fields := make([]string, 0, len(summary.Fields))
+		for field := range summary.Fields {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+
+		rows := make([][]string, 0, len(fields))
+		for _, field := range fields {
+			rows = append(rows, []string{field, strconv.Itoa(summary.Fields[field])})
+		}
+		_ = out.Table([]string{"FIELD", "REPORTS CHANGED"}, rows)
+	}
+	return out.JSON(summary)
+}
+
+// reparseReport runs a stored report through the parser again. The stored
+// copy is the parsed report rather than the original attachment, so it is
+// converted back to XML first.
+func reparseReport(stored *parser.Feedback) (*parser.Feedback, error) {
+	data, err := xml.Marshal(stored)
+	if err != nil {
+		return nil, fmt.Errorf("marshal report: %w", err)
+	}
+	return parser.ParseReport(data)
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/meysam81/parse-dmarc/internal/parser"
+	"github.com/meysam81/parse-dmarc/internal/storage"
+)
+
+func TestReparseReport(t *testing.T) {
+	original, err := parser.ParseReport(syntheticReport(7))
+	if err != nil {
+		t.Fatalf("Failed to parse report: %v", err)
+	}
+
+	reparsed, err := reparseReport(original)
+	if err != nil {
+		t.Fatalf("Failed to re-parse report: %v", err)
+	}
+	if changed := storage.ReportColumnsFor(reparsed).Diff(storage.ReportColumnsFor(original)); len(changed) != 0 {
+		t.Errorf("Expected no changed columns, got %v", changed)
+	}
+	if reparsed.Records[0].Row.SourceIP != original.Records[0].Row.SourceIP {
+		t.Errorf("Expected source IP %s, got %s", original.Records[0].Row.SourceIP, reparsed.Records[0].Row.SourceIP)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseReplayWindow(t *testing.T) {
+	since, before, err := parseReplayWindow("2024-01-01", "2024-03-31")
+	if err != nil {
+		t.Fatalf("Failed to parse window: %v", err)
+	}
+	if got := since.Format(time.DateOnly); got != "2024-01-01" {
+		t.Errorf("Expected since 2024-01-01, got %s", got)
+	}
+	// BEFORE is exclusive, so the last day is included by searching before
+	// the day after it
+	if got := before.Format(time.DateOnly); got != "2024-04-01" {
+		t.Errorf("Expected before 2024-04-01, got %s", got)
+	}
+
+	_, before, err = parseReplayWindow("2024-01-01", "")
+	if err != nil {
+		t.Fatalf("Failed to parse window: %v", err)
+	}
+	if want := time.Now().UTC().AddDate(0, 0, 1).Format(time.DateOnly); before.Format(time.DateOnly) != want {
+		t.Errorf("Expected before %s, got %s", want, before.Format(time.DateOnly))
+	}
+
+	for _, tt := range []struct{ from, to string }{
+		{"", ""},
+		{"2024-13-01", ""},
+		{"2024-01-01", "yesterday"},
+		{"2024-03-31", "2024-01-01"},
+	} {
+		if _, _, err := parseReplayWindow(tt.from, tt.to); err == nil {
+			t.Errorf("Expected error for --from %q --to %q", tt.from, tt.to)
+		}
+	}
+}
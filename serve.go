@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+
+	"github.com/urfave/cli/v3"
+)
+
+func serveCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "serve",
+		Usage: "Serve the dashboard and API without fetching reports (same as --serve-only)",
+		Description: "Use together with the fetch subcommand when serving and fetching run in\n" +
+			"separate containers that share the database.",
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return runWithMode(ctx, cmd, true, false)
+		},
+	}
+}
+
+func fetchCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "fetch",
+		Usage: "Fetch reports once and exit (same as --fetch-once)",
+		Description: "Suited to a cron job or a Kubernetes CronJob, while another instance runs\n" +
+			"the serve subcommand.",
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			return runWithMode(ctx, cmd, false, true)
+		},
+	}
+}
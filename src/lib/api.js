@@ -72,9 +72,16 @@ export const getTopSources = (limit = 10) =>
  * @param {Object} options - Query options
  * @param {number} options.limit - Maximum number of reports to return
  * @param {number} options.offset - Offset for pagination
+ * @param {string} [options.reportId] - Only return the report with this reporter-assigned ID
+ * @returns {Promise<{total: number, reports: Array}>} Total matching reports and the requested page
  */
-export const getReports = ({ limit = 20, offset = 0 } = {}) =>
-  createApiClient().get("reports", { searchParams: { limit, offset } }).json();
+export const getReports = ({ limit = 20, offset = 0, reportId } = {}) => {
+  const searchParams = { limit, offset };
+  if (reportId) {
+    searchParams.report_id = reportId;
+  }
+  return createApiClient().get("reports", { searchParams }).json();
+};
 
 /**
  * Get a single report by ID
@@ -83,4 +90,23 @@ export const getReports = ({ limit = 20, offset = 0 } = {}) =>
 export const getReportById = (id) =>
   createApiClient().get(`reports/${id}`).json();
 
+/**
+ * Subscribe to report events pushed by the server
+ * @param {Function} onReport - Called with each parsed report event
+ * @returns {EventSource} The open stream; call close() to unsubscribe
+ */
+export const subscribeReports = (onReport) => {
+  const settingsStore = useSettingsStore();
+  const source = new EventSource(
+    `${settingsStore.apiEndpoint.replace(/\/$/, "")}/stream/reports`,
+  );
+  source.addEventListener("report", (event) => {
+    onReport(JSON.parse(event.data));
+  });
+  source.onerror = () => {
+    log.debug("Report stream unavailable, relying on polling");
+  };
+  return source;
+};
+
 export default createApiClient;
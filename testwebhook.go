@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/meysam81/parse-dmarc/internal/cli/output"
+	"github.com/meysam81/parse-dmarc/internal/publisher"
+	"github.com/urfave/cli/v3"
+)
+
+func testWebhookCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "test-webhook",
+		Usage: "Send a test notification to a configured channel",
+		Description: "The notification is marked as a test (\"test\": true in JSON payloads and a\n" +
+			"[TEST] prefix in messages) and bypasses the channel's filters. --channel takes\n" +
+			"slack, discord or pagerduty for the top-level settings, a channel type such as\n" +
+			"webhook for every channel of that type, or a single channel such as webhook[0].",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "channel",
+				Usage:    "Channel to send the test notification to",
+				Required: true,
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			d, err := newDispatcher(&cfg.Notifications)
+			if err != nil {
+				return fmt.Errorf("failed to configure notifications: %w", err)
+			}
+
+			name := cmd.String("channel")
+			event := publisher.NewTestEvent()
+			sent, err := d.SendTo(ctx, name, event)
+			if sent == 0 {
+				return cli.Exit(fmt.Sprintf("no notification channel %q configured", name), 1)
+			}
+			if err != nil {
+				return cli.Exit(fmt.Sprintf("test notification failed: %v", err), 1)
+			}
+
+			out := output.New(os.Stdout, cmd.String("output"))
+			_ = out.Text(fmt.Sprintf("Sent test notification to %d channel(s) matching %q", sent, name))
+			return out.JSON(map[string]interface{}{
+				"channel":   name,
+				"sent":      sent,
+				"report_id": event.ReportID,
+			})
+		},
+	}
+}
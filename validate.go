@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/meysam81/parse-dmarc/internal/api"
+	"github.com/meysam81/parse-dmarc/internal/cli/output"
+	"github.com/meysam81/parse-dmarc/internal/config"
+	"github.com/meysam81/parse-dmarc/internal/geoip"
+	"github.com/meysam81/parse-dmarc/internal/imap"
+	"github.com/robfig/cron/v3"
+	"github.com/urfave/cli/v3"
+)
+
+// Exit codes for validate-config
+const (
+	validateExitErrors   = 1
+	validateExitWarnings = 2
+)
+
+// validationReport is the result of validate-config
+type validationReport struct {
+	Config   string   `json:"config"`
+	Valid    bool     `json:"valid"`
+	Errors   []string `json:"errors"`
+	Warnings []string `json:"warnings"`
+}
+
+func (r *validationReport) errorf(format string, args ...interface{}) {
+	r.Errors = append(r.Errors, fmt.Sprintf(format, args...))
+}
+
+func (r *validationReport) warnf(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+func validateConfigCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "validate-config",
+		Usage: "Check the configuration file and report errors and warnings",
+		Description: "Exits with status 0 if the configuration is valid, 1 if there are errors,\n" +
+			"and 2 if there are only warnings.",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "test-imap",
+				Usage: "Also connect and log in to the configured IMAP server",
+			},
+		},
+		Action: runValidateConfig,
+	}
+}
+
+func runValidateConfig(ctx context.Context, cmd *cli.Command) error {
+	configPath := cmd.String("config")
+	report := &validationReport{
+		Config:   configPath,
+		Errors:   []string{},
+		Warnings: []string{},
+	}
+
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		report.warnf("config file %s not found; using environment variables and defaults", configPath)
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		report.errorf("%v", err)
+	} else {
+		checkConfig(cfg, report)
+		if cmd.Bool("test-imap") && cfg.Validate() == nil {
+			client := imap.NewClient(&cfg.IMAP, log)
+			if err := client.Connect(); err != nil {
+				report.errorf("IMAP connection test failed: %v", err)
+			} else {
+				_ = client.Disconnect()
+			}
+		}
+	}
+
+	report.Valid = len(report.Errors) == 0
+	if err := printValidationReport(output.New(os.Stdout, cmd.String("output")), report); err != nil {
+		return err
+	}
+
+	switch {
+	case len(report.Errors) > 0:
+		return cli.Exit("configuration has errors", validateExitErrors)
+	case len(report.Warnings) > 0:
+		return cli.Exit("configuration has warnings", validateExitWarnings)
+	}
+	return nil
+}
+
+// checkConfig records configuration problems in the report
+func checkConfig(cfg *config.Config, report *validationReport) {
+	if err := cfg.Validate(); err != nil {
+		report.errorf("%v", err)
+	}
+	if !cfg.IMAP.UseTLS {
+		report.warnf("IMAP TLS is disabled; credentials are sent in plain text")
+	}
+
+	if err := checkWritableDir(filepath.Dir(cfg.Database.Path)); err != nil {
+		report.errorf("database directory: %v", err)
+	}
+
+	if cfg.Geo.DatabasePath != "" {
+		for _, name := range []string{geoip.ASNDatabase, geoip.CountryDatabase} {
+			if _, err := os.Stat(filepath.Join(cfg.Geo.DatabasePath, name)); err != nil {
+				report.errorf("GeoIP database: %v", err)
+			}
+		}
+	}
+
+	if cfg.Server.TLSClientCA != "" && cfg.Server.TLSCertFile == "" {
+		report.errorf("server: tls_client_ca requires tls_cert_file and tls_key_file")
+	}
+	for _, path := range []string{cfg.Server.TLSCertFile, cfg.Server.TLSKeyFile, cfg.Server.TLSClientCA} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			report.errorf("server TLS: %v", err)
+		}
+	}
+	for _, cidr := range cfg.Server.AllowedCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			report.errorf("server: allowed_cidrs: %v", err)
+		}
+	}
+	if cfg.Server.IngestEnabled {
+		if err := api.CheckWebhookPath(cfg.Server.IngestPath); err != nil {
+			report.errorf("server: ingest_path: %v", err)
+		}
+		if len(cfg.Server.WebhookAPIKeys()) == 0 {
+			report.errorf("server: ingest_enabled requires ingest_api_keys or jwt.api_keys")
+		}
+	}
+	switch cfg.Server.TLSClientAuth {
+	case "", api.ClientAuthNone, api.ClientAuthRequest, api.ClientAuthRequire:
+	default:
+		report.errorf("server: tls_client_auth must be %q, %q or %q", api.ClientAuthNone, api.ClientAuthRequest, api.ClientAuthRequire)
+	}
+
+	if cfg.Notifications.Slack.WebhookURL != "" && !isHTTPURL(cfg.Notifications.Slack.WebhookURL) {
+		report.errorf("notifications.slack: webhook_url must be an http(s) URL")
+	}
+	if cfg.Notifications.Discord.WebhookURL != "" && !isHTTPURL(cfg.Notifications.Discord.WebhookURL) {
+		report.errorf("notifications.discord: webhook_url must be an http(s) URL")
+	}
+	for i, ch := range cfg.Notifications.Channels {
+		switch ch.Type {
+		case config.ChannelWebhook, config.ChannelSlack, config.ChannelDiscord:
+			if !isHTTPURL(ch.URL) {
+				report.errorf("notifications.channels[%d]: url must be an http(s) URL", i)
+			}
+		case config.ChannelSMTP:
+			if ch.SMTP.Host == "" || ch.SMTP.From == "" || len(ch.SMTP.To) == 0 {
+				report.errorf("notifications.channels[%d]: smtp requires host, from and to", i)
+			}
+		case config.ChannelPagerDuty:
+			if ch.RoutingKey == "" || ch.Filters.MaxComplianceBelow <= 0 {
+				report.errorf("notifications.channels[%d]: pagerduty requires routing_key and filters.max_compliance_below", i)
+			}
+		default:
+			report.errorf("notifications.channels[%d]: unsupported type %q", i, ch.Type)
+		}
+	}
+
+	if cfg.Backup.Schedule != "" {
+		if _, err := cron.ParseStandard(cfg.Backup.Schedule); err != nil {
+			report.errorf("backup: schedule: %v", err)
+		}
+	}
+
+	for i, alert := range cfg.Alerts {
+		if alert.MinComplianceRate < 0 || alert.MinComplianceRate > 100 {
+			report.errorf("alerts[%d]: min_compliance_rate must be between 0 and 100", i)
+		}
+		if alert.NotificationWebhook != "" && !isHTTPURL(alert.NotificationWebhook) {
+			report.errorf("alerts[%d]: notification_webhook must be an http(s) URL", i)
+		}
+	}
+}
+
+// isHTTPURL reports whether s is an absolute http or https URL
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// checkWritableDir verifies dir exists, is a directory and accepts new files
+func checkWritableDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+
+	f, err := os.CreateTemp(dir, ".parse-dmarc-check-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %w", dir, err)
+	}
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+	return nil
+}
+
+func printValidationReport(out output.Writer, report *validationReport) error {
+	_ = out.Text(fmt.Sprintf("Config: %s", report.Config))
+	for _, e := range report.Errors {
+		_ = out.Text("ERROR:   " + e)
+	}
+	for _, w := range report.Warnings {
+		_ = out.Text("WARNING: " + w)
+	}
+	if report.Valid {
+		_ = out.Text("Configuration is valid")
+	}
+	return out.JSON(report)
+}